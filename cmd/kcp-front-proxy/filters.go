@@ -49,6 +49,34 @@ func withOptionalClientCert(handler, failed http.Handler, auth authenticator.Req
 			failed.ServeHTTP(w, req)
 			return
 		}
+		// the token has served its purpose, it is not passed on to the shards.
+		req.Header.Del("Authorization")
+		req = req.WithContext(request.WithUser(req.Context(), resp.User))
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// withOptionalBearerToken creates a handler that authenticates a request's
+// bearer token if the request has not been authenticated by a client cert yet.
+// Requests with tokens that cannot be authenticated are passed through to the
+// next handler unchanged, such that the shard can authenticate them.
+func withOptionalBearerToken(handler http.Handler, auth authenticator.Request) http.Handler {
+	if auth == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := request.UserFrom(req.Context()); ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		resp, ok, err := auth.AuthenticateRequest(req)
+		if err != nil || !ok {
+			if err != nil {
+				klog.V(4).Infof("Unable to authenticate the request token, passing it on: %v", err)
+			}
+			handler.ServeHTTP(w, req)
+			return
+		}
 		req = req.WithContext(request.WithUser(req.Context(), resp.User))
 		handler.ServeHTTP(w, req)
 	})
@@ -32,6 +32,7 @@ import (
 	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
+	kubernetesclient "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	utilflag "k8s.io/component-base/cli/flag"
@@ -43,8 +44,10 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	kcpmetrics "github.com/kcp-dev/kcp/pkg/metrics"
 	"github.com/kcp-dev/kcp/pkg/proxy"
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
+	proxymetrics "github.com/kcp-dev/kcp/pkg/proxy/metrics"
 	"github.com/kcp-dev/kcp/pkg/server"
 	bootstrap "github.com/kcp-dev/kcp/pkg/server/bootstrap"
 	"github.com/kcp-dev/kcp/pkg/server/requestinfo"
@@ -76,7 +79,8 @@ func NewProxyCommand(ctx context.Context) *cobra.Command {
 		Long: `kcp-front-proxy is a reverse proxy that accepts client certificates and
 forwards Common Name and Organizations to backend API servers in HTTP headers.
 The proxy terminates TLS and communicates with API servers via mTLS. Traffic is
-routed based on paths.`,
+routed based on paths. Optionally, OIDC, webhook and kcp service account tokens
+are authenticated and forwarded in the same headers.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := options.Logs.ValidateAndApply(); err != nil {
 				return err
@@ -140,15 +144,30 @@ routed based on paths.`,
 
 			go indexController.Start(ctx, 2)
 
+			tokenAuthenticator, err := proxy.NewTokenAuthenticator(&options.Proxy.Authentication, indexController, func(shardURL string) (kubernetesclient.ClusterInterface, error) {
+				shardConfig := restclient.CopyConfig(rootShardConfig)
+				shardConfig.Host = shardURL
+				return kubernetesclient.NewClusterForConfig(shardConfig)
+			})
+			if err != nil {
+				return err
+			}
+
 			kcpSharedInformerFactory.Start(ctx.Done())
 			kcpSharedInformerFactory.WaitForCacheSync(ctx.Done())
 
+			if options.Proxy.MetricsBindAddress != "" {
+				proxymetrics.Register()
+				go kcpmetrics.Serve(ctx, options.Proxy.MetricsBindAddress)
+			}
+
 			// start the server
 			handler, err := proxy.NewHandler(&options.Proxy, indexController)
 			if err != nil {
 				return err
 			}
 			failedHandler := newUnauthorizedHandler()
+			handler = withOptionalBearerToken(handler, options.Authentication.FilterGroups(tokenAuthenticator))
 			handler = withOptionalClientCert(handler, failedHandler, authenticationInfo.Authenticator)
 
 			requestInfoFactory := requestinfo.NewFactory()
@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/request/x509"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	apiserveroptions "k8s.io/apiserver/pkg/server/options"
@@ -57,32 +58,38 @@ func (c *Authentication) ApplyTo(authenticationInfo *genericapiserver.Authentica
 		authenticationInfo.Authenticator = x509.NewDynamic(clientCAProvider.VerifyOptions, x509.CommonNameUserConversion)
 	}
 
-	// only pass on those groups to the shards we want
-	if len(c.PassOnGroups) > 0 || len(c.DropGroups) > 0 {
-		filter := &kcpauthentication.GroupFilter{
-			Authenticator: authenticationInfo.Authenticator,
-			PassOnGroups:  sets.NewString(),
-			DropGroups:    sets.NewString(),
-		}
-		authenticationInfo.Authenticator = filter
-
-		for _, g := range c.PassOnGroups {
-			if strings.HasSuffix(g, "*") {
-				filter.PassOnGroupPrefixes = append(filter.PassOnGroupPrefixes, g[:len(g)-1])
-			} else {
-				filter.PassOnGroups.Insert(g)
-			}
+	authenticationInfo.Authenticator = c.FilterGroups(authenticationInfo.Authenticator)
+
+	return nil
+}
+
+// FilterGroups wraps the given authenticator such that only those groups are passed on to the
+// shards we want. It returns nil for a nil authenticator.
+func (c *Authentication) FilterGroups(auth authenticator.Request) authenticator.Request {
+	if auth == nil || (len(c.PassOnGroups) == 0 && len(c.DropGroups) == 0) {
+		return auth
+	}
+
+	filter := &kcpauthentication.GroupFilter{
+		Authenticator: auth,
+		PassOnGroups:  sets.NewString(),
+		DropGroups:    sets.NewString(),
+	}
+	for _, g := range c.PassOnGroups {
+		if strings.HasSuffix(g, "*") {
+			filter.PassOnGroupPrefixes = append(filter.PassOnGroupPrefixes, g[:len(g)-1])
+		} else {
+			filter.PassOnGroups.Insert(g)
 		}
-		for _, g := range c.DropGroups {
-			if strings.HasSuffix(g, "*") {
-				filter.DropGroupPrefixes = append(filter.DropGroupPrefixes, g[:len(g)-1])
-			} else {
-				filter.DropGroups.Insert(g)
-			}
+	}
+	for _, g := range c.DropGroups {
+		if strings.HasSuffix(g, "*") {
+			filter.DropGroupPrefixes = append(filter.DropGroupPrefixes, g[:len(g)-1])
+		} else {
+			filter.DropGroups.Insert(g)
 		}
 	}
-
-	return nil
+	return filter
 }
 
 // AddFlags delegates to ClientCertAuthenticationOptions
@@ -56,12 +56,10 @@ func startFrontProxy(ctx context.Context, args []string, servingCA *crypto.CA, h
 
 	if err := ioutil.WriteFile(".kcp-front-proxy/mapping.yaml", []byte(`
 - path: /services/
-  backend: https://localhost:6444
   backend_server_ca: .kcp/serving-ca.crt
   proxy_client_cert: .kcp-front-proxy/requestheader.crt
   proxy_client_key: .kcp-front-proxy/requestheader.key
 - path: /clusters/
-  backend: https://localhost:6444
   backend_server_ca: .kcp/serving-ca.crt
   proxy_client_cert: .kcp-front-proxy/requestheader.crt
   proxy_client_key: .kcp-front-proxy/requestheader.key
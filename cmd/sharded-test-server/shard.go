@@ -73,6 +73,7 @@ func startShard(ctx context.Context, n int, args []string, servingCA *crypto.CA,
 		"--requestheader-client-ca-file=.kcp/requestheader-ca.crt",
 		"--requestheader-username-headers=X-Remote-User",
 		"--requestheader-group-headers=X-Remote-Group",
+		"--requestheader-extra-headers-prefix=X-Remote-Extra-",
 		"--service-account-key-file=.kcp/service-account.crt",
 		"--service-account-private-key-file=.kcp/service-account.key",
 		"--audit-log-path", auditFilePath,
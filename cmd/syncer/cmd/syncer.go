@@ -29,7 +29,10 @@ import (
 	"k8s.io/klog/v2"
 
 	synceroptions "github.com/kcp-dev/kcp/cmd/syncer/options"
+	kcpmetrics "github.com/kcp-dev/kcp/pkg/metrics"
 	"github.com/kcp-dev/kcp/pkg/syncer"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
+	"github.com/kcp-dev/kcp/pkg/syncer/spec"
 )
 
 const numThreads = 2
@@ -101,14 +104,32 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 	toConfig.QPS = options.QPS
 	toConfig.Burst = options.Burst
 
+	if options.MetricsBindAddress != "" {
+		metrics.Register()
+		go kcpmetrics.Serve(ctx, options.MetricsBindAddress)
+	}
+
 	if err := syncer.StartSyncer(
 		ctx,
 		&syncer.SyncerConfig{
-			UpstreamConfig:   kcpConfig,
-			DownstreamConfig: toConfig,
-			ResourcesToSync:  sets.NewString(options.SyncedResourceTypes...),
-			KCPClusterName:   logicalcluster.New(options.FromClusterName),
-			SyncTargetName:   options.PclusterID,
+			UpstreamConfig:               kcpConfig,
+			DownstreamConfig:             toConfig,
+			ResourcesToSync:              sets.NewString(options.SyncedResourceTypes...),
+			KCPClusterName:               logicalcluster.New(options.FromClusterName),
+			SyncTargetName:               options.PclusterID,
+			ClusterScopedResourcesToSync: sets.NewString(options.SyncedClusterScopedResourceTypes...),
+			NamespaceOptions: spec.NamespaceOptions{
+				LabelAllowlist:      options.NamespaceLabelAllowlist,
+				AnnotationAllowlist: options.NamespaceAnnotationAllowlist,
+			},
+			BackoffOptions: syncer.BackoffOptions{
+				HeartbeatInterval:       options.HeartbeatInterval,
+				DiscoveryPollInterval:   options.DiscoveryPollInterval,
+				Jitter:                  options.BackoffJitter,
+				RetryInitialInterval:    options.RetryInitialInterval,
+				RetryMaxInterval:        options.RetryMaxInterval,
+				MaxConcurrentReconnects: options.MaxConcurrentReconnects,
+			},
 		},
 		numThreads,
 		options.APIImportPollInterval,
@@ -27,6 +27,7 @@ import (
 	"k8s.io/component-base/logs"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer"
 )
 
 type Options struct {
@@ -41,7 +42,32 @@ type Options struct {
 	Logs                *logs.Options
 	SyncedResourceTypes []string
 
+	// SyncedClusterScopedResourceTypes are the cluster-scoped resource types opted in for syncing.
+	SyncedClusterScopedResourceTypes []string
+
+	// NamespaceLabelAllowlist and NamespaceAnnotationAllowlist are the keys of the labels and annotations
+	// of upstream namespaces that are propagated to the downstream namespaces.
+	NamespaceLabelAllowlist      []string
+	NamespaceAnnotationAllowlist []string
+
 	APIImportPollInterval time.Duration
+
+	// HeartbeatInterval, DiscoveryPollInterval and BackoffJitter configure how often the syncer
+	// heartbeats and polls the discovery of the syncer virtual workspace.
+	HeartbeatInterval     time.Duration
+	DiscoveryPollInterval time.Duration
+	BackoffJitter         float64
+
+	// RetryInitialInterval and RetryMaxInterval bound the exponential backoff of failed
+	// heartbeats, discoveries and syncer virtual workspace connections.
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+
+	// MaxConcurrentReconnects limits the number of syncer virtual workspaces connected to at once.
+	MaxConcurrentReconnects int
+
+	// MetricsBindAddress is the address the Prometheus metrics are served on. Metrics are not served if empty.
+	MetricsBindAddress string
 }
 
 func NewOptions() *Options {
@@ -49,12 +75,24 @@ func NewOptions() *Options {
 	logs := logs.NewOptions()
 	logs.Config.Verbosity = config.VerbosityLevel(2)
 
+	backoff := syncer.DefaultBackoffOptions()
+
 	return &Options{
-		QPS:                   30,
-		Burst:                 20,
-		SyncedResourceTypes:   []string{},
-		Logs:                  logs,
-		APIImportPollInterval: 1 * time.Minute,
+		QPS:                              30,
+		Burst:                            20,
+		SyncedResourceTypes:              []string{},
+		SyncedClusterScopedResourceTypes: []string{},
+		NamespaceLabelAllowlist:          []string{},
+		NamespaceAnnotationAllowlist:     []string{},
+		Logs:                             logs,
+		APIImportPollInterval:            1 * time.Minute,
+		HeartbeatInterval:                backoff.HeartbeatInterval,
+		DiscoveryPollInterval:            backoff.DiscoveryPollInterval,
+		BackoffJitter:                    backoff.Jitter,
+		RetryInitialInterval:             backoff.RetryInitialInterval,
+		RetryMaxInterval:                 backoff.RetryMaxInterval,
+		MaxConcurrentReconnects:          backoff.MaxConcurrentReconnects,
+		MetricsBindAddress:               ":8080",
 	}
 }
 
@@ -69,7 +107,22 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&options.PclusterID, "sync-target-name", options.PclusterID,
 		fmt.Sprintf("ID of the -to cluster. Resources with this ID set in the '%s' label will be synced.", workloadv1alpha1.ClusterResourceStateLabelPrefix+"<ClusterID>"))
 	fs.StringArrayVarP(&options.SyncedResourceTypes, "resources", "r", options.SyncedResourceTypes, "Resources to be synchronized in kcp.")
+	fs.StringArrayVar(&options.SyncedClusterScopedResourceTypes, "cluster-scoped-resources", options.SyncedClusterScopedResourceTypes,
+		"Cluster-scoped resources to be synchronized in kcp. Cluster-scoped resources are not synchronized unless listed here.")
+	fs.StringSliceVar(&options.NamespaceLabelAllowlist, "namespace-label-allowlist", options.NamespaceLabelAllowlist,
+		"Keys of the upstream namespace labels propagated to the downstream namespaces. A key ending with '*' matches all keys with that prefix.")
+	fs.StringSliceVar(&options.NamespaceAnnotationAllowlist, "namespace-annotation-allowlist", options.NamespaceAnnotationAllowlist,
+		"Keys of the upstream namespace annotations propagated to the downstream namespaces. A key ending with '*' matches all keys with that prefix.")
 	fs.DurationVar(&options.APIImportPollInterval, "api-import-poll-interval", options.APIImportPollInterval, "Polling interval for API import.")
+	fs.DurationVar(&options.HeartbeatInterval, "heartbeat-interval", options.HeartbeatInterval, "Interval between the heartbeats of the syncer.")
+	fs.DurationVar(&options.DiscoveryPollInterval, "discovery-poll-interval", options.DiscoveryPollInterval, "Polling interval for the discovery of the synced resource types.")
+	fs.Float64Var(&options.BackoffJitter, "backoff-jitter", options.BackoffJitter,
+		"Jitter factor added to the heartbeat and discovery poll intervals, e.g. 0.2 adds up to 20%. 0 disables jitter.")
+	fs.DurationVar(&options.RetryInitialInterval, "retry-initial-interval", options.RetryInitialInterval, "Initial interval of the exponential backoff of failed requests.")
+	fs.DurationVar(&options.RetryMaxInterval, "retry-max-interval", options.RetryMaxInterval, "Maximum interval of the exponential backoff of failed requests.")
+	fs.IntVar(&options.MaxConcurrentReconnects, "max-concurrent-reconnects", options.MaxConcurrentReconnects,
+		"Maximum number of syncer virtual workspaces connected to concurrently. 0 means unlimited.")
+	fs.StringVar(&options.MetricsBindAddress, "metrics-bind-address", options.MetricsBindAddress, "Address to serve the Prometheus metrics on, e.g. :8080. Metrics are not served if empty.")
 
 	options.Logs.AddFlags(fs)
 }
@@ -85,6 +138,15 @@ func (options *Options) Validate() error {
 	if options.FromKubeconfig == "" {
 		return errors.New("--from-kubeconfig is required")
 	}
+	if options.BackoffJitter < 0 {
+		return errors.New("--backoff-jitter must not be negative")
+	}
+	if options.RetryInitialInterval > options.RetryMaxInterval {
+		return errors.New("--retry-initial-interval must not be greater than --retry-max-interval")
+	}
+	if options.MaxConcurrentReconnects < 0 {
+		return errors.New("--max-concurrent-reconnects must not be negative")
+	}
 
 	return nil
 }
@@ -52,14 +52,16 @@ func Register(plugins *admission.Plugins) {
 type clusterWorkspaceShard struct {
 	*admission.Handler
 
-	shardBaseURL            string
-	externalAddressProvider func() string
+	shardBaseURL             string
+	shardVirtualWorkspaceURL string
+	externalAddressProvider  func() string
 }
 
 // Ensure that the required admission interfaces are implemented.
 var _ = admission.ValidationInterface(&clusterWorkspaceShard{})
 var _ = admission.MutationInterface(&clusterWorkspaceShard{})
 var _ = initializers.WantsExternalAddressProvider(&clusterWorkspaceShard{})
+var _ = initializers.WantsShardVirtualWorkspaceURL(&clusterWorkspaceShard{})
 
 // Validate ensures that
 // - baseURL is set
@@ -88,7 +90,8 @@ func (o *clusterWorkspaceShard) Validate(ctx context.Context, a admission.Attrib
 	return nil
 }
 
-// Admit defaults the baseURL and externalURL to the shards external hostname.
+// Admit defaults the baseURL to the shards external hostname, the externalURL to the baseURL
+// and the virtualWorkspaceURL to the stand-alone virtual workspace apiserver or the baseURL.
 func (o *clusterWorkspaceShard) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaceshards") {
 		return nil
@@ -124,6 +127,14 @@ func (o *clusterWorkspaceShard) Admit(ctx context.Context, a admission.Attribute
 		cws.Spec.ExternalURL = cws.Spec.BaseURL
 	}
 
+	if cws.Spec.VirtualWorkspaceURL == "" {
+		if o.shardVirtualWorkspaceURL != "" {
+			cws.Spec.VirtualWorkspaceURL = o.shardVirtualWorkspaceURL
+		} else {
+			cws.Spec.VirtualWorkspaceURL = cws.Spec.BaseURL
+		}
+	}
+
 	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cws)
 	if err != nil {
 		return err
@@ -137,6 +148,10 @@ func (o *clusterWorkspaceShard) SetShardBaseURL(shardBaseURL string) {
 	o.shardBaseURL = shardBaseURL
 }
 
+func (o *clusterWorkspaceShard) SetShardVirtualWorkspaceURL(shardVirtualWorkspaceURL string) {
+	o.shardVirtualWorkspaceURL = shardVirtualWorkspaceURL
+}
+
 func (o *clusterWorkspaceShard) SetExternalAddressProvider(externalAddressProvider func() string) {
 	o.externalAddressProvider = externalAddressProvider
 }
@@ -74,6 +74,7 @@ func TestAdmit(t *testing.T) {
 		a                         admission.Attributes
 		emptyExternalAddress      bool
 		noExternalAddressProvider bool
+		shardVirtualWorkspaceURL  string
 		expectedObj               runtime.Object
 		wantErr                   bool
 	}{
@@ -102,8 +103,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://boston2.kcp.dev",
-					ExternalURL: "https://kcp2.dev",
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://kcp2.dev",
+					VirtualWorkspaceURL: "https://boston2.kcp.dev",
 				},
 			},
 		},
@@ -123,8 +125,53 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://boston2.kcp.dev",
-					ExternalURL: "https://kcp2.dev",
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://kcp2.dev",
+					VirtualWorkspaceURL: "https://boston2.kcp.dev",
+				},
+			},
+		},
+		{
+			name: "does not default virtualWorkspaceURL when set",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:             "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://virtual.boston2.kcp.dev",
+				},
+			}),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://virtual.boston2.kcp.dev",
+				},
+			},
+		},
+		{
+			name: "default virtualWorkspaceURL to the stand-alone virtual workspace apiserver",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL: "https://boston2.kcp.dev",
+				},
+			}),
+			shardVirtualWorkspaceURL: "https://virtual.kcp.dev",
+			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://virtual.kcp.dev",
 				},
 			},
 		},
@@ -152,8 +199,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://boston2.kcp.dev",
-					ExternalURL: "https://boston2.kcp.dev",
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://boston2.kcp.dev",
 				},
 			},
 		},
@@ -172,8 +220,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://boston2.kcp.dev",
-					ExternalURL: "https://boston2.kcp.dev",
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://boston2.kcp.dev",
 				},
 			},
 		},
@@ -201,8 +250,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://external.kcp.dev",
-					ExternalURL: "https://kcp.dev",
+					BaseURL:             "https://external.kcp.dev",
+					ExternalURL:         "https://kcp.dev",
+					VirtualWorkspaceURL: "https://external.kcp.dev",
 				},
 			},
 		},
@@ -221,8 +271,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://external.kcp.dev",
-					ExternalURL: "https://kcp.dev",
+					BaseURL:             "https://external.kcp.dev",
+					ExternalURL:         "https://kcp.dev",
+					VirtualWorkspaceURL: "https://external.kcp.dev",
 				},
 			},
 		},
@@ -248,8 +299,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://external.kcp.dev",
-					ExternalURL: "https://external.kcp.dev",
+					BaseURL:             "https://external.kcp.dev",
+					ExternalURL:         "https://external.kcp.dev",
+					VirtualWorkspaceURL: "https://external.kcp.dev",
 				},
 			},
 		},
@@ -266,8 +318,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://external.kcp.dev",
-					ExternalURL: "https://external.kcp.dev",
+					BaseURL:             "https://external.kcp.dev",
+					ExternalURL:         "https://external.kcp.dev",
+					VirtualWorkspaceURL: "https://external.kcp.dev",
 				},
 			},
 		},
@@ -328,8 +381,9 @@ func TestAdmit(t *testing.T) {
 					Name: "test",
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-					BaseURL:     "https://boston2.kcp.dev",
-					ExternalURL: "https://boston2.kcp.dev",
+					BaseURL:             "https://boston2.kcp.dev",
+					ExternalURL:         "https://boston2.kcp.dev",
+					VirtualWorkspaceURL: "https://boston2.kcp.dev",
 				},
 			},
 		},
@@ -393,8 +447,9 @@ func TestAdmit(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			o := &clusterWorkspaceShard{
-				Handler:                 admission.NewHandler(admission.Create, admission.Update),
-				externalAddressProvider: func() string { return "external.kcp.dev" },
+				Handler:                  admission.NewHandler(admission.Create, admission.Update),
+				externalAddressProvider:  func() string { return "external.kcp.dev" },
+				shardVirtualWorkspaceURL: tt.shardVirtualWorkspaceURL,
 			}
 			if tt.noExternalAddressProvider {
 				o.externalAddressProvider = nil
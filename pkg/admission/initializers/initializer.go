@@ -121,3 +121,21 @@ func (i *shardBaseURLInitializer) Initialize(plugin admission.Interface) {
 		wants.SetShardBaseURL(i.shardBaseURL)
 	}
 }
+
+// NewShardVirtualWorkspaceURLInitializer returns an admission plugin initializer that injects
+// the default shard virtual workspace URL into the admission plugin.
+func NewShardVirtualWorkspaceURLInitializer(shardVirtualWorkspaceURL string) *shardVirtualWorkspaceURLInitializer {
+	return &shardVirtualWorkspaceURLInitializer{
+		shardVirtualWorkspaceURL: shardVirtualWorkspaceURL,
+	}
+}
+
+type shardVirtualWorkspaceURLInitializer struct {
+	shardVirtualWorkspaceURL string
+}
+
+func (i *shardVirtualWorkspaceURLInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsShardVirtualWorkspaceURL); ok {
+		wants.SetShardVirtualWorkspaceURL(i.shardVirtualWorkspaceURL)
+	}
+}
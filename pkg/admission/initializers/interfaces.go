@@ -52,3 +52,9 @@ type WantsExternalAddressProvider interface {
 type WantsShardBaseURL interface {
 	SetShardBaseURL(shardBaseURL string)
 }
+
+// WantsShardVirtualWorkspaceURL interface should be implemented by admission plugins
+// that want to have the default shard virtual workspace url injected.
+type WantsShardVirtualWorkspaceURL interface {
+	SetShardVirtualWorkspaceURL(shardVirtualWorkspaceURL string)
+}
@@ -54,6 +54,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdannotations"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdgroups"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedmetadata"
+	"github.com/kcp-dev/kcp/pkg/admission/synctargetoverride"
 	kcpvalidatingwebhook "github.com/kcp-dev/kcp/pkg/admission/validatingwebhook"
 )
 
@@ -75,6 +76,7 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	crdnooverlappinggvr.PluginName,
 	reservedmetadata.PluginName,
 	permissionclaims.PluginName,
+	synctargetoverride.PluginName,
 )
 
 func beforeWebhooks(recommended []string, plugins ...string) []string {
@@ -108,6 +110,7 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	crdnooverlappinggvr.Register(plugins)
 	reservedmetadata.Register(plugins)
 	permissionclaims.Register(plugins)
+	synctargetoverride.Register(plugins)
 }
 
 var defaultOnPluginsInKcp = sets.NewString(
@@ -131,6 +134,7 @@ var defaultOnPluginsInKcp = sets.NewString(
 	reservedcrdannotations.PluginName,
 	reservedcrdgroups.PluginName,
 	permissionclaims.PluginName,
+	synctargetoverride.PluginName,
 )
 
 // defaultOnKubePluginsInKube is a copy of kubeapiserveroptions.defaultOnKubePlugins.
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetoverride
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+const (
+	PluginName = "workload.kcp.dev/SyncTargetOverride"
+)
+
+// Register registers the SyncTargetOverride plugin for creation and updates.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &syncTargetOverride{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// syncTargetOverride is a validating admission plugin validating the spec overrides of SyncTargetOverrides,
+// beyond what the schema of the resource can express.
+type syncTargetOverride struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&syncTargetOverride{})
+
+// Validate validates a SyncTargetOverride for create and update.
+func (o *syncTargetOverride) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != workloadv1alpha1.Resource("synctargetoverrides") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	override := &workloadv1alpha1.SyncTargetOverride{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, override); err != nil {
+		return fmt.Errorf("failed to convert unstructured to SyncTargetOverride: %w", err)
+	}
+
+	if errs := ValidateSyncTargetOverride(override); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
+	return nil
+}
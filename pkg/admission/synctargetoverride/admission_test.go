@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetoverride
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func createAttr(override *workloadv1alpha1.SyncTargetOverride, resourceName, subresource string) admission.Attributes {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(override)
+	if err != nil {
+		panic(err)
+	}
+	return admission.NewAttributesRecord(
+		&unstructured.Unstructured{Object: u},
+		nil,
+		workloadv1alpha1.Kind("SyncTargetOverride").WithVersion("v1alpha1"),
+		"",
+		override.Name,
+		workloadv1alpha1.Resource(resourceName).WithVersion("v1alpha1"),
+		subresource,
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newSyncTargetOverride(overrides workloadv1alpha1.SpecOverrides) *workloadv1alpha1.SyncTargetOverride {
+	return &workloadv1alpha1.SyncTargetOverride{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: workloadv1alpha1.SyncTargetOverrideSpec{
+			Location:  "us-east1",
+			Overrides: overrides,
+		},
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		attr        admission.Attributes
+		wantErr     bool
+		wantMessage string
+	}{
+		{
+			name: "valid spec overrides",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				Replicas:             int32Ptr(2),
+				ImageRegistryMirrors: map[string]string{"docker.io": "mirror.example.com:5000/docker"},
+				NodeSelector:         map[string]string{"disktype": "ssd"},
+				Containers: []workloadv1alpha1.ContainerOverride{{
+					Name: "app",
+					Resources: &corev1.ResourceRequirements{
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					},
+				}},
+			}), "synctargetoverrides", ""),
+		},
+		{
+			name: "missing location",
+			attr: func() admission.Attributes {
+				override := newSyncTargetOverride(workloadv1alpha1.SpecOverrides{})
+				override.Spec.Location = ""
+				return createAttr(override, "synctargetoverrides", "")
+			}(),
+			wantErr:     true,
+			wantMessage: "spec.location",
+		},
+		{
+			name: "invalid object selector",
+			attr: func() admission.Attributes {
+				override := newSyncTargetOverride(workloadv1alpha1.SpecOverrides{})
+				override.Spec.ObjectSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app name": "foo"}}
+				return createAttr(override, "synctargetoverrides", "")
+			}(),
+			wantErr:     true,
+			wantMessage: "spec.objectSelector",
+		},
+		{
+			name: "negative replicas",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				Replicas: int32Ptr(-1),
+			}), "synctargetoverrides", ""),
+			wantErr:     true,
+			wantMessage: "spec.overrides.replicas",
+		},
+		{
+			name: "invalid registry mirror",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				ImageRegistryMirrors: map[string]string{"docker.io": "https://mirror.example.com"},
+			}), "synctargetoverrides", ""),
+			wantErr:     true,
+			wantMessage: "spec.overrides.imageRegistryMirrors",
+		},
+		{
+			name: "invalid node selector",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				NodeSelector: map[string]string{"disk type": "ssd"},
+			}), "synctargetoverrides", ""),
+			wantErr:     true,
+			wantMessage: "spec.overrides.nodeSelector",
+		},
+		{
+			name: "duplicate container and request above the limit",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				Containers: []workloadv1alpha1.ContainerOverride{
+					{Name: "app"},
+					{
+						Name: "app",
+						Resources: &corev1.ResourceRequirements{
+							Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+							Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+					},
+				},
+			}), "synctargetoverrides", ""),
+			wantErr:     true,
+			wantMessage: "Duplicate value",
+		},
+		{
+			name: "other resources are ignored",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				Replicas: int32Ptr(-1),
+			}), "synctargets", ""),
+		},
+		{
+			name: "status subresource is ignored",
+			attr: createAttr(newSyncTargetOverride(workloadv1alpha1.SpecOverrides{
+				Replicas: int32Ptr(-1),
+			}), "synctargetoverrides", "status"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &syncTargetOverride{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			err := o.Validate(context.TODO(), tc.attr, nil)
+			if !tc.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantMessage)
+		})
+	}
+}
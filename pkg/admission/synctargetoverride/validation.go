@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetoverride
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// ValidateSyncTargetOverride validates a SyncTargetOverride.
+func ValidateSyncTargetOverride(override *workloadv1alpha1.SyncTargetOverride) field.ErrorList {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if override.Spec.Location == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("location"), ""))
+	}
+	if override.Spec.ObjectSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(override.Spec.ObjectSelector, specPath.Child("objectSelector"))...)
+	}
+	allErrs = append(allErrs, ValidateSpecOverrides(&override.Spec.Overrides, specPath.Child("overrides"))...)
+
+	return allErrs
+}
+
+// ValidateSpecOverrides validates spec overrides.
+func ValidateSpecOverrides(overrides *workloadv1alpha1.SpecOverrides, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if overrides.Replicas != nil && *overrides.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *overrides.Replicas, "must be greater than or equal to 0"))
+	}
+
+	for registry, mirror := range overrides.ImageRegistryMirrors {
+		allErrs = append(allErrs, validateRegistry(registry, fldPath.Child("imageRegistryMirrors").Key(registry))...)
+		allErrs = append(allErrs, validateRegistry(mirror, fldPath.Child("imageRegistryMirrors").Key(registry))...)
+	}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(overrides.NodeSelector, fldPath.Child("nodeSelector"))...)
+
+	names := sets.NewString()
+	for i, container := range overrides.Containers {
+		idxPath := fldPath.Child("containers").Index(i)
+		if container.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(container.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), container.Name))
+		}
+		names.Insert(container.Name)
+
+		if container.Resources != nil {
+			allErrs = append(allErrs, validateResourceRequirements(container.Resources, idxPath.Child("resources"))...)
+		}
+	}
+
+	return allErrs
+}
+
+func validateRegistry(registry string, fldPath *field.Path) field.ErrorList {
+	if registry == "" {
+		return field.ErrorList{field.Invalid(fldPath, registry, "must not be empty")}
+	}
+	if strings.Contains(registry, "://") || strings.ContainsAny(registry, " \t\n@") {
+		return field.ErrorList{field.Invalid(fldPath, registry, "must be a registry host, optionally followed by a port and a path")}
+	}
+	if strings.HasSuffix(registry, "/") {
+		return field.ErrorList{field.Invalid(fldPath, registry, "must not end with a slash")}
+	}
+	return nil
+}
+
+func validateResourceRequirements(requirements *corev1.ResourceRequirements, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for name, quantity := range requirements.Limits {
+		if quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("limits").Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+		}
+	}
+	for name, quantity := range requirements.Requests {
+		if quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requests").Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+		}
+		if limit, found := requirements.Limits[name]; found && quantity.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("requests").Key(string(name)), quantity.String(), "must be less than or equal to the limit"))
+		}
+	}
+	return allErrs
+}
@@ -81,6 +81,95 @@ type PlacementSpec struct {
 	// +optional
 	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	LocationWorkspace string `json:"locationWorkspace,omitempty"`
+
+	// locationAntiAffinity prevents this placement from selecting the same location, or a location in the
+	// same topology domain, as the other placements of the workspace it selects.
+	// +optional
+	LocationAntiAffinity *LocationAntiAffinity `json:"locationAntiAffinity,omitempty"`
+
+	// locationSpreadConstraints describe how the placements of the workspace are spread across the topology
+	// domains of the locations. A location is only selected if it satisfies all the constraints.
+	// +optional
+	LocationSpreadConstraints []LocationSpreadConstraint `json:"locationSpreadConstraints,omitempty"`
+
+	// syncTargets describes how many sync targets of the selected location a namespace bound to this
+	// placement is scheduled to. By default, a namespace is scheduled to one sync target.
+	// +optional
+	SyncTargets *SyncTargetSelection `json:"syncTargets,omitempty"`
+}
+
+// SyncTargetSelection describes how many sync targets of a location a namespace is scheduled to.
+type SyncTargetSelection struct {
+	// mode is Replicas to schedule a namespace to a fixed number of sync targets, or All to schedule it
+	// to all the ready sync targets of the location.
+	//
+	// +optional
+	// +kubebuilder:default=Replicas
+	// +kubebuilder:validation:Enum=Replicas;All
+	Mode SyncTargetSelectionMode `json:"mode,omitempty"`
+
+	// replicas is the number of sync targets a namespace is scheduled to in Replicas mode. If fewer sync
+	// targets are ready, the namespace is scheduled to all of them.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// SyncTargetSelectionMode is the mode of a SyncTargetSelection.
+type SyncTargetSelectionMode string
+
+const (
+	// SyncTargetSelectionReplicas schedules a namespace to a fixed number of sync targets of the location.
+	SyncTargetSelectionReplicas SyncTargetSelectionMode = "Replicas"
+
+	// SyncTargetSelectionAll schedules a namespace to all the ready sync targets of the location.
+	SyncTargetSelectionAll SyncTargetSelectionMode = "All"
+)
+
+// LocationAntiAffinity describes the placements that must not select the same location, or a location in
+// the same topology domain.
+type LocationAntiAffinity struct {
+	// placementSelector selects the other placements in the workspace of this placement that must not
+	// select the same location, or a location in the same topology domain, as this placement.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	PlacementSelector metav1.LabelSelector `json:"placementSelector"`
+
+	// topologyKey is the key of the location label whose values define the topology domains, e.g. region.
+	// If it is empty, every location is a topology domain of its own.
+	//
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// LocationSpreadConstraint describes how the placements of a workspace are spread across the topology
+// domains of the locations.
+type LocationSpreadConstraint struct {
+	// placementSelector selects the placements in the workspace of this placement that are counted in
+	// the topology domains.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	PlacementSelector metav1.LabelSelector `json:"placementSelector"`
+
+	// topologyKey is the key of the location label whose values define the topology domains, e.g. region.
+	// Locations without this label are not selected.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TopologyKey string `json:"topologyKey"`
+
+	// maxSkew is the maximum difference of the number of selected placements between any two topology
+	// domains, including this placement.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew int32 `json:"maxSkew,omitempty"`
 }
 
 type PlacementStatus struct {
@@ -149,6 +238,46 @@ const (
 	// LocationNotMatchReason is a reason for PlacementReady condition that no matched location for
 	// this placement can be found.
 	LocationNotMatchReason = "LocationNoMatch"
+
+	// PlacementLocationConstraintsSatisfied is a condition type for placement representing that the
+	// selected location satisfies the location anti-affinity and spread constraints of the placement.
+	// It is only set when the placement has such constraints.
+	PlacementLocationConstraintsSatisfied conditionsv1alpha1.ConditionType = "LocationConstraintsSatisfied"
+
+	// LocationConstraintsUnsatisfiableReason is a reason for the PlacementLocationConstraintsSatisfied and
+	// PlacementReady conditions that no valid location satisfies the constraints of the placement.
+	LocationConstraintsUnsatisfiableReason = "LocationConstraintsUnsatisfiable"
+
+	// LocationConstraintsViolatedReason is a reason for the PlacementLocationConstraintsSatisfied condition
+	// that the location selected by a bound placement does not satisfy its constraints anymore. The location
+	// is kept until the placement is unbound.
+	LocationConstraintsViolatedReason = "LocationConstraintsViolated"
+
+	// LocationConstraintsInvalidReason is a reason for the PlacementLocationConstraintsSatisfied and
+	// PlacementReady conditions that a placement selector of the location constraints is invalid. No location
+	// is selected until the constraints are fixed, but the location selected before is kept.
+	LocationConstraintsInvalidReason = "LocationConstraintsInvalid"
+)
+
+const (
+	// ExperimentalSpreadPolicyAnnotationKey is the annotation key on a placement selecting how the namespaces
+	// bound to the placement are spread across the sync targets of the selected location. The value is a
+	// SpreadPolicy.
+	ExperimentalSpreadPolicyAnnotationKey = "experimental.scheduling.kcp.dev/spread-policy"
+)
+
+// SpreadPolicy is the policy to spread the namespaces bound to a placement across the sync targets of the
+// selected location.
+type SpreadPolicy string
+
+const (
+	// SpreadPolicyNone schedules a namespace to the sync target with the most allocatable resources and the
+	// fewest namespaces. This is the default.
+	SpreadPolicyNone SpreadPolicy = "None"
+
+	// SpreadPolicyWorkspace additionally prefers the sync targets with the fewest namespaces of the workspace
+	// of the namespace.
+	SpreadPolicyWorkspace SpreadPolicy = "Workspace"
 )
 
 // PlacementList is a list of locations.
@@ -93,6 +93,23 @@ func (in *Location) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationAntiAffinity) DeepCopyInto(out *LocationAntiAffinity) {
+	*out = *in
+	in.PlacementSelector.DeepCopyInto(&out.PlacementSelector)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationAntiAffinity.
+func (in *LocationAntiAffinity) DeepCopy() *LocationAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocationList) DeepCopyInto(out *LocationList) {
 	*out = *in
@@ -171,6 +188,23 @@ func (in *LocationSpec) DeepCopy() *LocationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationSpreadConstraint) DeepCopyInto(out *LocationSpreadConstraint) {
+	*out = *in
+	in.PlacementSelector.DeepCopyInto(&out.PlacementSelector)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationSpreadConstraint.
+func (in *LocationSpreadConstraint) DeepCopy() *LocationSpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationSpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocationStatus) DeepCopyInto(out *LocationStatus) {
 	*out = *in
@@ -296,6 +330,23 @@ func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LocationAntiAffinity != nil {
+		in, out := &in.LocationAntiAffinity, &out.LocationAntiAffinity
+		*out = new(LocationAntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LocationSpreadConstraints != nil {
+		in, out := &in.LocationSpreadConstraints, &out.LocationSpreadConstraints
+		*out = make([]LocationSpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SyncTargets != nil {
+		in, out := &in.SyncTargets, &out.SyncTargets
+		*out = new(SyncTargetSelection)
+		**out = **in
+	}
 	return
 }
 
@@ -336,3 +387,19 @@ func (in *PlacementStatus) DeepCopy() *PlacementStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncTargetSelection) DeepCopyInto(out *SyncTargetSelection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncTargetSelection.
+func (in *SyncTargetSelection) DeepCopy() *SyncTargetSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncTargetSelection)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SpecOverrides are the overrides of the spec of an upstream object applied by the syncer
+// of a SyncTarget when syncing the object down. They are declared per Location by
+// SyncTargetOverrides.
+type SpecOverrides struct {
+	// Replicas overrides the replicas of the object.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ImageRegistryMirrors maps image registries to the registries replacing
+	// them in the images of the containers of the pod template.
+	// +optional
+	ImageRegistryMirrors map[string]string `json:"imageRegistryMirrors,omitempty"`
+
+	// NodeSelector is merged into the node selector of the pod template.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Containers overrides the containers of the pod template, by name.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Containers []ContainerOverride `json:"containers,omitempty"`
+}
+
+// ContainerOverride overrides a container of the pod template.
+type ContainerOverride struct {
+	// Name is the name of the container, or init container.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Resources replaces the resource requirements of the container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
@@ -92,6 +92,36 @@ type SyncTargetStatus struct {
 	// VirtualWorkspaces contains all syncer virtual workspace URLs.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// Eviction reports the progress of moving the namespaces off the SyncTarget
+	// after the EvictAfter time.
+	// +optional
+	Eviction *SyncTargetEviction `json:"eviction,omitempty"`
+}
+
+// SyncTargetEviction reports the progress of the eviction of the namespaces
+// scheduled to a SyncTarget.
+type SyncTargetEviction struct {
+	// StartTime is the time when the eviction started.
+	// +required
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is the time when no namespace was scheduled to the
+	// SyncTarget anymore.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// PendingNamespaces is the number of namespaces whose eviction has not
+	// started yet.
+	PendingNamespaces int32 `json:"pendingNamespaces"`
+
+	// EvictingNamespaces is the number of namespaces that are scheduled to
+	// another SyncTarget, and waiting for it to sync them.
+	EvictingNamespaces int32 `json:"evictingNamespaces"`
+
+	// RemovingNamespaces is the number of namespaces that are synced by
+	// another SyncTarget, and being removed from the SyncTarget.
+	RemovingNamespaces int32 `json:"removingNamespaces"`
 }
 
 type VirtualWorkspace struct {
@@ -136,6 +166,10 @@ const (
 	// SyncTargetUnreachableReason documents the SyncTarget state when the Syncer is unable to reach the SyncTarget "readyz" API endpoint
 	SyncTargetUnreachableReason = "SyncTargetUnreachable"
 
+	// SyncerStartingReason indicates that the Syncer is waiting for the syncer virtual workspace URLs,
+	// or is still starting syncing through some of them.
+	SyncerStartingReason = "SyncerStarting"
+
 	// ErrorStartingSyncerReason indicates that the Syncer failed to start.
 	ErrorStartingSyncerReason = "ErrorStartingSyncer"
 
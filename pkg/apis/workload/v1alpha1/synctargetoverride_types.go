@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SyncTargetOverride declares spec overrides applied by the syncers of the SyncTargets
+// of a Location when they sync objects down. It lives in the workspace of the Location.
+//
+// When several SyncTargetOverrides select an object, they are applied in the order of
+// their names.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Location",type="string",JSONPath=`.spec.location`,priority=1
+type SyncTargetOverride struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state.
+	// +required
+	// +kubebuilder:validation:Required
+	Spec SyncTargetOverrideSpec `json:"spec"`
+}
+
+// SyncTargetOverrideSpec holds the desired state of the SyncTargetOverride.
+type SyncTargetOverrideSpec struct {
+	// Location is the name of the Location whose SyncTargets apply the overrides.
+	// The Location must be in the same workspace as the SyncTargetOverride.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+
+	// ObjectSelector selects, by labels, the synced objects the overrides apply to.
+	// All the synced objects are selected if it is not set.
+	//
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// Overrides are the overrides applied to the spec of the selected objects.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Overrides SpecOverrides `json:"overrides"`
+}
+
+// SyncTargetOverrideList is a list of SyncTargetOverride resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SyncTargetOverrideList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SyncTargetOverride `json:"items"`
+}
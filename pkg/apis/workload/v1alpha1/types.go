@@ -43,6 +43,18 @@ const (
 	// TODO(sttts): use sync-target-uid instead of sync-target-name
 	InternalClusterDeletionTimestampAnnotationPrefix = "deletion.internal.workload.kcp.dev/"
 
+	// InternalClusterEvictionAnnotationPrefix is the prefix of the annotation
+	//
+	//   eviction.internal.workload.kcp.dev/<sync-target-name>
+	//
+	// on upstream namespaces storing the timestamp when the eviction of the namespace from
+	// an evicting sync target started. The namespace scheduler will then schedule the namespace
+	// to another sync target, and the eviction controller will set the deletion timestamp of
+	// the evicting sync target once the new sync target has synced the namespace.
+	//
+	// The format is RFC3339.
+	InternalClusterEvictionAnnotationPrefix = "eviction.internal.workload.kcp.dev/"
+
 	// ClusterFinalizerAnnotationPrefix is the prefix of the annotation
 	//
 	//   finalizers.workload.kcp.dev/<sync-target-name>
@@ -105,6 +117,27 @@ const (
 	// The format for the value of this annotation is: JSON Patch (https://tools.ietf.org/html/rfc6902).
 	ClusterSpecDiffAnnotationPrefix = "experimental.spec-diff.workload.kcp.dev/"
 
+	// InternalClusterDriftAnnotationPrefix is the prefix of the annotation
+	//
+	//   experimental.drift.workload.kcp.dev/<sync-target-name>
+	//
+	// on upstream resources recording the last time the syncer corrected a modification of the
+	// downstream resource made by another field manager than the syncer, and which field managers
+	// made it.
+	//
+	// The format is JSON.
+	InternalClusterDriftAnnotationPrefix = "experimental.drift.workload.kcp.dev/"
+
+	// InternalClusterSyncStatusAnnotationPrefix is the prefix of the annotation
+	//
+	//   experimental.sync-status.workload.kcp.dev/<sync-target-name>
+	//
+	// on upstream resources storing the generation the syncer last attempted to sync to the
+	// sync target, and the reason and message of the failure if the sync failed.
+	//
+	// The format is JSON.
+	InternalClusterSyncStatusAnnotationPrefix = "experimental.sync-status.workload.kcp.dev/"
+
 	// InternalDownstreamClusterLabel is a label with the upstream cluster name applied on the downstream cluster
 	// instead of state.workload.kcp.dev/<sync-target-name> which is used upstream.
 	InternalDownstreamClusterLabel = "internal.workload.kcp.dev/cluster"
@@ -33,6 +33,10 @@ func (c *FakeWorkloadV1alpha1) SyncTargets() v1alpha1.SyncTargetInterface {
 	return &FakeSyncTargets{c}
 }
 
+func (c *FakeWorkloadV1alpha1) SyncTargetOverrides() v1alpha1.SyncTargetOverrideInterface {
+	return &FakeSyncTargetOverrides{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeWorkloadV1alpha1) RESTClient() rest.Interface {
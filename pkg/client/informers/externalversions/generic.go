@@ -93,6 +93,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		// Group=workload.kcp.dev, Version=v1alpha1
 	case workloadv1alpha1.SchemeGroupVersion.WithResource("synctargets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().SyncTargets().Informer()}, nil
+	case workloadv1alpha1.SchemeGroupVersion.WithResource("synctargetoverrides"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().SyncTargetOverrides().Informer()}, nil
 
 	}
 
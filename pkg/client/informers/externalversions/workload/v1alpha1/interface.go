@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// SyncTargets returns a SyncTargetInformer.
 	SyncTargets() SyncTargetInformer
+	// SyncTargetOverrides returns a SyncTargetOverrideInformer.
+	SyncTargetOverrides() SyncTargetOverrideInformer
 }
 
 type version struct {
@@ -43,3 +45,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) SyncTargets() SyncTargetInformer {
 	return &syncTargetInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// SyncTargetOverrides returns a SyncTargetOverrideInformer.
+func (v *version) SyncTargetOverrides() SyncTargetOverrideInformer {
+	return &syncTargetOverrideInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
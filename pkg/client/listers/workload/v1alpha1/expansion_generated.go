@@ -21,3 +21,7 @@ package v1alpha1
 // SyncTargetListerExpansion allows custom methods to be added to
 // SyncTargetLister.
 type SyncTargetListerExpansion interface{}
+
+// SyncTargetOverrideListerExpansion allows custom methods to be added to
+// SyncTargetOverrideLister.
+type SyncTargetOverrideListerExpansion interface{}
@@ -19,6 +19,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -26,6 +27,7 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/kcp-dev/kcp/pkg/cliplugins/workload/plugin"
+	"github.com/kcp-dev/kcp/pkg/syncer"
 )
 
 var (
@@ -36,6 +38,9 @@ var (
 
 	# Directly apply the manifest
 	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> -o - | KUBECONFIG=<pcluster-config> kubectl apply -f -
+
+	# Upgrade the image of an existing syncer, rotate its token, and preview the changes in the physical cluster
+	%[1]s workload sync <sync-target-name> --syncer-image <new-kcp-syncer-image> --update --rotate-token --to-kubeconfig <pcluster-config> --dry-run
 `
 	cordonExample = `
 	# Mark a sync target as unschedulable.
@@ -48,6 +53,20 @@ var (
 	drainExample = `
 	# Start draining a sync target in preparation for maintenance.
 	%[1]s workload drain <sync-target-name>
+
+	# Drain a sync target and wait until all the objects are evicted from it.
+	%[1]s workload drain <sync-target-name> --wait --timeout=30m
+
+	# Drain a sync target and wait until the objects of some of the workspaces using it are evicted.
+	%[1]s workload drain <sync-target-name> --wait --workspaces=root:org:ws1,root:org:ws2
+`
+
+	statusExample = `
+	# Show the health of all the sync targets of the current workspace.
+	%[1]s workload status
+
+	# Show the health of a sync target, with all the details, as YAML.
+	%[1]s workload status <sync-target-name> -o yaml
 `
 )
 
@@ -75,14 +94,26 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	requiredResourcesToSync := sets.NewString("deployments.apps", "secrets", "configmaps", "serviceaccounts")
 
 	var (
-		userResourcesToSync []string
-		syncerImage         string
-		replicas            = 1
-		outputFile          string
-		downstreamNamespace string
-		kcpNamespace                = "default"
-		qps                 float32 = 30
-		burst                       = 20
+		userResourcesToSync          []string
+		clusterScopedResourcesToSync []string
+		syncerImage                  string
+		replicas                     = 1
+		outputFile                   string
+		downstreamNamespace          string
+		kcpNamespace                         = "default"
+		qps                          float32 = 30
+		burst                                = 20
+		syncerBackoff                        = syncer.DefaultBackoffOptions()
+		backoff                              = plugin.SyncerBackoffOptions{
+			HeartbeatInterval:       syncerBackoff.HeartbeatInterval,
+			DiscoveryPollInterval:   syncerBackoff.DiscoveryPollInterval,
+			Jitter:                  syncerBackoff.Jitter,
+			RetryInitialInterval:    syncerBackoff.RetryInitialInterval,
+			RetryMaxInterval:        syncerBackoff.RetryMaxInterval,
+			MaxConcurrentReconnects: syncerBackoff.MaxConcurrentReconnects,
+		}
+		metricsPort = 8080
+		update      plugin.SyncUpdateOptions
 	)
 
 	enableSyncerCmd := &cobra.Command{
@@ -118,8 +149,14 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 				// TODO: relax when we have leader-election in the syncer
 				return errors.New("only 0 and 1 are allowed as --replicas values")
 			}
-			if len(outputFile) == 0 {
-				return errors.New("a value must be specified for --output-file")
+			if metricsPort < 0 || metricsPort > 65535 {
+				return errors.New("a value between 0 and 65535 must be specified for --metrics-port")
+			}
+			if len(outputFile) == 0 && len(update.ToKubeconfig) == 0 {
+				return errors.New("a value must be specified for --output-file or --to-kubeconfig")
+			}
+			if update.DryRun && len(update.ToKubeconfig) == 0 {
+				return errors.New("--dry-run requires --to-kubeconfig")
 			}
 
 			syncTargetName := args[0]
@@ -137,13 +174,18 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 				downstreamNamespace,
 				syncerImage,
 				resourcesToSync,
+				sets.NewString(clusterScopedResourcesToSync...).List(),
 				replicas,
 				qps,
 				burst,
+				backoff,
+				metricsPort,
+				update,
 			)
 		},
 	}
 	enableSyncerCmd.Flags().StringSliceVar(&userResourcesToSync, "resources", userResourcesToSync, "Resources to synchronize with kcp.")
+	enableSyncerCmd.Flags().StringSliceVar(&clusterScopedResourcesToSync, "cluster-scoped-resources", clusterScopedResourcesToSync, "Cluster-scoped resources to synchronize with kcp. Cluster-scoped resources are not synchronized unless listed here.")
 	enableSyncerCmd.Flags().StringVar(&syncerImage, "syncer-image", syncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer.")
 	enableSyncerCmd.Flags().IntVar(&replicas, "replicas", replicas, "Number of replicas of the syncer deployment.")
 	enableSyncerCmd.Flags().StringVar(&kcpNamespace, "kcp-namespace", kcpNamespace, "The name of the kcp namespace to create a service account in.")
@@ -151,6 +193,18 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	enableSyncerCmd.Flags().StringVarP(&downstreamNamespace, "namespace", "n", downstreamNamespace, "The namespace to create the syncer in in the physical cluster. By default this is \"kcp-syncer-<synctarget-name>-<uid>\".")
 	enableSyncerCmd.Flags().Float32Var(&qps, "qps", qps, "QPS to use when talking to API servers.")
 	enableSyncerCmd.Flags().IntVar(&burst, "burst", burst, "Burst to use when talking to API servers.")
+	enableSyncerCmd.Flags().DurationVar(&backoff.HeartbeatInterval, "heartbeat-interval", backoff.HeartbeatInterval, "Interval between the heartbeats of the syncer.")
+	enableSyncerCmd.Flags().DurationVar(&backoff.DiscoveryPollInterval, "discovery-poll-interval", backoff.DiscoveryPollInterval, "Polling interval for the discovery of the synced resource types.")
+	enableSyncerCmd.Flags().Float64Var(&backoff.Jitter, "backoff-jitter", backoff.Jitter, "Jitter factor added to the heartbeat and discovery poll intervals of the syncer. 0 disables jitter.")
+	enableSyncerCmd.Flags().DurationVar(&backoff.RetryInitialInterval, "retry-initial-interval", backoff.RetryInitialInterval, "Initial interval of the exponential backoff of the syncer's failed requests.")
+	enableSyncerCmd.Flags().DurationVar(&backoff.RetryMaxInterval, "retry-max-interval", backoff.RetryMaxInterval, "Maximum interval of the exponential backoff of the syncer's failed requests.")
+	enableSyncerCmd.Flags().IntVar(&backoff.MaxConcurrentReconnects, "max-concurrent-reconnects", backoff.MaxConcurrentReconnects, "Maximum number of syncer virtual workspaces the syncer connects to concurrently. 0 means unlimited.")
+	enableSyncerCmd.Flags().IntVar(&metricsPort, "metrics-port", metricsPort, "Port the syncer serves its Prometheus metrics on. 0 disables the metrics endpoint.")
+	enableSyncerCmd.Flags().BoolVar(&update.Update, "update", update.Update, "Upgrade the syncer of an existing sync target. Only the resources that change with the upgrade are output or applied.")
+	enableSyncerCmd.Flags().BoolVar(&update.RotateToken, "rotate-token", update.RotateToken, "Replace the token the syncer uses to connect to kcp by a new one.")
+	enableSyncerCmd.Flags().StringVar(&update.ToKubeconfig, "to-kubeconfig", update.ToKubeconfig, "Kubeconfig file of the physical cluster. If set, the syncer resources are applied to the physical cluster directly.")
+	enableSyncerCmd.Flags().StringVar(&update.ToContext, "to-context", update.ToContext, "Context to use in the --to-kubeconfig file, instead of the current context.")
+	enableSyncerCmd.Flags().BoolVar(&update.DryRun, "dry-run", update.DryRun, "With --to-kubeconfig, only report how the syncer resources would change in the physical cluster.")
 
 	cmd.AddCommand(enableSyncerCmd)
 
@@ -209,6 +263,12 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	cmd.AddCommand(uncordonCmd)
 
 	// drain
+	var (
+		drainWait         bool
+		drainWorkspaces   []string
+		drainTimeout      = 10 * time.Minute
+		drainStallTimeout = 2 * time.Minute
+	)
 	drainCmd := &cobra.Command{
 		Use:          "drain <sync-target-name>",
 		Short:        "Start draining sync target in preparation for maintenance",
@@ -229,11 +289,56 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 			syncTargetName := args[0]
 
-			return kubeconfig.Drain(c.Context(), syncTargetName)
+			return kubeconfig.Drain(c.Context(), syncTargetName, drainWait, drainWorkspaces, drainTimeout, drainStallTimeout)
 		},
 	}
+	drainCmd.Flags().BoolVar(&drainWait, "wait", drainWait, "Wait until all the objects are evicted from the sync target, printing the number of remaining objects per workspace.")
+	drainCmd.Flags().StringSliceVar(&drainWorkspaces, "workspaces", drainWorkspaces, "Fully qualified names of the workspaces using the sync target to wait for with --wait. Defaults to all the workspaces using the sync target.")
+	drainCmd.Flags().DurationVar(&drainTimeout, "timeout", drainTimeout, "The maximum time to wait with --wait. 0 means no timeout.")
+	drainCmd.Flags().DurationVar(&drainStallTimeout, "stall-timeout", drainStallTimeout, "Fail with --wait if the number of remaining objects does not decrease for this long. 0 disables the detection.")
 
 	cmd.AddCommand(drainCmd)
 
+	// status
+	var (
+		statusOutput     string
+		statusWorkspaces []string
+	)
+	statusCmd := &cobra.Command{
+		Use:          "status [<sync-target-name>]",
+		Short:        "Show the health of sync targets and the workloads placed on them",
+		Example:      fmt.Sprintf(statusExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 1 {
+				return cmd.Help()
+			}
+			switch statusOutput {
+			case "", "wide", "json", "yaml":
+			default:
+				return fmt.Errorf("invalid value %q for --output; valid values are wide, json, yaml", statusOutput)
+			}
+
+			var syncTargetName string
+			if len(args) == 1 {
+				syncTargetName = args[0]
+			}
+
+			return kubeconfig.Status(c.Context(), syncTargetName, statusOutput, statusWorkspaces)
+		},
+	}
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", statusOutput, "Output format. One of: wide, json, yaml. Defaults to a table.")
+	statusCmd.Flags().StringSliceVar(&statusWorkspaces, "workspaces", statusWorkspaces, "Fully qualified names of the workspaces using the sync targets to count the placed objects in. Defaults to all the workspaces using the sync targets.")
+
+	cmd.AddCommand(statusCmd)
+
 	return cmd, nil
 }
@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// drainPollInterval is the interval at which the objects remaining on a draining sync target are counted.
+const drainPollInterval = 5 * time.Second
+
+// objectCounts are numbers of objects by logical cluster.
+type objectCounts map[logicalcluster.Name]int
+
+func (r objectCounts) total() int {
+	total := 0
+	for _, n := range r {
+		total += n
+	}
+	return total
+}
+
+// String returns the numbers of objects sorted by logical cluster, e.g. "root:org:ws1=3, root:org:ws2=1".
+func (r objectCounts) String() string {
+	clusters := make([]string, 0, len(r))
+	for clusterName := range r {
+		clusters = append(clusters, clusterName.String())
+	}
+	sort.Strings(clusters)
+
+	counts := make([]string, 0, len(clusters))
+	for _, clusterName := range clusters {
+		counts = append(counts, fmt.Sprintf("%s=%d", clusterName, r[logicalcluster.New(clusterName)]))
+	}
+	return strings.Join(counts, ", ")
+}
+
+// evictionProgress tracks the number of objects remaining on a draining sync target, to detect when
+// the eviction stalls.
+type evictionProgress struct {
+	stallTimeout time.Duration
+
+	lowestTotal  int
+	lastProgress time.Time
+}
+
+func newEvictionProgress(stallTimeout time.Duration, now time.Time) *evictionProgress {
+	return &evictionProgress{
+		stallTimeout: stallTimeout,
+		lowestTotal:  -1,
+		lastProgress: now,
+	}
+}
+
+// observe records the number of remaining objects at the given time, and returns true if the number
+// did not decrease for longer than the stall timeout.
+func (p *evictionProgress) observe(total int, now time.Time) (stalled bool) {
+	if p.lowestTotal < 0 || total < p.lowestTotal {
+		p.lowestTotal = total
+		p.lastProgress = now
+		return false
+	}
+	return p.stallTimeout > 0 && now.Sub(p.lastProgress) > p.stallTimeout
+}
+
+// waitForEviction blocks until no object carries the state label of the sync target anymore, in the given workspaces
+// or else in all the workspaces using it, printing the number of remaining objects per workspace as it changes. It fails if the timeout
+// expires, or if the number of remaining objects does not decrease for longer than the stall timeout.
+func (c *Config) waitForEviction(ctx context.Context, config *rest.Config, kcpClient kcpclientset.Interface, syncTargetName string, workspaces []logicalcluster.Name, timeout, stallTimeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, syncTargetName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get SyncTarget %s: %w", syncTargetName, err)
+	}
+
+	progress := newEvictionProgress(stallTimeout, time.Now())
+	lastReport := ""
+	var remaining objectCounts
+	err = wait.PollImmediateUntilWithContext(ctx, drainPollInterval, func(ctx context.Context) (bool, error) {
+		synced, err := countSyncedObjects(ctx, config, syncTarget, workspaces)
+		if err != nil {
+			return false, err
+		}
+		remaining = synced.byCluster()
+
+		total := remaining.total()
+		if total == 0 {
+			return true, nil
+		}
+		if report := remaining.String(); report != lastReport {
+			fmt.Fprintf(c.Out, "%s: %d objects remaining (%s)\n", syncTargetName, total, report)
+			lastReport = report
+		}
+		if progress.observe(total, time.Now()) {
+			return false, fmt.Errorf("eviction from SyncTarget %s stalled for %s with %d objects remaining (%s)", syncTargetName, stallTimeout, total, remaining)
+		}
+		return false, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("timed out waiting for the eviction from SyncTarget %s with %d objects remaining (%s)", syncTargetName, remaining.total(), remaining)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Out, syncTargetName, "drained")
+	return nil
+}
+
+// syncedObjects are the numbers of objects carrying the state label of a sync target, by resource.
+type syncedObjects map[schema.GroupResource]objectCounts
+
+// add counts an object of the given resource and logical cluster.
+func (s syncedObjects) add(groupResource schema.GroupResource, clusterName logicalcluster.Name) {
+	counts, ok := s[groupResource]
+	if !ok {
+		counts = objectCounts{}
+		s[groupResource] = counts
+	}
+	counts[clusterName]++
+}
+
+// byCluster returns the numbers of objects of all resources by logical cluster.
+func (s syncedObjects) byCluster() objectCounts {
+	counts := objectCounts{}
+	for _, resourceCounts := range s {
+		for clusterName, n := range resourceCounts {
+			counts[clusterName] += n
+		}
+	}
+	return counts
+}
+
+// syncedObjectsSelector selects the objects placed on the sync target.
+func syncedObjectsSelector(syncTargetName string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetName: string(workloadv1alpha1.ResourceStateSync)})
+}
+
+// countSyncedObjects counts the objects that carry the state label of the sync target, by resource and logical
+// cluster. Without workspaces, they are counted in all the workspaces using the sync target, see
+// countSyncedObjectsInVirtualWorkspaces, and otherwise in the given workspaces only. config must not point
+// to a workspace.
+func countSyncedObjects(ctx context.Context, config *rest.Config, syncTarget *workloadv1alpha1.SyncTarget, workspaces []logicalcluster.Name) (syncedObjects, error) {
+	if len(workspaces) == 0 {
+		return countSyncedObjectsInVirtualWorkspaces(ctx, config, syncTarget)
+	}
+	synced, err := countSyncedObjectsInWorkspaces(ctx, config, []string{syncTarget.Name}, workspaces)
+	if err != nil {
+		return nil, err
+	}
+	return synced[syncTarget.Name], nil
+}
+
+// countSyncedObjectsInVirtualWorkspaces counts the objects that carry the state label of the sync target,
+// by resource and logical cluster, in all the workspaces using the sync target. They are listed across
+// workspaces through the syncer virtual workspaces of the sync target, which only serve the objects placed
+// on it.
+func countSyncedObjectsInVirtualWorkspaces(ctx context.Context, config *rest.Config, syncTarget *workloadv1alpha1.SyncTarget) (syncedObjects, error) {
+	if len(syncTarget.Status.VirtualWorkspaces) == 0 {
+		return nil, fmt.Errorf("SyncTarget %s has no syncer virtual workspace to find the workspaces using it, specify them with --workspaces", syncTarget.Name)
+	}
+	selector := syncedObjectsSelector(syncTarget.Name).String()
+
+	synced := syncedObjects{}
+	for _, virtualWorkspace := range syncTarget.Status.VirtualWorkspaces {
+		virtualWorkspaceConfig := rest.CopyConfig(config)
+		virtualWorkspaceConfig.Host = virtualWorkspace.URL
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(virtualWorkspaceConfig)
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := dynamic.NewClusterForConfig(virtualWorkspaceConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		gvrs, err := listableResources(discoveryClient.WithCluster(logicalcluster.Wildcard))
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover the resources of syncer virtual workspace %s: %w", virtualWorkspace.URL, err)
+		}
+		for _, gvr := range gvrs {
+			list, err := dynamicClient.Cluster(logicalcluster.Wildcard).Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in syncer virtual workspace %s: %w", gvr.GroupResource(), virtualWorkspace.URL, err)
+			}
+			for i := range list.Items {
+				synced.add(gvr.GroupResource(), logicalcluster.From(&list.Items[i]))
+			}
+		}
+	}
+	return synced, nil
+}
+
+// countSyncedObjectsInWorkspaces counts the objects that carry the state label of each of the sync targets,
+// by sync target, resource and logical cluster, in the given workspaces. The resources of each workspace
+// are discovered and listed once for all the sync targets. config must not point to a workspace.
+func countSyncedObjectsInWorkspaces(ctx context.Context, config *rest.Config, syncTargetNames []string, workspaces []logicalcluster.Name) (map[string]syncedObjects, error) {
+	// Objects of several sync targets cannot be selected at once, so all the objects are listed then.
+	selector := labels.Everything()
+	if len(syncTargetNames) == 1 {
+		selector = syncedObjectsSelector(syncTargetNames[0])
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := make(map[string]syncedObjects, len(syncTargetNames))
+	for _, name := range syncTargetNames {
+		synced[name] = syncedObjects{}
+	}
+	for _, workspace := range workspaces {
+		gvrs, err := listableResources(discoveryClient.WithCluster(workspace))
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover the resources of workspace %s: %w", workspace, err)
+		}
+		for _, gvr := range gvrs {
+			list, err := dynamicClient.Cluster(workspace).Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in workspace %s: %w", gvr.GroupResource(), workspace, err)
+			}
+			for i := range list.Items {
+				for _, name := range syncTargetNames {
+					if syncedObjectsSelector(name).Matches(labels.Set(list.Items[i].GetLabels())) {
+						synced[name].add(gvr.GroupResource(), workspace)
+					}
+				}
+			}
+		}
+	}
+	return synced, nil
+}
+
+// workspacesConfig returns the config of the cluster-aware clients, and the given workspaces in which the objects
+// placed on the sync targets of the current workspace are counted. Without workspaces, the objects are counted
+// in all the workspaces using the sync targets.
+func workspacesConfig(config *rest.Config, workspaces []string) (*rest.Config, []logicalcluster.Name, error) {
+	u, _, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	clusterConfig := rest.CopyConfig(config)
+	clusterConfig.Host = u.String()
+
+	var clusterNames []logicalcluster.Name
+	for _, workspace := range workspaces {
+		clusterName := logicalcluster.New(workspace)
+		if !tenancyhelper.IsValidCluster(clusterName) {
+			return nil, nil, fmt.Errorf("invalid workspace %q, expected a fully qualified workspace name like root:org:ws", workspace)
+		}
+		clusterNames = append(clusterNames, clusterName)
+	}
+	return clusterConfig, clusterNames, nil
+}
+
+// listableResources returns the resources, without subresources, that support the list verb.
+func listableResources(discoveryClient discovery.DiscoveryInterface) ([]schema.GroupVersionResource, error) {
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	var gvrs []schema.GroupVersionResource
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") || !sets.NewString(resource.Verbs...).Has("list") {
+				continue
+			}
+			gvrs = append(gvrs, gv.WithResource(resource.Name))
+		}
+	}
+	return gvrs, nil
+}
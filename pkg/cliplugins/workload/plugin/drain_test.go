@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestObjectCounts(t *testing.T) {
+	counts := objectCounts{
+		logicalcluster.New("root:org:ws2"): 1,
+		logicalcluster.New("root:org:ws1"): 3,
+	}
+	require.Equal(t, 4, counts.total())
+	require.Equal(t, "root:org:ws1=3, root:org:ws2=1", counts.String())
+
+	require.Equal(t, 0, objectCounts{}.total())
+	require.Equal(t, "", objectCounts{}.String())
+}
+
+func TestSyncedObjects(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	synced := syncedObjects{}
+	synced.add(deployments, logicalcluster.New("root:org:ws1"))
+	synced.add(deployments, logicalcluster.New("root:org:ws1"))
+	synced.add(namespacesGroupResource, logicalcluster.New("root:org:ws2"))
+
+	require.Equal(t, syncedObjects{
+		deployments:             {logicalcluster.New("root:org:ws1"): 2},
+		namespacesGroupResource: {logicalcluster.New("root:org:ws2"): 1},
+	}, synced)
+	require.Equal(t, objectCounts{
+		logicalcluster.New("root:org:ws1"): 2,
+		logicalcluster.New("root:org:ws2"): 1,
+	}, synced.byCluster())
+}
+
+func TestEvictionProgress(t *testing.T) {
+	start := time.Now()
+	tests := map[string]struct {
+		stallTimeout time.Duration
+		totals       []int
+		interval     time.Duration
+		stalled      []bool
+	}{
+		"decreasing totals never stall": {
+			stallTimeout: time.Minute,
+			totals:       []int{10, 8, 5, 1},
+			interval:     50 * time.Second,
+			stalled:      []bool{false, false, false, false},
+		},
+		"constant totals stall after the timeout": {
+			stallTimeout: time.Minute,
+			totals:       []int{10, 10, 10, 10},
+			interval:     25 * time.Second,
+			stalled:      []bool{false, false, false, true},
+		},
+		"increasing totals are no progress": {
+			stallTimeout: time.Minute,
+			totals:       []int{10, 12, 11, 11},
+			interval:     25 * time.Second,
+			stalled:      []bool{false, false, false, true},
+		},
+		"progress resets the stall timeout": {
+			stallTimeout: time.Minute,
+			totals:       []int{10, 10, 9, 9, 9},
+			interval:     25 * time.Second,
+			stalled:      []bool{false, false, false, false, false},
+		},
+		"no stall detection": {
+			totals:   []int{10, 10, 10},
+			interval: time.Hour,
+			stalled:  []bool{false, false, false},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			progress := newEvictionProgress(tc.stallTimeout, start)
+			for i, total := range tc.totals {
+				now := start.Add(time.Duration(i) * tc.interval)
+				require.Equal(t, tc.stalled[i], progress.observe(total, now), "observation %d", i)
+			}
+		})
+	}
+}
+
+func TestWorkspacesConfig(t *testing.T) {
+	tests := map[string]struct {
+		host       string
+		workspaces []string
+
+		wantHost       string
+		wantWorkspaces []logicalcluster.Name
+		wantErr        bool
+	}{
+		"all workspaces by default": {
+			host:     "https://kcp.dev/clusters/root:org:ws",
+			wantHost: "https://kcp.dev",
+		},
+		"given workspaces": {
+			host:           "https://kcp.dev/clusters/root:org:ws",
+			workspaces:     []string{"root:org:ws1", "root:org:ws2"},
+			wantHost:       "https://kcp.dev",
+			wantWorkspaces: []logicalcluster.Name{logicalcluster.New("root:org:ws1"), logicalcluster.New("root:org:ws2")},
+		},
+		"not fully qualified workspace": {
+			host:       "https://kcp.dev/clusters/root:org:ws",
+			workspaces: []string{"ws1"},
+			wantErr:    true,
+		},
+		"not pointing to a workspace": {
+			host:    "https://kcp.dev",
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, workspaces, err := workspacesConfig(&rest.Config{Host: tc.host}, tc.workspaces)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantHost, config.Host)
+			require.Equal(t, tc.wantWorkspaces, workspaces)
+		})
+	}
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+const (
+	// The scheduling states of a SyncTarget.
+	schedulableState = "Schedulable"
+	cordonedState    = "Cordoned"
+	drainingState    = "Draining"
+)
+
+var namespacesGroupResource = schema.GroupResource{Resource: "namespaces"}
+
+// SyncTargetStatus is the aggregated health of a SyncTarget.
+type SyncTargetStatus struct {
+	Name string `json:"name"`
+	// Scheduling is Schedulable, Cordoned or Draining.
+	Scheduling              string       `json:"scheduling"`
+	LastSyncerHeartbeatTime *metav1.Time `json:"lastSyncerHeartbeatTime,omitempty"`
+	// HeartbeatAge is the time elapsed since the last syncer heartbeat.
+	HeartbeatAge         string                   `json:"heartbeatAge,omitempty"`
+	Conditions           conditionsapi.Conditions `json:"conditions,omitempty"`
+	SyncedResources      []string                 `json:"syncedResources,omitempty"`
+	VirtualWorkspaceURLs []string                 `json:"virtualWorkspaceURLs,omitempty"`
+	Allocatable          *corev1.ResourceList     `json:"allocatable,omitempty"`
+	Capacity             *corev1.ResourceList     `json:"capacity,omitempty"`
+	// Locations are the names of the Locations the SyncTarget belongs to.
+	Locations []string `json:"locations,omitempty"`
+	// Namespaces is the number of namespaces placed on the SyncTarget.
+	Namespaces int `json:"namespaces"`
+	// Objects is the number of other objects placed on the SyncTarget, and ObjectsByWorkspace
+	// their number by workspace.
+	Objects            int            `json:"objects"`
+	ObjectsByWorkspace map[string]int `json:"objectsByWorkspace,omitempty"`
+	// ObjectsError is set if the placed namespaces and objects could not be counted.
+	ObjectsError string `json:"objectsError,omitempty"`
+}
+
+// Status prints the aggregated health of a SyncTarget, or of all the SyncTargets of the workspace if
+// syncTargetName is empty. The output format is one of "" (table), "wide", "json" and "yaml". The placed
+// namespaces and objects are counted in the given workspaces, or in all the workspaces using the SyncTargets
+// if none is given.
+func (c *Config) Status(ctx context.Context, syncTargetName, outputFormat string, workspaces []string) error {
+	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	clusterConfig, clusterNames, err := workspacesConfig(config, workspaces)
+	if err != nil {
+		return err
+	}
+
+	kcpClient, err := kcpclientset.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kcp client: %w", err)
+	}
+
+	var syncTargets []workloadv1alpha1.SyncTarget
+	if syncTargetName != "" {
+		syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, syncTargetName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get SyncTarget %s: %w", syncTargetName, err)
+		}
+		syncTargets = append(syncTargets, *syncTarget)
+	} else {
+		syncTargetList, err := kcpClient.WorkloadV1alpha1().SyncTargets().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list SyncTargets: %w", err)
+		}
+		syncTargets = syncTargetList.Items
+	}
+
+	locations, err := kcpClient.SchedulingV1alpha1().Locations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list Locations: %w", err)
+	}
+
+	// In the given workspaces, the objects placed on all the SyncTargets are counted at once.
+	var syncedInWorkspaces map[string]syncedObjects
+	var countErr error
+	if len(clusterNames) > 0 {
+		syncTargetNames := make([]string, 0, len(syncTargets))
+		for i := range syncTargets {
+			syncTargetNames = append(syncTargetNames, syncTargets[i].Name)
+		}
+		if syncedInWorkspaces, countErr = countSyncedObjectsInWorkspaces(ctx, clusterConfig, syncTargetNames, clusterNames); countErr != nil {
+			fmt.Fprintf(c.ErrOut, "Warning: failed to count the objects placed on the SyncTargets: %v\n", countErr)
+		}
+	}
+
+	now := time.Now()
+	statuses := make([]*SyncTargetStatus, 0, len(syncTargets))
+	for i := range syncTargets {
+		status, err := newSyncTargetStatus(&syncTargets[i], locations.Items, now)
+		if err != nil {
+			return err
+		}
+		switch {
+		case countErr != nil:
+			status.ObjectsError = countErr.Error()
+		case len(clusterNames) > 0:
+			status.setObjects(syncedInWorkspaces[syncTargets[i].Name])
+		default:
+			synced, err := countSyncedObjectsInVirtualWorkspaces(ctx, clusterConfig, &syncTargets[i])
+			if err != nil {
+				status.ObjectsError = err.Error()
+				fmt.Fprintf(c.ErrOut, "Warning: failed to count the objects placed on SyncTarget %s: %v\n", syncTargets[i].Name, err)
+			} else {
+				status.setObjects(synced)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	switch outputFormat {
+	case "", "wide":
+		return printSyncTargetStatuses(c.Out, statuses, outputFormat == "wide")
+	case "json", "yaml":
+		var obj interface{} = statuses
+		if syncTargetName != "" {
+			obj = statuses[0]
+		}
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+		if outputFormat == "yaml" {
+			if data, err = yaml.JSONToYAML(data); err != nil {
+				return err
+			}
+		} else {
+			data = append(data, '\n')
+		}
+		_, err = c.Out.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q", outputFormat)
+	}
+}
+
+// newSyncTargetStatus returns the status of a SyncTarget, without the placed namespaces and objects.
+func newSyncTargetStatus(syncTarget *workloadv1alpha1.SyncTarget, locations []schedulingv1alpha1.Location, now time.Time) (*SyncTargetStatus, error) {
+	status := &SyncTargetStatus{
+		Name:                    syncTarget.Name,
+		Scheduling:              schedulableState,
+		LastSyncerHeartbeatTime: syncTarget.Status.LastSyncerHeartbeatTime,
+		Conditions:              syncTarget.Status.Conditions,
+		SyncedResources:         syncTarget.Status.SyncedResources,
+		Allocatable:             syncTarget.Status.Allocatable,
+		Capacity:                syncTarget.Status.Capacity,
+	}
+	if syncTarget.Spec.Unschedulable {
+		status.Scheduling = cordonedState
+		if syncTarget.Spec.EvictAfter != nil {
+			status.Scheduling = drainingState
+		}
+	}
+	if heartbeat := syncTarget.Status.LastSyncerHeartbeatTime; heartbeat != nil {
+		status.HeartbeatAge = duration.HumanDuration(now.Sub(heartbeat.Time))
+	}
+	for _, virtualWorkspace := range syncTarget.Status.VirtualWorkspaces {
+		status.VirtualWorkspaceURLs = append(status.VirtualWorkspaceURLs, virtualWorkspace.URL)
+	}
+
+	for i := range locations {
+		location := &locations[i]
+		if location.Spec.Resource.Group != workloadv1alpha1.SchemeGroupVersion.Group || location.Spec.Resource.Resource != "synctargets" {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(location.Spec.InstanceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse label selector %v in location %s: %w", location.Spec.InstanceSelector, location.Name, err)
+		}
+		if selector.Matches(labels.Set(syncTarget.Labels)) {
+			status.Locations = append(status.Locations, location.Name)
+		}
+	}
+	sort.Strings(status.Locations)
+
+	return status, nil
+}
+
+// setObjects sets the numbers of namespaces and other objects placed on the SyncTarget.
+func (s *SyncTargetStatus) setObjects(synced syncedObjects) {
+	s.Namespaces = synced[namespacesGroupResource].total()
+
+	objects := syncedObjects{}
+	for groupResource, counts := range synced {
+		if groupResource != namespacesGroupResource {
+			objects[groupResource] = counts
+		}
+	}
+	byCluster := objects.byCluster()
+	s.Objects = byCluster.total()
+	if len(byCluster) > 0 {
+		s.ObjectsByWorkspace = make(map[string]int, len(byCluster))
+		for clusterName, n := range byCluster {
+			s.ObjectsByWorkspace[clusterName.String()] = n
+		}
+	}
+}
+
+// printSyncTargetStatuses prints the statuses as a table, with more columns if wide is true.
+func printSyncTargetStatuses(out io.Writer, statuses []*SyncTargetStatus, wide bool) error {
+	w := printers.GetNewTabWriter(out)
+
+	columns := []string{"NAME", "SCHEDULING", "READY", "SYNCER", "API-IMPORTER", "HEARTBEAT", "LAST-HEARTBEAT", "NAMESPACES", "OBJECTS", "LOCATIONS"}
+	if wide {
+		columns = append(columns, "SYNCED-RESOURCES", "VIRTUAL-WORKSPACES", "ALLOCATABLE", "CAPACITY")
+	}
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+
+	for _, status := range statuses {
+		namespaces, objects := fmt.Sprint(status.Namespaces), fmt.Sprint(status.Objects)
+		if status.ObjectsError != "" {
+			namespaces, objects = "<unknown>", "<unknown>"
+		}
+		row := []string{
+			status.Name,
+			status.Scheduling,
+			conditionSummary(status, conditionsapi.ReadyCondition),
+			conditionSummary(status, workloadv1alpha1.SyncerReady),
+			conditionSummary(status, workloadv1alpha1.APIImporterReady),
+			conditionSummary(status, workloadv1alpha1.HeartbeatHealthy),
+			valueOrNone(status.HeartbeatAge),
+			namespaces,
+			objects,
+			valueOrNone(strings.Join(status.Locations, ",")),
+		}
+		if wide {
+			row = append(row,
+				valueOrNone(strings.Join(status.SyncedResources, ",")),
+				valueOrNone(strings.Join(status.VirtualWorkspaceURLs, ",")),
+				valueOrNone(resourceListString(status.Allocatable)),
+				valueOrNone(resourceListString(status.Capacity)),
+			)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// conditionSummary returns the status of a condition, followed by its reason if it is not true.
+func conditionSummary(status *SyncTargetStatus, conditionType conditionsapi.ConditionType) string {
+	for _, condition := range status.Conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status != corev1.ConditionTrue && condition.Reason != "" {
+			return fmt.Sprintf("%s (%s)", condition.Status, condition.Reason)
+		}
+		return string(condition.Status)
+	}
+	return string(corev1.ConditionUnknown)
+}
+
+// resourceListString returns the quantities of a resource list sorted by name, e.g. "cpu=4,memory=16Gi".
+func resourceListString(resources *corev1.ResourceList) string {
+	if resources == nil {
+		return ""
+	}
+	names := make([]string, 0, len(*resources))
+	for name := range *resources {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	quantities := make([]string, 0, len(names))
+	for _, name := range names {
+		quantity := (*resources)[corev1.ResourceName(name)]
+		quantities = append(quantities, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	return strings.Join(quantities, ",")
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
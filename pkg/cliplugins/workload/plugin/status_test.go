@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestNewSyncTargetStatus(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	syncTargetLocation := func(name string, selector *metav1.LabelSelector) schedulingv1alpha1.Location {
+		return schedulingv1alpha1.Location{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: schedulingv1alpha1.LocationSpec{
+				Resource:         schedulingv1alpha1.GroupVersionResource{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"},
+				InstanceSelector: selector,
+			},
+		}
+	}
+	locations := []schedulingv1alpha1.Location{
+		syncTargetLocation("us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}}),
+		syncTargetLocation("eu-west1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu-west1"}}),
+		syncTargetLocation("default", &metav1.LabelSelector{}),
+	}
+
+	tests := map[string]struct {
+		syncTarget *workloadv1alpha1.SyncTarget
+		expected   *SyncTargetStatus
+	}{
+		"schedulable sync target with heartbeat": {
+			syncTarget: &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "east", Labels: map[string]string{"region": "us-east1"}},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					LastSyncerHeartbeatTime: &metav1.Time{Time: now.Add(-45 * time.Second)},
+					SyncedResources:         []string{"deployments.apps"},
+					VirtualWorkspaces:       []workloadv1alpha1.VirtualWorkspace{{URL: "https://shard-1/services/syncer/root:org/east"}},
+				},
+			},
+			expected: &SyncTargetStatus{
+				Name:                    "east",
+				Scheduling:              "Schedulable",
+				LastSyncerHeartbeatTime: &metav1.Time{Time: now.Add(-45 * time.Second)},
+				HeartbeatAge:            "45s",
+				SyncedResources:         []string{"deployments.apps"},
+				VirtualWorkspaceURLs:    []string{"https://shard-1/services/syncer/root:org/east"},
+				Locations:               []string{"default", "us-east1"},
+			},
+		},
+		"cordoned sync target": {
+			syncTarget: &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "west", Labels: map[string]string{"region": "eu-west1"}},
+				Spec:       workloadv1alpha1.SyncTargetSpec{Unschedulable: true},
+			},
+			expected: &SyncTargetStatus{
+				Name:       "west",
+				Scheduling: "Cordoned",
+				Locations:  []string{"default", "eu-west1"},
+			},
+		},
+		"draining sync target": {
+			syncTarget: &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "west"},
+				Spec:       workloadv1alpha1.SyncTargetSpec{Unschedulable: true, EvictAfter: &metav1.Time{Time: now}},
+			},
+			expected: &SyncTargetStatus{
+				Name:       "west",
+				Scheduling: "Draining",
+				Locations:  []string{"default"},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, err := newSyncTargetStatus(tc.syncTarget, locations, now)
+			require.NoError(t, err)
+			require.Empty(t, cmp.Diff(tc.expected, status))
+		})
+	}
+}
+
+func TestSetObjects(t *testing.T) {
+	status := &SyncTargetStatus{}
+	status.setObjects(syncedObjects{
+		schema.GroupResource{Resource: "namespaces"}: {
+			logicalcluster.New("root:org:ws1"): 2,
+			logicalcluster.New("root:org:ws2"): 1,
+		},
+		schema.GroupResource{Group: "apps", Resource: "deployments"}: {
+			logicalcluster.New("root:org:ws1"): 3,
+		},
+		schema.GroupResource{Resource: "services"}: {
+			logicalcluster.New("root:org:ws1"): 1,
+			logicalcluster.New("root:org:ws2"): 4,
+		},
+	})
+	require.Equal(t, 3, status.Namespaces)
+	require.Equal(t, 8, status.Objects)
+	require.Equal(t, map[string]int{"root:org:ws1": 4, "root:org:ws2": 4}, status.ObjectsByWorkspace)
+}
+
+func TestPrintSyncTargetStatuses(t *testing.T) {
+	statuses := []*SyncTargetStatus{
+		{
+			Name:       "east",
+			Scheduling: "Schedulable",
+			Conditions: conditionsapi.Conditions{
+				{Type: conditionsapi.ReadyCondition, Status: corev1.ConditionTrue},
+				{Type: workloadv1alpha1.SyncerReady, Status: corev1.ConditionTrue},
+				{Type: workloadv1alpha1.APIImporterReady, Status: corev1.ConditionTrue},
+				{Type: workloadv1alpha1.HeartbeatHealthy, Status: corev1.ConditionTrue},
+			},
+			HeartbeatAge:         "12s",
+			SyncedResources:      []string{"deployments.apps", "services"},
+			VirtualWorkspaceURLs: []string{"https://shard-1/services/syncer/root:org/east"},
+			Capacity:             &corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi"), corev1.ResourceCPU: resource.MustParse("4")},
+			Locations:            []string{"default"},
+			Namespaces:           2,
+			Objects:              5,
+		},
+		{
+			Name:       "west",
+			Scheduling: "Draining",
+			Conditions: conditionsapi.Conditions{
+				{Type: conditionsapi.ReadyCondition, Status: corev1.ConditionFalse, Reason: "ErrorHeartbeatMissed"},
+				{Type: workloadv1alpha1.HeartbeatHealthy, Status: corev1.ConditionFalse, Reason: "ErrorHeartbeatMissed"},
+			},
+			ObjectsError: "forbidden",
+		},
+	}
+
+	tests := map[string]struct {
+		wide     bool
+		expected string
+	}{
+		"table": {
+			expected: `NAME   SCHEDULING    READY                          SYNCER    API-IMPORTER   HEARTBEAT                      LAST-HEARTBEAT   NAMESPACES   OBJECTS     LOCATIONS
+east   Schedulable   True                           True      True           True                           12s              2            5           default
+west   Draining      False (ErrorHeartbeatMissed)   Unknown   Unknown        False (ErrorHeartbeatMissed)   <none>           <unknown>    <unknown>   <none>
+`,
+		},
+		"wide": {
+			wide: true,
+			expected: `NAME   SCHEDULING    READY                          SYNCER    API-IMPORTER   HEARTBEAT                      LAST-HEARTBEAT   NAMESPACES   OBJECTS     LOCATIONS   SYNCED-RESOURCES            VIRTUAL-WORKSPACES                              ALLOCATABLE   CAPACITY
+east   Schedulable   True                           True      True           True                           12s              2            5           default     deployments.apps,services   https://shard-1/services/syncer/root:org/east   <none>        cpu=4,memory=16Gi
+west   Draining      False (ErrorHeartbeatMissed)   Unknown   Unknown        False (ErrorHeartbeatMissed)   <none>           <unknown>    <unknown>   <none>      <none>                      <none>                                          <none>        <none>
+`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, printSyncTargetStatuses(&out, statuses, tc.wide))
+			require.Empty(t, cmp.Diff(tc.expected, out.String()))
+		})
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubernetesclientset "k8s.io/client-go/kubernetes"
@@ -46,6 +47,7 @@ import (
 	"k8s.io/klog/v2"
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
@@ -62,13 +64,18 @@ const (
 
 // Sync prepares a kcp workspace for use with a syncer and outputs the
 // configuration required to deploy a syncer to the pcluster to stdout.
+// With update options, it upgrades the syncer of an existing sync target instead.
 func (c *Config) Sync(
 	ctx context.Context,
 	outputFilePath, syncTargetName, kcpNamespaceName, downstreamNamespace, image string,
 	resourcesToSync []string,
+	clusterScopedResourcesToSync []string,
 	replicas int,
 	qps float32,
 	burst int,
+	backoff SyncerBackoffOptions,
+	metricsPort int,
+	update SyncUpdateOptions,
 ) error {
 	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
 	if err != nil {
@@ -76,9 +83,12 @@ func (c *Config) Sync(
 	}
 
 	var outputFile *os.File
-	if outputFilePath == "-" {
+	switch outputFilePath {
+	case "":
+		// The resources are only applied to the physical cluster.
+	case "-":
 		outputFile = os.Stdout
-	} else {
+	default:
 		outputFile, err = os.Create(outputFilePath)
 		if err != nil {
 			return err
@@ -86,7 +96,7 @@ func (c *Config) Sync(
 		defer outputFile.Close() // nolint: errcheck
 	}
 
-	token, syncerID, err := c.enableSyncerForWorkspace(ctx, config, syncTargetName, kcpNamespaceName)
+	token, syncerID, err := c.enableSyncerForWorkspace(ctx, config, syncTargetName, kcpNamespaceName, update)
 	if err != nil {
 		return err
 	}
@@ -108,18 +118,21 @@ func (c *Config) Sync(
 	serverURL := configURL.Scheme + "://" + configURL.Host
 
 	input := templateInput{
-		ServerURL:       serverURL,
-		CAData:          base64.StdEncoding.EncodeToString(config.CAData),
-		Token:           token,
-		KCPNamespace:    kcpNamespaceName,
-		Namespace:       downstreamNamespace,
-		LogicalCluster:  currentClusterName.String(),
-		SyncTarget:      syncTargetName,
-		Image:           image,
-		Replicas:        replicas,
-		ResourcesToSync: resourcesToSync,
-		QPS:             qps,
-		Burst:           burst,
+		ServerURL:                    serverURL,
+		CAData:                       base64.StdEncoding.EncodeToString(config.CAData),
+		Token:                        token,
+		KCPNamespace:                 kcpNamespaceName,
+		Namespace:                    downstreamNamespace,
+		LogicalCluster:               currentClusterName.String(),
+		SyncTarget:                   syncTargetName,
+		Image:                        image,
+		Replicas:                     replicas,
+		ResourcesToSync:              resourcesToSync,
+		ClusterScopedResourcesToSync: clusterScopedResourcesToSync,
+		QPS:                          qps,
+		Burst:                        burst,
+		Backoff:                      backoff,
+		MetricsPort:                  metricsPort,
 	}
 
 	resources, err := renderSyncerResources(input, syncerID)
@@ -127,6 +140,31 @@ func (c *Config) Sync(
 		return err
 	}
 
+	if update.Update {
+		// Only the resources that change when the syncer is upgraded are output.
+		kinds := updatedSyncerResourceKinds
+		if update.RotateToken {
+			kinds = kinds.Union(sets.NewString("Secret"))
+		}
+		if resources, err = filterSyncerResources(resources, kinds); err != nil {
+			return err
+		}
+	}
+
+	if update.ToKubeconfig != "" {
+		if err := c.applySyncerResources(ctx, resources, update); err != nil {
+			return err
+		}
+	}
+	if update.RotateToken && !update.DryRun {
+		if err := c.retireRotatedServiceAccountTokens(ctx, config, kcpNamespaceName, syncerID, downstreamNamespace, update); err != nil {
+			return err
+		}
+	}
+	if outputFile == nil {
+		return nil
+	}
+
 	_, err = outputFile.Write(resources)
 	if outputFilePath != "-" {
 		// nolint: errcheck
@@ -147,7 +185,9 @@ func getSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
 // enableSyncerForWorkspace creates a sync target with the given name and creates a service
 // account for the syncer in the given namespace. The expectation is that the provided config is
 // for a logical cluster (workspace). Returns the token the syncer will use to connect to kcp.
-func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Config, syncTargetName, namespace string) (string, string, error) {
+// In update mode, the sync target and the service account must exist already, and the token
+// is rotated if requested.
+func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Config, syncTargetName, namespace string, update SyncUpdateOptions) (string, string, error) {
 	kcpClient, err := kcpclientset.NewForConfig(config)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create kcp client: %w", err)
@@ -159,6 +199,8 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 	)
 	if err != nil && !errors.IsNotFound(err) {
 		return "", "", fmt.Errorf("failed to get synctarget %q: %w", syncTargetName, err)
+	} else if errors.IsNotFound(err) && update.Update {
+		return "", "", fmt.Errorf("synctarget %q does not exist, run without --update to create it", syncTargetName)
 	} else if errors.IsNotFound(err) {
 		// Create the sync target that will serve as a point of coordination between
 		// kcp and the syncer (e.g. heartbeating from the syncer and virtual cluster urls
@@ -197,6 +239,8 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 	sa, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, syncerID, metav1.GetOptions{})
 
 	switch {
+	case errors.IsNotFound(err) && update.Update:
+		return "", "", fmt.Errorf("no syncer found for synctarget %q: service account %s|%s/%s does not exist, run without --update to create it", syncTargetName, syncTargetName, namespace, syncerID)
 	case errors.IsNotFound(err):
 		c.ErrOut.Write([]byte(fmt.Sprintf("Creating service account %q\n", syncerID))) // nolint: errcheck
 		if sa, err = kubeClient.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
@@ -267,6 +311,16 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
 			Resources: []string{"apiresourceimports"},
 		},
+		{
+			Verbs:     []string{"get", "list", "watch"},
+			APIGroups: []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"synctargetoverrides"},
+		},
+		{
+			Verbs:     []string{"get", "list", "watch"},
+			APIGroups: []string{schedulingv1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"locations"},
+		},
 	}
 
 	cr, err := kubeClient.RbacV1().ClusterRoles().Get(ctx,
@@ -274,7 +328,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		metav1.GetOptions{})
 	switch {
 	case errors.IsNotFound(err):
-		c.ErrOut.Write([]byte(fmt.Sprintf("Creating cluster role %q to give service account %q\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports.\n\n", syncerID, syncerID, syncerID))) // nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("Creating cluster role %q to give service account %q\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports\n 3. read access to the synctargetoverrides and locations.\n\n", syncerID, syncerID, syncerID))) // nolint: errcheck
 		if _, err = kubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            syncerID,
@@ -312,7 +366,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			return "", "", fmt.Errorf("failed to create patch for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
 		}
 
-		c.ErrOut.Write([]byte(fmt.Sprintf("Updating cluster role %q with\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports.\n\n", syncerID, syncerID))) // nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("Updating cluster role %q with\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports\n 3. read access to the synctargetoverrides and locations.\n\n", syncerID, syncerID))) // nolint: errcheck
 		if _, err = kubeClient.RbacV1().ClusterRoles().Patch(ctx, cr.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
 			return "", "", fmt.Errorf("failed to patch ClusterRole %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
 		}
@@ -356,6 +410,14 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		return "", "", err
 	}
 
+	if update.RotateToken && update.DryRun {
+		c.ErrOut.Write([]byte(fmt.Sprintf("Not rotating the token of service account %q in dry run.\n", sa.Name))) // nolint: errcheck
+	} else if update.RotateToken {
+		if err := c.rotateServiceAccountToken(ctx, kubeClient, namespace, sa.Name); err != nil {
+			return "", "", err
+		}
+	}
+
 	// Wait for the service account to be updated with the name of the token secret
 	tokenSecretName := ""
 	err = wait.PollImmediateWithContext(ctx, 100*time.Millisecond, 20*time.Second, func(ctx context.Context) (bool, error) {
@@ -433,6 +495,10 @@ type templateInput struct {
 	// "deployments.apps.k8s.io") that the syncer will synchronize between the kcp
 	// workspace and the pcluster.
 	ResourcesToSync []string
+	// ClusterScopedResourcesToSync is the set of qualified names of the cluster-scoped
+	// resources (eg. ["clusterroles.rbac.authorization.k8s.io"]) that the syncer will
+	// synchronize in addition to ResourcesToSync.
+	ClusterScopedResourcesToSync []string
 	// Image is the name of the container image that the syncer deployment will use
 	Image string
 	// Replicas is the number of syncer pods to run (should be 0 or 1).
@@ -441,6 +507,26 @@ type templateInput struct {
 	QPS float32
 	// Burst is the burst the syncer uses when talking to an apiserver.
 	Burst int
+	// Backoff configures the heartbeat, the discovery polling and the retries of the syncer.
+	Backoff SyncerBackoffOptions
+	// MetricsPort is the port the syncer serves its Prometheus metrics on. Metrics are not served if 0.
+	MetricsPort int
+}
+
+// SyncerBackoffOptions are the heartbeat, discovery polling and retry settings passed to the syncer.
+type SyncerBackoffOptions struct {
+	// HeartbeatInterval is the interval at which the syncer heartbeats its sync target.
+	HeartbeatInterval time.Duration
+	// DiscoveryPollInterval is the interval at which the syncer discovers the resource types to sync.
+	DiscoveryPollInterval time.Duration
+	// Jitter is the maximum factor by which the syncer randomly increases its intervals.
+	Jitter float64
+	// RetryInitialInterval is the initial delay of the exponential backoff of failed requests.
+	RetryInitialInterval time.Duration
+	// RetryMaxInterval is the maximum delay of the exponential backoff of failed requests.
+	RetryMaxInterval time.Duration
+	// MaxConcurrentReconnects is the maximum number of syncer virtual workspaces the syncer connects to at once.
+	MaxConcurrentReconnects int
 }
 
 // templateArgs represents the full set of arguments required to render the resources
@@ -469,6 +555,10 @@ type templateArgs struct {
 	Secret string
 	// Key in the syncer secret for the kcp logical cluster kubconfig.
 	SecretConfigKey string
+	// SecretConfigHash is the hash of the kubeconfig in the syncer secret. It is set as an
+	// annotation of the syncer pods, so that they are restarted when the kubeconfig changes,
+	// e.g. when the token is rotated.
+	SecretConfigHash string
 	// Deployment is the name of the deployment that will run the syncer in the
 	// pcluster.
 	Deployment string
@@ -489,8 +579,9 @@ func renderSyncerResources(input templateInput, syncerID string) ([]byte, error)
 		ServiceAccount:          syncerID,
 		ClusterRole:             syncerID,
 		ClusterRoleBinding:      syncerID,
-		GroupMappings:           getGroupMappings(input.ResourcesToSync),
+		GroupMappings:           getGroupMappings(sets.NewString(input.ResourcesToSync...).Insert(input.ClusterScopedResourcesToSync...).List()),
 		Secret:                  syncerID,
+		SecretConfigHash:        syncerConfigHash(input),
 		SecretConfigKey:         SyncerSecretConfigKey,
 		Deployment:              syncerID,
 		DeploymentApp:           syncerID,
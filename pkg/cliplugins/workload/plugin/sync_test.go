@@ -18,6 +18,7 @@ package plugin
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
@@ -123,6 +124,11 @@ spec:
     metadata:
       labels:
         app: kcp-syncer-sync-target-name-34b23c4k
+      annotations:
+        workload.kcp.io/syncer-config-hash: "9d915179ba9e871a"
+        prometheus.io/scrape: "true"
+        prometheus.io/port: "8080"
+        prometheus.io/path: /metrics
     spec:
       containers:
       - name: kcp-syncer
@@ -136,7 +142,18 @@ spec:
         - --resources=resource2
         - --qps=123.4
         - --burst=456
+        - --heartbeat-interval=20s
+        - --discovery-poll-interval=30s
+        - --backoff-jitter=0.2
+        - --retry-initial-interval=1s
+        - --retry-max-interval=2m0s
+        - --max-concurrent-reconnects=2
+        - --metrics-bind-address=:8080
         image: image
+        ports:
+        - name: metrics
+          containerPort: 8080
+          protocol: TCP
         imagePullPolicy: IfNotPresent
         terminationMessagePolicy: FallbackToLogsOnError
         volumeMounts:
@@ -163,6 +180,15 @@ spec:
 		ResourcesToSync: []string{"resource1", "resource2"},
 		QPS:             123.4,
 		Burst:           456,
+		Backoff: SyncerBackoffOptions{
+			HeartbeatInterval:       20 * time.Second,
+			DiscoveryPollInterval:   30 * time.Second,
+			Jitter:                  0.2,
+			RetryInitialInterval:    time.Second,
+			RetryMaxInterval:        2 * time.Minute,
+			MaxConcurrentReconnects: 2,
+		},
+		MetricsPort: 8080,
 	}, "kcp-syncer-sync-target-name-34b23c4k")
 	require.NoError(t, err)
 	require.Empty(t, cmp.Diff(expectedYAML, string(actualYAML)))
@@ -0,0 +1,349 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+)
+
+// syncerApplyFieldManager is the field manager used to apply the syncer resources to the physical cluster.
+const syncerApplyFieldManager = "kubectl-kcp"
+
+// updatedSyncerResourceKinds are the kinds of the syncer resources that change when the syncer
+// is upgraded: the cluster role follows the synced resources, and the deployment the image and
+// the syncer flags. The other resources only depend on the sync target, apart from the secret
+// that changes when the token is rotated.
+var updatedSyncerResourceKinds = sets.NewString("ClusterRole", "Deployment")
+
+// SyncUpdateOptions configure the upgrade of the syncer of an existing sync target.
+type SyncUpdateOptions struct {
+	// Update requires the syncer of the sync target to exist already, and limits the output
+	// to the resources that change when the syncer is upgraded.
+	Update bool
+	// RotateToken replaces the token of the syncer service account by a new one. The previous token
+	// is revoked once the syncer using the new one is ready in the physical cluster.
+	RotateToken bool
+	// ToKubeconfig is the kubeconfig of the physical cluster. If set, the resources are applied
+	// to the physical cluster directly.
+	ToKubeconfig string
+	// ToContext is the context of ToKubeconfig to use, instead of the current context.
+	ToContext string
+	// DryRun only reports how the resources would change in the physical cluster.
+	DryRun bool
+}
+
+// syncerConfigHash returns the hash of the kubeconfig the syncer uses to connect to kcp.
+func syncerConfigHash(input templateInput) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{input.ServerURL, input.CAData, input.Token, input.KCPNamespace}, "\n")))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// splitSyncerResources splits the rendered syncer resources into their YAML documents.
+func splitSyncerResources(resources []byte) ([][]byte, error) {
+	reader := kubeyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(resources)))
+	var docs [][]byte
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the first document keeps the separator it starts with.
+		doc = bytes.TrimPrefix(doc, []byte("---\n"))
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// filterSyncerResources returns the rendered syncer resources of the given kinds.
+func filterSyncerResources(resources []byte, kinds sets.String) ([]byte, error) {
+	docs, err := splitSyncerResources(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered bytes.Buffer
+	for _, doc := range docs {
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, err
+		}
+		if !kinds.Has(typeMeta.Kind) {
+			continue
+		}
+		filtered.WriteString("---\n")
+		filtered.Write(doc)
+	}
+	return filtered.Bytes(), nil
+}
+
+// physicalClusterConfig loads the client configuration of the physical cluster the syncer is deployed to.
+func physicalClusterConfig(update SyncUpdateOptions) (*rest.Config, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: update.ToKubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: update.ToContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the physical cluster kubeconfig %s: %w", update.ToKubeconfig, err)
+	}
+	return config, nil
+}
+
+// applySyncerResources applies the rendered syncer resources to the physical cluster with server-side
+// apply, and reports which of them are created, configured or unchanged.
+func (c *Config) applySyncerResources(ctx context.Context, resources []byte, update SyncUpdateOptions) error {
+	config, err := physicalClusterConfig(update)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	docs, err := splitSyncerResources(resources)
+	if err != nil {
+		return err
+	}
+
+	patchOptions := metav1.PatchOptions{FieldManager: syncerApplyFieldManager, Force: pointer.Bool(true)}
+	dryRunSuffix := ""
+	if update.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+		dryRunSuffix = " (server dry run)"
+	}
+
+	for _, doc := range docs {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return err
+		}
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to map %s: %w", gvk, err)
+		}
+		var client dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			client = dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+
+		existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get %s %s: %w", gvk.Kind, obj.GetName(), err)
+		} else if apierrors.IsNotFound(err) {
+			existing = nil
+		}
+
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		applied, err := client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, patchOptions)
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+
+		result := "unchanged"
+		switch {
+		case existing == nil:
+			result = "created"
+		case !equalApartFromServerFields(existing, applied):
+			result = "configured"
+		}
+		fmt.Fprintf(c.Out, "%s/%s %s%s\n", strings.ToLower(gvk.Kind), obj.GetName(), result, dryRunSuffix)
+	}
+	return nil
+}
+
+// equalApartFromServerFields returns true if the objects are equal, apart from the metadata the server
+// updates on every write.
+func equalApartFromServerFields(a, b *unstructured.Unstructured) bool {
+	strip := func(obj *unstructured.Unstructured) map[string]interface{} {
+		obj = obj.DeepCopy()
+		obj.SetResourceVersion("")
+		obj.SetManagedFields(nil)
+		obj.SetGeneration(0)
+		return obj.Object
+	}
+	return equality.Semantic.DeepEqual(strip(a), strip(b))
+}
+
+// rotateServiceAccountToken issues a new token secret for the service account, and references it first
+// on the service account so that the syncer is configured with it. The previous token secrets are kept,
+// and remain valid, until the syncer using the new token is ready, see retireRotatedServiceAccountTokens.
+func (c *Config) rotateServiceAccountToken(ctx context.Context, kubeClient kubernetesclientset.Interface, namespace, name string) error {
+	sa, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ServiceAccount %s/%s: %w", namespace, name, err)
+	}
+	if len(sa.Secrets) == 0 {
+		// No token was issued yet, there is nothing to rotate.
+		return nil
+	}
+
+	c.ErrOut.Write([]byte(fmt.Sprintf("Rotating the token of service account %q.\n", name))) // nolint: errcheck
+	tokenSecret, err := kubeClient.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + "-token-",
+			Annotations: map[string]string{
+				corev1.ServiceAccountNameKey: name,
+				corev1.ServiceAccountUIDKey:  string(sa.UID),
+			},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create token Secret for ServiceAccount %s/%s: %w", namespace, name, err)
+	}
+
+	err = wait.PollImmediateWithContext(ctx, 100*time.Millisecond, 20*time.Second, func(ctx context.Context) (bool, error) {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, tokenSecret.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return len(secret.Data[corev1.ServiceAccountTokenKey]) > 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for a token to be issued in Secret %s/%s", namespace, tokenSecret.Name)
+	}
+
+	sa.Secrets = append([]corev1.ObjectReference{{Name: tokenSecret.Name}}, sa.Secrets...)
+	if _, err := kubeClient.CoreV1().ServiceAccounts(namespace).Update(ctx, sa, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to reference the token Secret %s on ServiceAccount %s/%s: %w", tokenSecret.Name, namespace, name, err)
+	}
+	return nil
+}
+
+// retireRotatedServiceAccountTokens deletes the token secrets replaced by the rotation of the token of the
+// service account, once the syncer using the new token is ready in the physical cluster. When the resources
+// are not applied to the physical cluster, the secrets are only listed, to be deleted after the new syncer
+// is deployed.
+func (c *Config) retireRotatedServiceAccountTokens(ctx context.Context, config *rest.Config, namespace, name, downstreamNamespace string, update SyncUpdateOptions) error {
+	kubeClient, err := kubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	sa, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ServiceAccount %s/%s: %w", namespace, name, err)
+	}
+	if len(sa.Secrets) < 2 {
+		return nil
+	}
+	var rotated []string
+	for _, ref := range sa.Secrets[1:] {
+		rotated = append(rotated, ref.Name)
+	}
+
+	if update.ToKubeconfig == "" {
+		// nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("\nThe previous tokens of service account %q remain valid. Once the new syncer is running, use\n\n  kubectl delete secret -n %q %s\n\nto revoke them.\n", name, namespace, strings.Join(rotated, " "))))
+		return nil
+	}
+
+	c.ErrOut.Write([]byte(fmt.Sprintf("Waiting for the syncer %s/%s to be ready with the new token.\n", downstreamNamespace, name))) // nolint: errcheck
+	if err := waitForSyncerReady(ctx, update, downstreamNamespace, name); err != nil {
+		return fmt.Errorf("the previous tokens of service account %q were not revoked: %w", name, err)
+	}
+
+	for _, secretName := range rotated {
+		c.ErrOut.Write([]byte(fmt.Sprintf("Revoking the previous token of service account %q.\n", name))) // nolint: errcheck
+		if err := kubeClient.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete token Secret %s/%s: %w", namespace, secretName, err)
+		}
+	}
+	return nil
+}
+
+// waitForSyncerReady waits for the rollout of the syncer deployment in the physical cluster to complete.
+func waitForSyncerReady(ctx context.Context, update SyncUpdateOptions, namespace, name string) error {
+	config, err := physicalClusterConfig(update)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetesclientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	var deployment *appsv1.Deployment
+	err = wait.PollImmediateWithContext(ctx, time.Second, 5*time.Minute, func(ctx context.Context) (bool, error) {
+		deployment, err = kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return deploymentRolledOut(deployment), nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for the rollout of Deployment %s/%s", namespace, name)
+	}
+	return nil
+}
+
+// deploymentRolledOut returns true if all the replicas of the deployment are updated and available.
+func deploymentRolledOut(deployment *appsv1.Deployment) bool {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
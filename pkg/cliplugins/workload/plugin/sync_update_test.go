@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+)
+
+func TestFilterSyncerResources(t *testing.T) {
+	resources, err := renderSyncerResources(templateInput{
+		ServerURL:       "server-url",
+		Token:           "token",
+		CAData:          "ca-data",
+		KCPNamespace:    "kcp-namespace",
+		Namespace:       "kcp-syncer-sync-target-name-34b23c4k",
+		LogicalCluster:  "root:default:foo",
+		SyncTarget:      "sync-target-name",
+		Image:           "image",
+		Replicas:        1,
+		ResourcesToSync: []string{"resource1", "resource2"},
+	}, "kcp-syncer-sync-target-name-34b23c4k")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		kinds    sets.String
+		expected []string
+	}{
+		"upgrade": {
+			kinds:    updatedSyncerResourceKinds,
+			expected: []string{"ClusterRole", "Deployment"},
+		},
+		"upgrade with token rotation": {
+			kinds:    updatedSyncerResourceKinds.Union(sets.NewString("Secret")),
+			expected: []string{"ClusterRole", "Secret", "Deployment"},
+		},
+		"all resources": {
+			kinds:    sets.NewString("Namespace", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Secret", "Deployment"),
+			expected: []string{"Namespace", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Secret", "Deployment"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, err := filterSyncerResources(resources, tc.kinds)
+			require.NoError(t, err)
+
+			docs, err := splitSyncerResources(filtered)
+			require.NoError(t, err)
+			var kinds []string
+			for _, doc := range docs {
+				var typeMeta metav1.TypeMeta
+				require.NoError(t, yaml.Unmarshal(doc, &typeMeta))
+				kinds = append(kinds, typeMeta.Kind)
+			}
+			require.Equal(t, tc.expected, kinds)
+		})
+	}
+}
+
+func TestSyncerConfigHash(t *testing.T) {
+	input := templateInput{ServerURL: "server-url", CAData: "ca-data", Token: "token", KCPNamespace: "kcp-namespace"}
+	hash := syncerConfigHash(input)
+	require.Len(t, hash, 16)
+
+	input.Image = "new-image"
+	require.Equal(t, hash, syncerConfigHash(input), "the hash should only depend on the kubeconfig")
+
+	input.Token = "rotated-token"
+	require.NotEqual(t, hash, syncerConfigHash(input), "the hash should change when the token is rotated")
+}
+
+func TestEqualApartFromServerFields(t *testing.T) {
+	newDeployment := func(resourceVersion string, generation int64, image string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":            "syncer",
+				"resourceVersion": resourceVersion,
+				"generation":      generation,
+			},
+			"spec": map[string]interface{}{"image": image},
+		}}
+		obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl-kcp", Operation: metav1.ManagedFieldsOperationApply}})
+		return obj
+	}
+
+	require.True(t, equalApartFromServerFields(newDeployment("1", 1, "image"), newDeployment("2", 2, "image")))
+	require.False(t, equalApartFromServerFields(newDeployment("1", 1, "image"), newDeployment("2", 2, "new-image")))
+}
+
+func TestDeploymentRolledOut(t *testing.T) {
+	newDeployment := func(generation, observedGeneration int64, replicas, updated, available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "syncer", Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32(1)},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: observedGeneration,
+				Replicas:           replicas,
+				UpdatedReplicas:    updated,
+				AvailableReplicas:  available,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		deployment *appsv1.Deployment
+		want       bool
+	}{
+		"rolled out":                     {deployment: newDeployment(2, 2, 1, 1, 1), want: true},
+		"new generation not observed":    {deployment: newDeployment(2, 1, 1, 1, 1)},
+		"new replica not available":      {deployment: newDeployment(2, 2, 1, 1, 0)},
+		"old replica not terminated yet": {deployment: newDeployment(2, 2, 2, 1, 2)},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, deploymentRolledOut(tc.deployment))
+		})
+	}
+}
@@ -112,13 +112,20 @@ func modifyCordon(ctx context.Context, config *rest.Config, syncTargetName strin
 	return nil
 }
 
-// Start draining the sync target and mark it as unschedulable
-func (c *Config) Drain(ctx context.Context, syncTargetName string) error {
+// Start draining the sync target and mark it as unschedulable. If wait is true, block until all the
+// objects are evicted from the sync target in the given workspaces, or all the workspaces using it if none is given,
+// the timeout expires, or the eviction stalls for stallTimeout.
+func (c *Config) Drain(ctx context.Context, syncTargetName string, wait bool, workspaces []string, timeout, stallTimeout time.Duration) error {
 	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
 	if err != nil {
 		return err
 	}
 
+	clusterConfig, clusterNames, err := workspacesConfig(config, workspaces)
+	if err != nil {
+		return err
+	}
+
 	kcpClient, err := kcpclientset.NewForConfig(config)
 	if err != nil {
 		return fmt.Errorf("failed to create kcp client: %w", err)
@@ -135,6 +142,9 @@ func (c *Config) Drain(ctx context.Context, syncTargetName string) error {
 	// See if there is nothing to do
 	if syncTarget.Spec.EvictAfter != nil && syncTarget.Spec.Unschedulable {
 		fmt.Println(syncTargetName, "already draining")
+		if wait {
+			return c.waitForEviction(ctx, clusterConfig, kcpClient, syncTargetName, clusterNames, timeout, stallTimeout)
+		}
 		return nil
 	}
 
@@ -149,6 +159,8 @@ func (c *Config) Drain(ctx context.Context, syncTargetName string) error {
 
 	fmt.Println(syncTargetName, "draining")
 
+	if wait {
+		return c.waitForEviction(ctx, clusterConfig, kcpClient, syncTargetName, clusterNames, timeout, stallTimeout)
+	}
 	return nil
-
 }
@@ -17,11 +17,17 @@ limitations under the License.
 package indexers
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/kcp-dev/logicalcluster"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 const (
@@ -29,6 +35,8 @@ const (
 	ByLogicalCluster = "kcp-global-byLogicalCluster"
 	// ByLogicalClusterAndNamespace is the name for the index that indexes by an object's logical cluster and namespace.
 	ByLogicalClusterAndNamespace = "kcp-global-byLogicalClusterAndNamespace"
+	// ByScheduledSyncTarget is the name for the index that indexes namespaces by the names of the SyncTargets they are scheduled to.
+	ByScheduledSyncTarget = "kcp-global-byScheduledSyncTarget"
 	// IndexAPIExportByIdentity is the indexer name for by identity index for the API Export indexers.
 	IndexAPIExportByIdentity = "byIdentity"
 )
@@ -67,3 +75,36 @@ func IndexByLogicalClusterAndNamespace(obj interface{}) ([]string, error) {
 
 	return []string{clusters.ToClusterAwareKey(logicalcluster.From(a), a.GetNamespace())}, nil
 }
+
+// IndexByScheduledSyncTarget is an index function that indexes namespaces by the names of the SyncTargets
+// they are scheduled to, i.e. the SyncTargets of their state labels.
+func IndexByScheduledSyncTarget(obj interface{}) ([]string, error) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a Namespace, but is %T", obj)
+	}
+
+	var syncTargets []string
+	for k := range ns.Labels {
+		if strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) {
+			syncTargets = append(syncTargets, strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix))
+		}
+	}
+	return syncTargets, nil
+}
+
+// AddIfNotPresent adds the indexers to the informer, apart from the ones already added with the same name.
+// It allows the controllers sharing an informer to register the same global index.
+func AddIfNotPresent(informer cache.SharedIndexInformer, indexers cache.Indexers) error {
+	existing := informer.GetIndexer().GetIndexers()
+	toAdd := cache.Indexers{}
+	for name, indexFunc := range indexers {
+		if _, found := existing[name]; !found {
+			toAdd[name] = indexFunc
+		}
+	}
+	if len(toAdd) == 0 {
+		return nil
+	}
+	return informer.AddIndexers(toAdd)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster"
+	jwt2 "gopkg.in/square/go-jose.v2/jwt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/request/union"
+	tokencache "k8s.io/apiserver/pkg/authentication/token/cache"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/index"
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+// ShardClientFunc returns a client for the shard with the given base URL.
+type ShardClientFunc func(shardURL string) (kubernetes.ClusterInterface, error)
+
+// NewTokenAuthenticator returns an authenticator of bearer tokens as configured by the given
+// options, or nil if no token authentication is enabled. kcp service account tokens of known
+// workspaces are reviewed by the shard of the workspace the service account lives in, before
+// OIDC and webhook tokens are tried.
+func NewTokenAuthenticator(o *proxyoptions.Authentication, index index.Index, shardClient ShardClientFunc) (authenticator.Request, error) {
+	config, err := o.BuiltIn.ToAuthenticationConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid authentication config: %w", err)
+	}
+	builtIn, _, err := config.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token authenticator: %w", err)
+	}
+
+	var authenticators []authenticator.Request
+	if o.ServiceAccountTokens {
+		serviceAccountAuth := tokencache.New(newServiceAccountTokenAuthenticator(index, shardClient), true, o.BuiltIn.TokenSuccessCacheTTL, o.BuiltIn.TokenFailureCacheTTL)
+		authenticators = append(authenticators, bearertoken.New(serviceAccountAuth))
+	}
+	if builtIn != nil {
+		authenticators = append(authenticators, builtIn)
+	}
+
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return union.New(authenticators...), nil
+}
+
+// serviceAccountTokenAuthenticator authenticates kcp service account tokens by a TokenReview
+// against the shard of the workspace the service account lives in. As the workspace is taken
+// from the unverified claims of the token, only workspaces already in the index are reviewed,
+// such that arbitrary tokens cannot trigger live lookups. Other tokens are not authenticated.
+type serviceAccountTokenAuthenticator struct {
+	index       index.Index
+	shardClient ShardClientFunc
+
+	lock    sync.Mutex
+	clients map[string]kubernetes.ClusterInterface
+}
+
+func newServiceAccountTokenAuthenticator(index index.Index, shardClient ShardClientFunc) *serviceAccountTokenAuthenticator {
+	return &serviceAccountTokenAuthenticator{
+		index:       index,
+		shardClient: shardClient,
+		clients:     map[string]kubernetes.ClusterInterface{},
+	}
+}
+
+func (a *serviceAccountTokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	clusterName, ok := serviceAccountTokenClusterName(token)
+	if !ok {
+		return nil, false, nil
+	}
+
+	shardURL, found := a.index.Lookup(clusterName)
+	if !found {
+		klog.V(4).Infof("Not reviewing service account token of unknown cluster %q", clusterName)
+		return nil, false, nil
+	}
+	client, err := a.clientFor(shardURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	review, err := client.Cluster(clusterName).AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// failures of the shard are returned, such that the token cache does not remember them as
+		// rejections of the token. The other authenticators of the union still get to authenticate it.
+		return nil, false, fmt.Errorf("failed to review service account token of cluster %q: %w", clusterName, err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			klog.V(4).Infof("Service account token of cluster %q not authenticated: %s", clusterName, review.Status.Error)
+		}
+		return nil, false, nil
+	}
+
+	extra := map[string][]string{}
+	for key, values := range review.Status.User.Extra {
+		extra[key] = values
+	}
+	return &authenticator.Response{
+		Audiences: review.Status.Audiences,
+		User: &user.DefaultInfo{
+			Name:   review.Status.User.Username,
+			UID:    review.Status.User.UID,
+			Groups: review.Status.User.Groups,
+			Extra:  extra,
+		},
+	}, true, nil
+}
+
+// clientFor returns the client of the shard with the given base URL, creating it if it does
+// not exist yet.
+func (a *serviceAccountTokenAuthenticator) clientFor(shardURL string) (kubernetes.ClusterInterface, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if client, found := a.clients[shardURL]; found {
+		return client, nil
+	}
+	client, err := a.shardClient(shardURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for shard %s: %w", shardURL, err)
+	}
+	a.clients[shardURL] = client
+	return client, nil
+}
+
+// serviceAccountTokenClusterName returns the logical cluster of the service account the given
+// token belongs to, without verifying the token. It returns false if the token is not a kcp
+// service account token.
+func serviceAccountTokenClusterName(token string) (logicalcluster.Name, bool) {
+	decoded, err := jwt2.ParseSigned(token)
+	if err != nil {
+		return logicalcluster.Name{}, false
+	}
+
+	var claims struct {
+		// bound service account tokens
+		Kubernetes struct {
+			ClusterName string `json:"clusterName"`
+		} `json:"kubernetes.io"`
+		// legacy service account tokens
+		LegacyClusterName string `json:"kubernetes.io/serviceaccount/clusterName"`
+	}
+	if err := decoded.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return logicalcluster.Name{}, false
+	}
+
+	clusterName := claims.Kubernetes.ClusterName
+	if clusterName == "" {
+		clusterName = claims.LegacyClusterName
+	}
+	if clusterName == "" {
+		return logicalcluster.Name{}, false
+	}
+	return logicalcluster.New(clusterName), true
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt2 "gopkg.in/square/go-jose.v2/jwt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func signedToken(t *testing.T, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	require.NoError(t, err)
+	token, err := jwt2.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return token
+}
+
+// fakeKubeClusterClient serves all logical clusters from the same fake clientset, recording
+// the clusters requested.
+type fakeKubeClusterClient struct {
+	client   *kubernetesfake.Clientset
+	clusters []logicalcluster.Name
+}
+
+func (c *fakeKubeClusterClient) Cluster(name logicalcluster.Name) kubernetes.Interface {
+	c.clusters = append(c.clusters, name)
+	return c.client
+}
+
+func TestServiceAccountTokenAuthenticator(t *testing.T) {
+	tests := []struct {
+		name          string
+		claims        map[string]interface{}
+		authenticated bool
+		wantUser      string
+		wantCluster   string
+		wantShard     string
+		reviewErr     bool
+	}{
+		{
+			name: "bound service account token",
+			claims: map[string]interface{}{
+				"kubernetes.io": map[string]interface{}{"clusterName": "root:org:ws"},
+			},
+			authenticated: true,
+			wantUser:      "system:serviceaccount:default:default",
+			wantCluster:   "root:org:ws",
+			wantShard:     "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name: "legacy service account token",
+			claims: map[string]interface{}{
+				"kubernetes.io/serviceaccount/clusterName": "root",
+			},
+			authenticated: true,
+			wantUser:      "system:serviceaccount:default:default",
+			wantCluster:   "root",
+			wantShard:     "https://root.kcp.dev:6443",
+		},
+		{
+			name: "service account token rejected by the shard",
+			claims: map[string]interface{}{
+				"kubernetes.io": map[string]interface{}{"clusterName": "root:org:ws"},
+			},
+			wantCluster: "root:org:ws",
+			wantShard:   "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name: "service account token of a workspace not in the index",
+			claims: map[string]interface{}{
+				"kubernetes.io": map[string]interface{}{"clusterName": "root:org:new"},
+			},
+		},
+		{
+			name: "shard failing to review the service account token",
+			claims: map[string]interface{}{
+				"kubernetes.io": map[string]interface{}{"clusterName": "root:org:ws"},
+			},
+			reviewErr:   true,
+			wantCluster: "root:org:ws",
+			wantShard:   "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name:   "other token",
+			claims: map[string]interface{}{"sub": "user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := &fakeIndex{
+				baseURLs: map[logicalcluster.Name]string{
+					logicalcluster.New("root"):        "https://root.kcp.dev:6443",
+					logicalcluster.New("root:org:ws"): "https://shard-1.kcp.dev:6443",
+				},
+				onMissBaseURLs: map[logicalcluster.Name]string{
+					logicalcluster.New("root:org:new"): "https://shard-2.kcp.dev:6443",
+				},
+			}
+			token := signedToken(t, tt.claims)
+
+			kubeClient := kubernetesfake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				require.Equal(t, token, review.Spec.Token)
+				if tt.reviewErr {
+					return true, nil, errors.New("shard unavailable")
+				}
+				if !tt.authenticated {
+					review.Status.Error = "invalid token"
+					return true, review, nil
+				}
+				review.Status.Authenticated = true
+				review.Status.User = authenticationv1.UserInfo{
+					Username: "system:serviceaccount:default:default",
+					Groups:   []string{"system:serviceaccounts", "system:authenticated"},
+					Extra: map[string]authenticationv1.ExtraValue{
+						"authentication.kubernetes.io/cluster-name": {tt.wantCluster},
+					},
+				}
+				return true, review, nil
+			})
+			clusterClient := &fakeKubeClusterClient{client: kubeClient}
+			var gotShards []string
+			auth := newServiceAccountTokenAuthenticator(index, func(shardURL string) (kubernetes.ClusterInterface, error) {
+				gotShards = append(gotShards, shardURL)
+				return clusterClient, nil
+			})
+
+			resp, ok, err := auth.AuthenticateToken(context.Background(), token)
+			if tt.reviewErr {
+				// failures of the shard are returned, so that they are not cached as rejections.
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			if tt.wantShard != "" {
+				require.Equal(t, []string{tt.wantShard}, gotShards)
+				require.Equal(t, []logicalcluster.Name{logicalcluster.New(tt.wantCluster)}, clusterClient.clusters)
+			} else {
+				require.Empty(t, gotShards)
+			}
+			require.Equal(t, tt.wantUser != "", ok)
+			if !ok {
+				return
+			}
+			require.Equal(t, tt.wantUser, resp.User.GetName())
+			require.Equal(t, []string{"system:serviceaccounts", "system:authenticated"}, resp.User.GetGroups())
+			require.Equal(t, map[string][]string{"authentication.kubernetes.io/cluster-name": {tt.wantCluster}}, resp.User.GetExtra())
+		})
+	}
+}
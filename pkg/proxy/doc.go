@@ -17,12 +17,18 @@ limitations under the License.
 // Package proxy provides a reverse proxy that accepts client certificates and
 // forwards Common Name and Organizations to backend API servers in HTTP
 // headers. The proxy terminates client TLS and communicates with API servers
-// via mTLS. Traffic is routed based on paths.
+// via mTLS. Traffic is routed based on paths. Requests to /clusters/ and
+// /services/ are routed to the shard the workspace lives on, as found in the
+// ClusterWorkspaceShards, and hence do not need a backend.
+//
+// Besides client certificates, the proxy can authenticate OIDC, webhook and kcp
+// service account tokens, the latter by a TokenReview against the shard of the
+// workspace the service account lives in. Authenticated users are forwarded in
+// the user, group and extra headers. Other tokens are passed on unchanged.
 //
 // An example configuration:
 //
 //  - path: /services/
-//    backend: https://localhost:6444
 //    backend_server_ca: certs/kcp-ca-cert.pem
 //    proxy_client_cert: certs/proxy-client-cert.pem
 //    proxy_client_key: certs/proxy-client-key.pem
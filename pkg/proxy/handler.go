@@ -17,23 +17,37 @@ limitations under the License.
 package proxy
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/kcp-dev/logicalcluster"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/filters"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	kcpauthorization "github.com/kcp-dev/kcp/pkg/authorization"
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 )
 
-func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
+// notReadyRetryAfterSeconds is the time after which clients are asked to retry requests to
+// workspaces that are not ready yet.
+const notReadyRetryAfterSeconds = 1
+
+func shardHandler(index index.Index, proxy http.Handler, transport http.RoundTripper) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 3)
 		if len(cs) != 3 || cs[0] != "clusters" {
@@ -49,29 +63,168 @@ func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
 		}
 
 		clusterName := logicalcluster.New(cs[1])
+		if clusterName == logicalcluster.Wildcard {
+			klog.V(4).Infof("Wildcard cluster name %q", req.URL.Path)
+			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+			return
+		}
 		if !tenancyhelper.IsValidCluster(clusterName) {
-			// this includes wildcards
+			// such a workspace cannot exist, hence nothing is leaked.
 			klog.V(4).Infof("Invalid cluster name %q", req.URL.Path)
-			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+			responsewriters.ErrorNegotiated(apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), clusterName.String()), kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
 			return
 		}
 
-		shardURLString, found := index.Lookup(clusterName)
-		if !found {
-			klog.V(4).Infof("Unknown cluster %q", clusterName)
+		if _, found := index.Lookup(clusterName); !found && !isAuthenticated(attributes.GetUser()) {
+			// live lookups are done with the credentials of the front-proxy, hence only for authenticated users.
+			klog.V(4).Infof("Unknown cluster %q for unauthenticated user", clusterName)
 			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
 			return
 		}
-		shardURL, err := url.Parse(shardURLString)
+
+		shardURLString, err := index.LookupOnMiss(ctx, clusterName)
+		if err != nil {
+			klog.V(4).Infof("Failed to look up cluster %q: %v", clusterName, err)
+			writeLookupError(w, req, attributes, clusterName, err, func() bool {
+				return canGetClusterWorkspace(req, index, transport, clusterName)
+			})
+			return
+		}
+
+		proxyToShard(w, req, proxy, shardURLString)
+	}
+}
+
+// isAuthenticated returns whether the user of a request was authenticated, i.e. is not anonymous.
+func isAuthenticated(u user.Info) bool {
+	if u == nil || u.GetName() == user.Anonymous {
+		return false
+	}
+	return !sets.NewString(u.GetGroups()...).Has(user.AllUnauthenticated)
+}
+
+// writeLookupError answers a request to a logical cluster that could not be looked up. Only users
+// that may get the ClusterWorkspace of the logical cluster learn whether it does not exist, or is not
+// ready yet and the request should be retried. Everybody else is forbidden to access the workspace,
+// independently of whether it exists.
+func writeLookupError(w http.ResponseWriter, req *http.Request, attributes authorizer.Attributes, clusterName logicalcluster.Name, err error, allowed func() bool) {
+	switch {
+	case errors.Is(err, index.ErrNotFound) && allowed():
+		responsewriters.ErrorNegotiated(apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), clusterName.Base()), kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+	case errors.Is(err, index.ErrNotReady) && allowed():
+		statusErr := apierrors.NewServiceUnavailable(fmt.Sprintf("workspace %q is not ready yet, please retry later", clusterName))
+		statusErr.ErrStatus.Details = &metav1.StatusDetails{RetryAfterSeconds: notReadyRetryAfterSeconds}
+		w.Header().Set("Retry-After", strconv.Itoa(notReadyRetryAfterSeconds))
+		responsewriters.ErrorNegotiated(statusErr, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+	default:
+		responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+	}
+}
+
+// canGetClusterWorkspace returns whether the user of the request may get the ClusterWorkspace of
+// the logical cluster in its parent. The request is sent to the shard of the parent with the
+// credentials of the original request, such that the shard authorizes it.
+func canGetClusterWorkspace(req *http.Request, index index.Index, transport http.RoundTripper, clusterName logicalcluster.Name) bool {
+	parent, hasParent := clusterName.Parent()
+	if !hasParent {
+		return false
+	}
+	parentURLString, found := index.Lookup(parent)
+	if !found {
+		return false
+	}
+	parentURL, err := url.Parse(parentURLString)
+	if err != nil {
+		return false
+	}
+
+	checkURL := &url.URL{
+		Scheme: parentURL.Scheme,
+		Host:   parentURL.Host,
+		Path:   parent.Path() + "/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/" + clusterName.Base(),
+	}
+	checkReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, checkURL.String(), nil)
+	if err != nil {
+		return false
+	}
+	checkReq.Header = req.Header.Clone()
+	checkReq.Header.Set("Accept", "application/json")
+	checkReq.Header.Del("Content-Type")
+	checkReq.Header.Del("Content-Encoding")
+
+	resp, err := transport.RoundTrip(checkReq)
+	if err != nil {
+		klog.V(4).Infof("Failed to check access to ClusterWorkspace of cluster %q: %v", clusterName, err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	// the user is allowed to get the ClusterWorkspace, independently of whether it exists.
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound
+}
+
+// servicesHandler routes virtual workspace requests to the virtual workspace apiserver of the
+// shard the workspace in the path lives on. Virtual workspaces that are not workspace specific,
+// or requests for all workspaces, are routed to the root shard.
+func servicesHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 6)
+		if len(cs) < 2 || cs[0] != "services" {
+			http.NotFound(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		attributes, err := filters.GetAuthorizerAttributes(ctx)
 		if err != nil {
 			responsewriters.InternalError(w, req, err)
 			return
 		}
 
-		klog.V(4).Infof("Redirecting %q to %s", req.URL.Path, shardURL)
+		clusterName := tenancyv1alpha1.RootCluster
+		switch cs[1] {
+		case "syncer", "apiexport":
+			//  /services/syncer/root:org:ws/<sync-target-name>/clusters/*/api/v1/configmaps
+			//  /services/apiexport/root:org:ws/<apiexport-name>/clusters/*/api/v1/configmaps
+			if len(cs) < 3 || cs[2] == "" {
+				http.NotFound(w, req)
+				return
+			}
+			clusterName = logicalcluster.New(cs[2])
+		case "initializingworkspaces":
+			//  /services/initializingworkspaces/<initializer>/clusters/<cluster>/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces
+			if len(cs) >= 5 && cs[3] == "clusters" && cs[4] != logicalcluster.Wildcard.String() {
+				clusterName = logicalcluster.New(cs[4])
+			}
+		}
+
+		if !tenancyhelper.IsValidCluster(clusterName) {
+			klog.V(4).Infof("Invalid cluster name %q", req.URL.Path)
+			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+			return
+		}
+
+		shardURLString, found := index.LookupVirtualWorkspaceURL(clusterName)
+		if !found {
+			klog.V(4).Infof("Unknown cluster %q", clusterName)
+			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
+			return
+		}
+
+		proxyToShard(w, req, proxy, shardURLString)
+	}
+}
 
-		ctx = WithShardURL(ctx, shardURL)
-		req = req.WithContext(ctx)
-		proxy.ServeHTTP(w, req)
+func proxyToShard(w http.ResponseWriter, req *http.Request, proxy http.Handler, shardURLString string) {
+	shardURL, err := url.Parse(shardURLString)
+	if err != nil {
+		responsewriters.InternalError(w, req, err)
+		return
 	}
+
+	klog.V(4).Infof("Redirecting %q to %s", req.URL.Path, shardURL)
+
+	ctx := WithShardURL(req.Context(), shardURL)
+	req = req.WithContext(ctx)
+	proxy.ServeHTTP(w, req)
 }
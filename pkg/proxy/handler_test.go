@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/index"
+)
+
+type fakeIndex struct {
+	baseURLs            map[logicalcluster.Name]string
+	virtualWorkspaceURL map[logicalcluster.Name]string
+	onMissBaseURLs      map[logicalcluster.Name]string
+	onMissErrs          map[logicalcluster.Name]error
+}
+
+func (i *fakeIndex) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
+	url, found := i.baseURLs[logicalCluster]
+	return url, found
+}
+
+func (i *fakeIndex) LookupVirtualWorkspaceURL(logicalCluster logicalcluster.Name) (string, bool) {
+	url, found := i.virtualWorkspaceURL[logicalCluster]
+	return url, found
+}
+
+func (i *fakeIndex) LookupOnMiss(ctx context.Context, logicalCluster logicalcluster.Name) (string, error) {
+	if url, found := i.baseURLs[logicalCluster]; found {
+		return url, nil
+	}
+	if url, found := i.onMissBaseURLs[logicalCluster]; found {
+		return url, nil
+	}
+	if err, found := i.onMissErrs[logicalCluster]; found {
+		return "", err
+	}
+	return "", index.ErrNotFound
+}
+
+// fakeAccessTransport answers requests for ClusterWorkspaces with the given status.
+type fakeAccessTransport struct {
+	status   int
+	gotPaths []string
+}
+
+func (rt *fakeAccessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.gotPaths = append(rt.gotPaths, req.URL.Host+req.URL.Path)
+	return &http.Response{StatusCode: rt.status, Body: http.NoBody, Request: req}, nil
+}
+
+func TestShardHandler(t *testing.T) {
+	idx := &fakeIndex{
+		baseURLs: map[logicalcluster.Name]string{
+			logicalcluster.New("root"):        "https://root.kcp.dev:6443",
+			logicalcluster.New("root:org"):    "https://shard-1.kcp.dev:6443",
+			logicalcluster.New("root:org:ws"): "https://shard-1.kcp.dev:6443",
+		},
+		onMissBaseURLs: map[logicalcluster.Name]string{
+			logicalcluster.New("root:org:new"): "https://shard-2.kcp.dev:6443",
+		},
+		onMissErrs: map[logicalcluster.Name]error{
+			logicalcluster.New("root:org:initializing"): index.ErrNotReady,
+		},
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		anonymous       bool
+		accessStatus    int
+		wantStatus      int
+		wantRetryAfter  string
+		wantShard       string
+		wantAccessPaths []string
+	}{
+		{
+			name:      "indexed workspace",
+			path:      "/clusters/root:org:ws/api/v1/configmaps",
+			wantShard: "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name:      "workspace found on miss",
+			path:      "/clusters/root:org:new/api/v1/configmaps",
+			wantShard: "https://shard-2.kcp.dev:6443",
+		},
+		{
+			name:            "unknown workspace for an authorized user",
+			path:            "/clusters/root:org:unknown/api/v1/configmaps",
+			accessStatus:    http.StatusNotFound,
+			wantStatus:      http.StatusNotFound,
+			wantAccessPaths: []string{"shard-1.kcp.dev:6443/clusters/root:org/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/unknown"},
+		},
+		{
+			name:            "unknown workspace for an unauthorized user",
+			path:            "/clusters/root:org:unknown/api/v1/configmaps",
+			accessStatus:    http.StatusForbidden,
+			wantStatus:      http.StatusForbidden,
+			wantAccessPaths: []string{"shard-1.kcp.dev:6443/clusters/root:org/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/unknown"},
+		},
+		{
+			name:            "not ready workspace for an authorized user",
+			path:            "/clusters/root:org:initializing/api/v1/configmaps",
+			accessStatus:    http.StatusOK,
+			wantStatus:      http.StatusServiceUnavailable,
+			wantRetryAfter:  "1",
+			wantAccessPaths: []string{"shard-1.kcp.dev:6443/clusters/root:org/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/initializing"},
+		},
+		{
+			name:            "not ready workspace for an unauthorized user",
+			path:            "/clusters/root:org:initializing/api/v1/configmaps",
+			accessStatus:    http.StatusForbidden,
+			wantStatus:      http.StatusForbidden,
+			wantAccessPaths: []string{"shard-1.kcp.dev:6443/clusters/root:org/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/initializing"},
+		},
+		{
+			name:         "workspace with an unknown parent",
+			path:         "/clusters/root:unknown:ws/api/v1/configmaps",
+			accessStatus: http.StatusOK,
+			wantStatus:   http.StatusForbidden,
+		},
+		{
+			name:      "indexed workspace for an anonymous user",
+			path:      "/clusters/root:org:ws/api/v1/configmaps",
+			anonymous: true,
+			wantShard: "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name:         "workspace not in the index for an anonymous user",
+			path:         "/clusters/root:org:new/api/v1/configmaps",
+			anonymous:    true,
+			accessStatus: http.StatusOK,
+			wantStatus:   http.StatusForbidden,
+		},
+		{
+			name:       "wildcard",
+			path:       "/clusters/*/api/v1/configmaps",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid workspace",
+			path:       "/clusters/foo/api/v1/configmaps",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotShard string
+			proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotShard = ShardURLFrom(req.Context()).String()
+			})
+			transport := &fakeAccessTransport{status: tt.accessStatus}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+			u := &user.DefaultInfo{Name: "user", Groups: []string{user.AllAuthenticated}}
+			if tt.anonymous {
+				u = &user.DefaultInfo{Name: user.Anonymous, Groups: []string{user.AllUnauthenticated}}
+			}
+			req = req.WithContext(request.WithUser(req.Context(), u))
+			w := httptest.NewRecorder()
+			shardHandler(idx, proxy, transport).ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantAccessPaths, transport.gotPaths)
+			if tt.wantStatus != 0 {
+				require.Equal(t, tt.wantStatus, w.Code)
+				require.Equal(t, tt.wantRetryAfter, w.Header().Get("Retry-After"))
+				require.Empty(t, gotShard)
+				return
+			}
+			require.Equal(t, tt.wantShard, gotShard)
+		})
+	}
+}
+
+func TestServicesHandler(t *testing.T) {
+	index := &fakeIndex{
+		virtualWorkspaceURL: map[logicalcluster.Name]string{
+			logicalcluster.New("root"):        "https://root.kcp.dev:6444",
+			logicalcluster.New("root:org:ws"): "https://shard-1.kcp.dev:6444",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantShard  string
+	}{
+		{
+			name:      "syncer virtual workspace",
+			path:      "/services/syncer/root:org:ws/cluster-1/clusters/*/api/v1/configmaps",
+			wantShard: "https://shard-1.kcp.dev:6444",
+		},
+		{
+			name:      "apiexport virtual workspace",
+			path:      "/services/apiexport/root:org:ws/kubernetes/clusters/*/api/v1/configmaps",
+			wantShard: "https://shard-1.kcp.dev:6444",
+		},
+		{
+			name:      "initializingworkspaces virtual workspace for a cluster",
+			path:      "/services/initializingworkspaces/root:org:ws:type/clusters/root:org:ws/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces",
+			wantShard: "https://shard-1.kcp.dev:6444",
+		},
+		{
+			name:      "initializingworkspaces virtual workspace for all clusters",
+			path:      "/services/initializingworkspaces/root:org:ws:type/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces",
+			wantShard: "https://root.kcp.dev:6444",
+		},
+		{
+			name:      "other virtual workspace",
+			path:      "/services/workspaces/root:org/all/apis/tenancy.kcp.dev/v1beta1/workspaces",
+			wantShard: "https://root.kcp.dev:6444",
+		},
+		{
+			name:       "unknown workspace",
+			path:       "/services/syncer/root:org:unknown/cluster-1/clusters/*/api/v1/configmaps",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid workspace",
+			path:       "/services/apiexport/foo/kubernetes/clusters/*/api/v1/configmaps",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "missing workspace",
+			path:       "/services/syncer/",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotShard string
+			proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotShard = ShardURLFrom(req.Context()).String()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{}))
+			w := httptest.NewRecorder()
+			servicesHandler(index, proxy).ServeHTTP(w, req)
+
+			if tt.wantStatus != 0 {
+				require.Equal(t, tt.wantStatus, w.Code)
+				require.Empty(t, gotShard)
+				return
+			}
+			require.Equal(t, tt.wantShard, gotShard)
+		})
+	}
+}
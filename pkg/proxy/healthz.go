@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/server/healthz"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/index"
+)
+
+// backendCheckTimeout is the timeout of a single backend reachability check.
+const backendCheckTimeout = 5 * time.Second
+
+// ShardIndex is an index.Index that knows whether it has synced, and the URLs of the
+// shards it routes to.
+type ShardIndex interface {
+	index.Index
+
+	// Synced returns an error if the index has not synced yet.
+	Synced() error
+	// ShardURLs returns the base URLs of all shards.
+	ShardURLs() []string
+	// VirtualWorkspaceURLs returns the virtual workspace URLs of all shards.
+	VirtualWorkspaceURLs() []string
+}
+
+// indexSyncedCheck is ready when the index has synced, i.e. when requests to all known
+// workspaces can be routed.
+func indexSyncedCheck(index ShardIndex) healthz.HealthChecker {
+	return healthz.NamedCheck("index-synced", func(_ *http.Request) error {
+		return index.Synced()
+	})
+}
+
+// backendsReachableCheck is ready when all the given backends answer to /livez through
+// the given transport. Any response other than a server error means the backend is reachable.
+func backendsReachableCheck(name string, backendURLs func() []string, transport http.RoundTripper) healthz.HealthChecker {
+	client := &http.Client{Transport: transport, Timeout: backendCheckTimeout}
+
+	return healthz.NamedCheck(name, func(req *http.Request) error {
+		urls := backendURLs()
+
+		var lock sync.Mutex
+		var errs []error
+		var wg sync.WaitGroup
+		for _, url := range urls {
+			wg.Add(1)
+			go func(url string) {
+				defer wg.Done()
+				if err := checkBackend(req.Context(), client, url); err != nil {
+					lock.Lock()
+					defer lock.Unlock()
+					errs = append(errs, err)
+				}
+			}(url)
+		}
+		wg.Wait()
+
+		return utilerrors.NewAggregate(errs)
+	})
+}
+
+func checkBackend(ctx context.Context, client *http.Client, backendURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(backendURL, "/")+"/livez", nil)
+	if err != nil {
+		return fmt.Errorf("backend %s: %w", backendURL, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("backend %s not reachable: %w", backendURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("backend %s not live: %s", backendURL, resp.Status)
+	}
+	return nil
+}
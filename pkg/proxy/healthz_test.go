@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendsReachableCheck(t *testing.T) {
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/livez", req.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer live.Close()
+
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	down.Close()
+
+	tests := []struct {
+		name     string
+		backends []string
+		wantErrs []string
+	}{
+		{
+			name: "no backends",
+		},
+		{
+			name:     "live and forbidden backends",
+			backends: []string{live.URL, forbidden.URL + "/"},
+		},
+		{
+			name:     "failing and down backends",
+			backends: []string{live.URL, failing.URL, down.URL},
+			wantErrs: []string{"backend " + failing.URL + " not live: 500", "backend " + down.URL + " not reachable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := backendsReachableCheck("test", func() []string { return tt.backends }, http.DefaultTransport)
+			require.Equal(t, "test", check.Name())
+
+			err := check.Check(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if len(tt.wantErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErrs {
+				require.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestBackendCheckName(t *testing.T) {
+	require.Equal(t, "backend-default-reachable", backendCheckName("/"))
+	require.Equal(t, "backend-api-v1-reachable", backendCheckName("/api/v1/"))
+}
@@ -18,14 +18,22 @@ package index
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
+	"golang.org/x/sync/singleflight"
 
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -41,11 +49,36 @@ const (
 	controllerName = "kcp-clusterworkspace-index"
 
 	clusterWorkspaceResyncPeriod = 2 * time.Hour
+
+	// negativeCacheTTL is the time for which failed live lookups of logical clusters are cached.
+	negativeCacheTTL = 5 * time.Second
+	// negativeCacheSize is the maximum number of failed live lookups that are cached. The least recently
+	// used entries are evicted first.
+	negativeCacheSize = 1000
+	// liveLookupTimeout bounds a live lookup of a logical cluster, which is shared by all the
+	// concurrent callers and so does not run under the context of any of them.
+	liveLookupTimeout = 10 * time.Second
+)
+
+var (
+	// ErrNotFound is returned by live lookups of logical clusters that do not exist.
+	ErrNotFound = errors.New("logical cluster not found")
+	// ErrNotReady is returned by live lookups of logical clusters that exist, but are not scheduled
+	// to a known shard yet.
+	ErrNotReady = errors.New("logical cluster not ready")
 )
 
 // Index implements a mapping from logical cluster to (shard) URL.
 type Index interface {
+	// Lookup returns the base URL of the shard the logical cluster lives on.
 	Lookup(logicalCluster logicalcluster.Name) (string, bool)
+	// LookupOnMiss is like Lookup, but looks up logical clusters that are not in the index yet
+	// in the ClusterWorkspaces of their parent. It returns ErrNotFound or ErrNotReady if the
+	// logical cluster does not exist, or is not scheduled yet.
+	LookupOnMiss(ctx context.Context, logicalCluster logicalcluster.Name) (string, error)
+	// LookupVirtualWorkspaceURL returns the URL of the virtual workspace apiserver of the shard
+	// the logical cluster lives on.
+	LookupVirtualWorkspaceURL(logicalCluster logicalcluster.Name) (string, bool)
 }
 
 type ClusterWorkspaceClientGetter func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclientset.ClusterInterface, error)
@@ -65,12 +98,17 @@ func NewController(
 
 		clusterWorkspaceShardIndexer: clusterWorkspaceShardInformer.Informer().GetIndexer(),
 		clusterWorkspaceShardLister:  clusterWorkspaceShardInformer.Lister(),
+		clusterWorkspaceShardSynced:  clusterWorkspaceShardInformer.Informer().HasSynced,
 
 		shardClusterWorkspaceInformers: map[string]cache.SharedIndexInformer{},
 		shardClusterWorkspaceStopCh:    map[string]chan struct{}{},
+		shardClusterWorkspaceBaseURLs:  map[string]string{},
+		shardClients:                   map[string]kcpclientset.ClusterInterface{},
 
-		workspaceShardNames: map[logicalcluster.Name]string{},
-		shardBaseURLs:       map[string]string{},
+		workspaceShardNames:       map[logicalcluster.Name]string{},
+		negativeCache:             utilcache.NewLRUExpireCache(negativeCacheSize),
+		shardBaseURLs:             map[string]string{},
+		shardVirtualWorkspaceURLs: map[string]string{},
 	}
 
 	c.clusterWorkspaceHandler = cache.ResourceEventHandlerFuncs{
@@ -84,6 +122,7 @@ func NewController(
 				c.lock.Lock()
 				defer c.lock.Unlock()
 				c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)] = expected
+				c.negativeCache.Remove(logicalcluster.From(ws).Join(ws.Name))
 			}
 		},
 		UpdateFunc: func(old, obj interface{}) {
@@ -97,6 +136,7 @@ func NewController(
 				c.lock.Lock()
 				defer c.lock.Unlock()
 				c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)] = expected
+				c.negativeCache.Remove(logicalcluster.From(ws).Join(ws.Name))
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
@@ -114,31 +154,18 @@ func NewController(
 	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
-			c.lock.RLock()
-			got := c.shardBaseURLs[shard.Name]
-			c.lock.RUnlock()
-
-			if expected := shard.Spec.BaseURL; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.shardBaseURLs[shard.Name] = expected
-			}
-
+			c.setShardURLs(shard)
 			c.enqueueShard(shard)
 		},
 		UpdateFunc: func(old, obj interface{}) {
+			oldShard := old.(*tenancyv1alpha1.ClusterWorkspaceShard)
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
-			c.lock.RLock()
-			got := c.shardBaseURLs[shard.Name]
-			c.lock.RUnlock()
+			c.setShardURLs(shard)
 
-			if expected := shard.Spec.BaseURL; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.shardBaseURLs[shard.Name] = expected
+			// the ClusterWorkspace informer of the shard has to be restarted against the new URL.
+			if oldShard.Spec.BaseURL != shard.Spec.BaseURL {
+				c.enqueueShard(shard)
 			}
-
-			// don't updates. Not of interest.
 		},
 		DeleteFunc: func(obj interface{}) {
 			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
@@ -147,8 +174,9 @@ func NewController(
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
 
 			c.lock.Lock()
-			defer c.lock.Unlock()
 			delete(c.shardBaseURLs, shard.Name)
+			delete(c.shardVirtualWorkspaceURLs, shard.Name)
+			c.lock.Unlock()
 
 			c.enqueueShard(shard)
 		},
@@ -168,16 +196,25 @@ type Controller struct {
 
 	clusterWorkspaceShardIndexer cache.Indexer
 	clusterWorkspaceShardLister  tenancylister.ClusterWorkspaceShardLister
+	clusterWorkspaceShardSynced  cache.InformerSynced
 
 	clusterWorkspaceHandler cache.ResourceEventHandler
 
 	shardInformersLock             sync.RWMutex
 	shardClusterWorkspaceInformers map[string]cache.SharedIndexInformer
 	shardClusterWorkspaceStopCh    map[string]chan struct{}
-
-	lock                sync.RWMutex
-	workspaceShardNames map[logicalcluster.Name]string
-	shardBaseURLs       map[string]string
+	shardClusterWorkspaceBaseURLs  map[string]string
+	shardClients                   map[string]kcpclientset.ClusterInterface
+
+	lock                      sync.RWMutex
+	workspaceShardNames       map[logicalcluster.Name]string
+	negativeCache             *utilcache.LRUExpireCache
+	shardBaseURLs             map[string]string
+	shardVirtualWorkspaceURLs map[string]string
+	rootShardName             string
+
+	// liveLookups deduplicates concurrent live lookups of the same logical cluster.
+	liveLookups singleflight.Group
 }
 
 // Start the controller. It does not really do anything, but to keep the shape of a normal
@@ -202,6 +239,28 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	<-ctx.Done()
 }
 
+// setShardURLs updates the URLs of the shard in the index, the virtual workspace URL
+// falling back to the base URL for shards that have not been defaulted yet.
+func (c *Controller) setShardURLs(shard *tenancyv1alpha1.ClusterWorkspaceShard) {
+	virtualWorkspaceURL := shard.Spec.VirtualWorkspaceURL
+	if virtualWorkspaceURL == "" {
+		virtualWorkspaceURL = shard.Spec.BaseURL
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.shardBaseURLs[shard.Name] = shard.Spec.BaseURL
+	c.shardVirtualWorkspaceURLs[shard.Name] = virtualWorkspaceURL
+
+	// the root logical cluster is served by the shard at the root host.
+	if strings.TrimSuffix(shard.Spec.BaseURL, "/") == strings.TrimSuffix(c.rootHost, "/") {
+		c.rootShardName = shard.Name
+	} else if c.rootShardName == shard.Name {
+		c.rootShardName = ""
+	}
+}
+
 func (c *Controller) enqueueShard(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -243,12 +302,11 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 func (c *Controller) process(ctx context.Context, key string) error {
 	shard, err := c.clusterWorkspaceShardLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
 	if err != nil {
-		if errors.IsNotFound(err) {
+		if apierrors.IsNotFound(err) {
 			c.shardInformersLock.Lock()
 			defer c.shardInformersLock.Unlock()
 
-			delete(c.shardClusterWorkspaceInformers, shard.Name)
-			delete(c.shardClusterWorkspaceStopCh, shard.Name)
+			c.stopShardInformer(key)
 
 			return nil
 		}
@@ -258,6 +316,11 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	c.shardInformersLock.Lock()
 	defer c.shardInformersLock.Unlock()
 
+	if baseURL, found := c.shardClusterWorkspaceBaseURLs[shard.Name]; found && baseURL != shard.Spec.BaseURL {
+		klog.Infof("Restarting ClusterWorkspace informer of ClusterWorkspaceShard %q with new base URL %q", shard.Name, shard.Spec.BaseURL)
+		c.stopShardInformer(shard.Name)
+	}
+
 	if _, found := c.shardClusterWorkspaceInformers[shard.Name]; !found {
 		client, err := c.clientGetter(shard)
 		if err != nil {
@@ -269,6 +332,8 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		stopCh := make(chan struct{})
 		c.shardClusterWorkspaceInformers[shard.Name] = informer
 		c.shardClusterWorkspaceStopCh[shard.Name] = stopCh
+		c.shardClusterWorkspaceBaseURLs[shard.Name] = shard.Spec.BaseURL
+		c.shardClients[shard.Name] = client
 
 		go informer.Run(stopCh)
 
@@ -278,6 +343,19 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	return nil
 }
 
+// stopShardInformer stops the ClusterWorkspace informer of the given shard, if it is running.
+// The caller must hold the shardInformersLock.
+func (c *Controller) stopShardInformer(shardName string) {
+	if stopCh, found := c.shardClusterWorkspaceStopCh[shardName]; found {
+		close(stopCh)
+	}
+
+	delete(c.shardClusterWorkspaceInformers, shardName)
+	delete(c.shardClusterWorkspaceStopCh, shardName)
+	delete(c.shardClusterWorkspaceBaseURLs, shardName)
+	delete(c.shardClients, shardName)
+}
+
 func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
 	if logicalCluster == tenancyv1alpha1.RootCluster {
 		return c.rootHost, true
@@ -293,3 +371,160 @@ func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
 	url, found := c.shardBaseURLs[shardName]
 	return url, found
 }
+
+func (c *Controller) LookupOnMiss(ctx context.Context, logicalCluster logicalcluster.Name) (string, error) {
+	if url, found := c.Lookup(logicalCluster); found {
+		return url, nil
+	}
+
+	if err, found := c.negativeCache.Get(logicalCluster); found {
+		return "", err.(error)
+	}
+
+	results := c.liveLookups.DoChan(logicalCluster.String(), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), liveLookupTimeout)
+		defer cancel()
+
+		url, err := c.lookupLive(ctx, logicalCluster)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNotReady) {
+			c.negativeCache.Add(logicalCluster, err, negativeCacheTTL)
+		}
+		return url, err
+	})
+	select {
+	case res := <-results:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// lookupLive gets the ClusterWorkspace of the logical cluster in its parent from the shard of the
+// parent, looking up the parent first if it is not in the index either. Logical clusters scheduled
+// to a known shard are added to the index.
+func (c *Controller) lookupLive(ctx context.Context, logicalCluster logicalcluster.Name) (string, error) {
+	parent, hasParent := logicalCluster.Parent()
+	if !hasParent {
+		return "", ErrNotFound
+	}
+
+	var parentShardName string
+	if parent == tenancyv1alpha1.RootCluster {
+		c.lock.RLock()
+		parentShardName = c.rootShardName
+		c.lock.RUnlock()
+	} else {
+		if _, err := c.LookupOnMiss(ctx, parent); err != nil {
+			return "", err
+		}
+		c.lock.RLock()
+		parentShardName = c.workspaceShardNames[parent]
+		c.lock.RUnlock()
+	}
+
+	c.shardInformersLock.RLock()
+	client, found := c.shardClients[parentShardName]
+	c.shardInformersLock.RUnlock()
+	if !found {
+		return "", fmt.Errorf("no client for shard %q of logical cluster %q", parentShardName, parent)
+	}
+
+	ws, err := client.Cluster(parent).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, logicalCluster.Base(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	shardName := ws.Status.Location.Current
+	if shardName == "" {
+		return "", ErrNotReady
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	url, found := c.shardBaseURLs[shardName]
+	if !found {
+		return "", ErrNotReady
+	}
+	c.workspaceShardNames[logicalCluster] = shardName
+	return url, nil
+}
+
+func (c *Controller) LookupVirtualWorkspaceURL(logicalCluster logicalcluster.Name) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if logicalCluster == tenancyv1alpha1.RootCluster {
+		if url, found := c.shardVirtualWorkspaceURLs[c.rootShardName]; found {
+			return url, true
+		}
+		return c.rootHost, true
+	}
+
+	shardName, found := c.workspaceShardNames[logicalCluster]
+	if !found {
+		return "", false
+	}
+	url, found := c.shardVirtualWorkspaceURLs[shardName]
+	return url, found
+}
+
+// Synced returns an error if the ClusterWorkspaceShard informer, or the ClusterWorkspace
+// informer of any of the shards, has not synced yet.
+func (c *Controller) Synced() error {
+	if !c.clusterWorkspaceShardSynced() {
+		return fmt.Errorf("ClusterWorkspaceShard informer not synced")
+	}
+
+	shards, err := c.clusterWorkspaceShardLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	c.shardInformersLock.RLock()
+	defer c.shardInformersLock.RUnlock()
+
+	var notSynced []string
+	for _, shard := range shards {
+		if informer, found := c.shardClusterWorkspaceInformers[shard.Name]; !found || !informer.HasSynced() {
+			notSynced = append(notSynced, shard.Name)
+		}
+	}
+	if len(notSynced) > 0 {
+		sort.Strings(notSynced)
+		return fmt.Errorf("ClusterWorkspace informers of shards %s not synced", strings.Join(notSynced, ", "))
+	}
+
+	return nil
+}
+
+// ShardURLs returns the base URLs of all shards.
+func (c *Controller) ShardURLs() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return sortedURLs(c.shardBaseURLs)
+}
+
+// VirtualWorkspaceURLs returns the virtual workspace URLs of all shards.
+func (c *Controller) VirtualWorkspaceURLs() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return sortedURLs(c.shardVirtualWorkspaceURLs)
+}
+
+func sortedURLs(urls map[string]string) []string {
+	set := sets.NewString()
+	for _, url := range urls {
+		if url != "" {
+			set.Insert(url)
+		}
+	}
+	return set.List()
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func shard(name, baseURL, virtualWorkspaceURL string) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+			BaseURL:             baseURL,
+			VirtualWorkspaceURL: virtualWorkspaceURL,
+		},
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name                    string
+		shards                  []*tenancyv1alpha1.ClusterWorkspaceShard
+		cluster                 logicalcluster.Name
+		wantURL                 string
+		wantVirtualWorkspaceURL string
+		wantFound               bool
+	}{
+		{
+			name: "workspace on a shard",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("root", "https://root.kcp.dev:6443", "https://root.kcp.dev:6444"),
+				shard("shard-1", "https://shard-1.kcp.dev:6443", "https://shard-1.kcp.dev:6444"),
+			},
+			cluster:                 logicalcluster.New("root:org:ws"),
+			wantURL:                 "https://shard-1.kcp.dev:6443",
+			wantVirtualWorkspaceURL: "https://shard-1.kcp.dev:6444",
+			wantFound:               true,
+		},
+		{
+			name: "virtual workspace URL defaults to the base URL",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("shard-1", "https://shard-1.kcp.dev:6443", ""),
+			},
+			cluster:                 logicalcluster.New("root:org:ws"),
+			wantURL:                 "https://shard-1.kcp.dev:6443",
+			wantVirtualWorkspaceURL: "https://shard-1.kcp.dev:6443",
+			wantFound:               true,
+		},
+		{
+			name: "shard URLs changed",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("shard-1", "https://shard-1.kcp.dev:6443", "https://shard-1.kcp.dev:6444"),
+				shard("shard-1", "https://new-shard-1.kcp.dev:6443", "https://new-shard-1.kcp.dev:6444"),
+			},
+			cluster:                 logicalcluster.New("root:org:ws"),
+			wantURL:                 "https://new-shard-1.kcp.dev:6443",
+			wantVirtualWorkspaceURL: "https://new-shard-1.kcp.dev:6444",
+			wantFound:               true,
+		},
+		{
+			name:    "workspace on an unknown shard",
+			cluster: logicalcluster.New("root:org:ws"),
+		},
+		{
+			name: "unknown workspace",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("shard-1", "https://shard-1.kcp.dev:6443", "https://shard-1.kcp.dev:6444"),
+			},
+			cluster: logicalcluster.New("root:org:unknown"),
+		},
+		{
+			name: "root on the root shard",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("root", "https://root.kcp.dev:6443/", "https://root.kcp.dev:6444"),
+				shard("shard-1", "https://shard-1.kcp.dev:6443", "https://shard-1.kcp.dev:6444"),
+			},
+			cluster:                 tenancyv1alpha1.RootCluster,
+			wantURL:                 "https://root.kcp.dev:6443",
+			wantVirtualWorkspaceURL: "https://root.kcp.dev:6444",
+			wantFound:               true,
+		},
+		{
+			name:                    "root without root shard",
+			cluster:                 tenancyv1alpha1.RootCluster,
+			wantURL:                 "https://root.kcp.dev:6443",
+			wantVirtualWorkspaceURL: "https://root.kcp.dev:6443",
+			wantFound:               true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{
+				rootHost: "https://root.kcp.dev:6443",
+				workspaceShardNames: map[logicalcluster.Name]string{
+					logicalcluster.New("root:org:ws"): "shard-1",
+				},
+				shardBaseURLs:             map[string]string{},
+				shardVirtualWorkspaceURLs: map[string]string{},
+			}
+			for _, shard := range tt.shards {
+				c.setShardURLs(shard)
+			}
+
+			url, found := c.Lookup(tt.cluster)
+			require.Equal(t, tt.wantFound, found)
+			require.Equal(t, tt.wantURL, url)
+
+			url, found = c.LookupVirtualWorkspaceURL(tt.cluster)
+			require.Equal(t, tt.wantFound, found)
+			require.Equal(t, tt.wantVirtualWorkspaceURL, url)
+		})
+	}
+}
+
+// fakeClusterClient serves the ClusterWorkspaces of each logical cluster from its own fake clientset.
+type fakeClusterClient map[logicalcluster.Name]*kcpfakeclient.Clientset
+
+func (c fakeClusterClient) Cluster(name logicalcluster.Name) kcpclientset.Interface {
+	if client, found := c[name]; found {
+		return client
+	}
+	return kcpfakeclient.NewSimpleClientset()
+}
+
+func clusterWorkspace(name, shard string) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard},
+		},
+	}
+}
+
+func TestLookupOnMiss(t *testing.T) {
+	tests := []struct {
+		name      string
+		cluster   logicalcluster.Name
+		wantURL   string
+		wantErr   error
+		wantCache bool
+	}{
+		{
+			name:    "scheduled workspace",
+			cluster: logicalcluster.New("root:org:ws"),
+			wantURL: "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name:    "scheduled workspace with a parent not in the index",
+			cluster: logicalcluster.New("root:org:team:ws"),
+			wantURL: "https://shard-1.kcp.dev:6443",
+		},
+		{
+			name:      "unscheduled workspace",
+			cluster:   logicalcluster.New("root:org:unscheduled"),
+			wantErr:   ErrNotReady,
+			wantCache: true,
+		},
+		{
+			name:      "workspace on an unknown shard",
+			cluster:   logicalcluster.New("root:org:elsewhere"),
+			wantErr:   ErrNotReady,
+			wantCache: true,
+		},
+		{
+			name:      "unknown workspace",
+			cluster:   logicalcluster.New("root:org:unknown"),
+			wantErr:   ErrNotFound,
+			wantCache: true,
+		},
+		{
+			name:      "workspace with an unknown parent",
+			cluster:   logicalcluster.New("root:unknown:ws"),
+			wantErr:   ErrNotFound,
+			wantCache: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgClient := kcpfakeclient.NewSimpleClientset(
+				clusterWorkspace("ws", "shard-1"),
+				clusterWorkspace("team", "shard-1"),
+				clusterWorkspace("unscheduled", ""),
+				clusterWorkspace("elsewhere", "shard-2"),
+			)
+			clients := fakeClusterClient{
+				tenancyv1alpha1.RootCluster:         kcpfakeclient.NewSimpleClientset(clusterWorkspace("org", "root")),
+				logicalcluster.New("root:org"):      orgClient,
+				logicalcluster.New("root:org:team"): kcpfakeclient.NewSimpleClientset(clusterWorkspace("ws", "shard-1")),
+			}
+			c := &Controller{
+				rootHost:                  "https://root.kcp.dev:6443",
+				rootShardName:             "root",
+				workspaceShardNames:       map[logicalcluster.Name]string{},
+				shardBaseURLs:             map[string]string{},
+				shardVirtualWorkspaceURLs: map[string]string{},
+				shardClients: map[string]kcpclientset.ClusterInterface{
+					"root":    clients,
+					"shard-1": clients,
+				},
+				negativeCache: utilcache.NewLRUExpireCache(negativeCacheSize),
+			}
+			c.setShardURLs(shard("root", "https://root.kcp.dev:6443", ""))
+			c.setShardURLs(shard("shard-1", "https://shard-1.kcp.dev:6443", ""))
+
+			url, err := c.LookupOnMiss(context.Background(), tt.cluster)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantURL, url)
+
+			if tt.wantErr == nil {
+				// found workspaces are added to the index.
+				url, found := c.Lookup(tt.cluster)
+				require.True(t, found)
+				require.Equal(t, tt.wantURL, url)
+			}
+
+			_, cached := c.negativeCache.Get(tt.cluster)
+			require.Equal(t, tt.wantCache, cached)
+			if cached {
+				// a second lookup is answered from the negative cache.
+				actions := len(orgClient.Actions())
+				_, err := c.LookupOnMiss(context.Background(), tt.cluster)
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Len(t, orgClient.Actions(), actions)
+			}
+		})
+	}
+}
@@ -22,28 +22,35 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 
-	"github.com/kcp-dev/kcp/pkg/proxy/index"
 	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
 )
 
 // PathMapping describes how to route traffic from a path to a backend server.
 // Each Path is registered with the DefaultServeMux with a handler that
 // delegates to the specified backend.
+//
+// The backends of the /clusters/ and /services/ paths are derived from the
+// ClusterWorkspaceShards, i.e. requests are routed to the base URL respectively
+// the virtual workspace URL of the shard the workspace lives on. The Backend of
+// these paths is ignored.
 type PathMapping struct {
-	Path            string `json:"path"`
-	Backend         string `json:"backend"`
-	BackendServerCA string `json:"backend_server_ca"`
-	ProxyClientCert string `json:"proxy_client_cert"`
-	ProxyClientKey  string `json:"proxy_client_key"`
-	UserHeader      string `json:"user_header,omitempty"`
-	GroupHeader     string `json:"group_header,omitempty"`
+	Path              string `json:"path"`
+	Backend           string `json:"backend,omitempty"`
+	BackendServerCA   string `json:"backend_server_ca"`
+	ProxyClientCert   string `json:"proxy_client_cert"`
+	ProxyClientKey    string `json:"proxy_client_key"`
+	UserHeader        string `json:"user_header,omitempty"`
+	GroupHeader       string `json:"group_header,omitempty"`
+	ExtraHeaderPrefix string `json:"extra_header_prefix,omitempty"`
 }
 
-func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error) {
+func NewHandler(o *proxyoptions.Options, index ShardIndex) (http.Handler, error) {
 	mappingData, err := ioutil.ReadFile(o.MappingFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping file %q: %w", o.MappingFile, err)
@@ -56,56 +63,70 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 
 	mux := http.NewServeMux()
 
-	// TODO: implement proper readyz handler
-	mux.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("OK")) // nolint: errcheck
-		w.WriteHeader(http.StatusOK)
-	}))
-
-	// TODO: implement proper livez handler
-	mux.Handle("/livez", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("OK")) // nolint: errcheck
-		w.WriteHeader(http.StatusOK)
-	}))
+	readyChecks := []healthz.HealthChecker{healthz.PingHealthz, indexSyncedCheck(index)}
 
 	for _, m := range mapping {
 		klog.V(2).Infof("Adding mapping %v", m)
 
-		u, err := url.Parse(m.Backend)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
-		}
-
 		transport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create path mapping for path %q: %w", m.Path, err)
 		}
 
 		var handler http.HandlerFunc
-		if m.Path == "/clusters/" {
+		switch m.Path {
+		case "/clusters/":
+			clusterTransport := newShardTransport(transport, o)
 			clusterProxy := newShardReverseProxy()
-			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
-		} else {
-			// TODO: handle virtual workspace apiservers per shard
+			clusterProxy.Transport = clusterTransport
+			handler = shardHandler(index, clusterProxy, clusterTransport)
+			readyChecks = append(readyChecks, backendsReachableCheck("shards-reachable", index.ShardURLs, transport))
+		case "/services/":
+			servicesProxy := newShardReverseProxy()
+			servicesProxy.Transport = newShardTransport(transport, o)
+			handler = servicesHandler(index, servicesProxy)
+			readyChecks = append(readyChecks, backendsReachableCheck("virtual-workspaces-reachable", index.VirtualWorkspaceURLs, transport))
+		default:
+			u, err := url.Parse(m.Backend)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
+			}
 			proxy := httputil.NewSingleHostReverseProxy(u)
 			proxy.Transport = transport
 			handler = proxy.ServeHTTP
+			backendURLs := []string{m.Backend}
+			readyChecks = append(readyChecks, backendsReachableCheck(backendCheckName(m.Path), func() []string { return backendURLs }, transport))
 		}
 
 		userHeader := "X-Remote-User"
 		groupHeader := "X-Remote-Group"
+		extraHeaderPrefix := "X-Remote-Extra-"
 		if m.UserHeader != "" {
 			userHeader = m.UserHeader
 		}
 		if m.GroupHeader != "" {
 			groupHeader = m.GroupHeader
 		}
+		if m.ExtraHeaderPrefix != "" {
+			extraHeaderPrefix = m.ExtraHeaderPrefix
+		}
 
-		handler = WithProxyAuthHeaders(handler, userHeader, groupHeader)
+		handler = WithProxyAuthHeaders(handler, userHeader, groupHeader, extraHeaderPrefix)
 
 		mux.Handle(m.Path, handler)
 	}
 
+	healthz.InstallReadyzHandler(mux, readyChecks...)
+	healthz.InstallLivezHandler(mux, healthz.PingHealthz)
+
 	return mux, nil
 }
+
+// backendCheckName returns the name of the reachability check of the static backend of the given path.
+func backendCheckName(path string) string {
+	name := strings.Trim(strings.ReplaceAll(path, "/", "-"), "-")
+	if name == "" {
+		name = "default"
+	}
+	return "backend-" + name + "-reachable"
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the front-proxy.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "kcp"
+	subsystem = "front_proxy"
+
+	// errorCode is the value of the code label of requests that failed without a response.
+	errorCode = "error"
+)
+
+var (
+	shardRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "shard_requests_total",
+			Help:           "Number of requests sent to the shards, by shard and response code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "code"},
+	)
+
+	shardRequestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "shard_request_duration_seconds",
+			Help:           "Duration until the response headers of the shards are received, by shard.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard"},
+	)
+
+	shardRetries = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "shard_retries_total",
+			Help:           "Number of retried requests to the shards, by shard.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard"},
+	)
+
+	shardRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "shard_rejected_requests_total",
+			Help:           "Number of requests rejected because the shard is unhealthy, by shard.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard"},
+	)
+
+	shardUnhealthy = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "shard_unhealthy",
+			Help:           "Whether the shard is considered unhealthy (1) or not (0), by shard.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard"},
+	)
+)
+
+var registerOnce sync.Once
+
+// Register registers the front-proxy metrics in the legacy registry.
+func Register() {
+	registerOnce.Do(func() {
+		legacyregistry.MustRegister(shardRequests)
+		legacyregistry.MustRegister(shardRequestDuration)
+		legacyregistry.MustRegister(shardRetries)
+		legacyregistry.MustRegister(shardRejections)
+		legacyregistry.MustRegister(shardUnhealthy)
+	})
+}
+
+// RecordShardRequest records the duration of a request to a shard that started at start,
+// and counts it by the response code, or as error if it failed without a response.
+func RecordShardRequest(shard string, resp *http.Response, err error, start time.Time) {
+	shardRequestDuration.WithLabelValues(shard).Observe(time.Since(start).Seconds())
+
+	code := errorCode
+	if err == nil && resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	shardRequests.WithLabelValues(shard, code).Inc()
+}
+
+// RecordShardRetry counts a retry of a request to a shard.
+func RecordShardRetry(shard string) {
+	shardRetries.WithLabelValues(shard).Inc()
+}
+
+// RecordShardRejection counts a request rejected because the shard is unhealthy.
+func RecordShardRejection(shard string) {
+	shardRejections.WithLabelValues(shard).Inc()
+}
+
+// SetShardUnhealthy records whether the shard is considered unhealthy.
+func SetShardUnhealthy(shard string, unhealthy bool) {
+	value := 0.0
+	if unhealthy {
+		value = 1.0
+	}
+	shardUnhealthy.WithLabelValues(shard).Set(value)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestRecordShardRequest(t *testing.T) {
+	Register()
+	shardRequests.Reset()
+
+	RecordShardRequest("shard-1:6443", &http.Response{StatusCode: http.StatusOK}, nil, time.Now())
+	RecordShardRequest("shard-1:6443", &http.Response{StatusCode: http.StatusOK}, nil, time.Now())
+	RecordShardRequest("shard-1:6443", &http.Response{StatusCode: http.StatusNotFound}, nil, time.Now())
+	RecordShardRequest("shard-2:6443", nil, errors.New("connection refused"), time.Now())
+
+	expected := `
+# HELP kcp_front_proxy_shard_requests_total [ALPHA] Number of requests sent to the shards, by shard and response code.
+# TYPE kcp_front_proxy_shard_requests_total counter
+kcp_front_proxy_shard_requests_total{code="200",shard="shard-1:6443"} 2
+kcp_front_proxy_shard_requests_total{code="404",shard="shard-1:6443"} 1
+kcp_front_proxy_shard_requests_total{code="error",shard="shard-2:6443"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "kcp_front_proxy_shard_requests_total"))
+}
+
+func TestSetShardUnhealthy(t *testing.T) {
+	Register()
+	shardUnhealthy.Reset()
+
+	SetShardUnhealthy("shard-1:6443", true)
+	SetShardUnhealthy("shard-2:6443", true)
+	SetShardUnhealthy("shard-2:6443", false)
+
+	expected := `
+# HELP kcp_front_proxy_shard_unhealthy [ALPHA] Whether the shard is considered unhealthy (1) or not (0), by shard.
+# TYPE kcp_front_proxy_shard_unhealthy gauge
+kcp_front_proxy_shard_unhealthy{shard="shard-1:6443"} 1
+kcp_front_proxy_shard_unhealthy{shard="shard-2:6443"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "kcp_front_proxy_shard_unhealthy"))
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"github.com/spf13/pflag"
+
+	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
+)
+
+// Authentication are the options of the token authentication of the front-proxy. Users
+// authenticated by a token are passed on to the shards in the user, group and extra headers,
+// like users authenticated by a client certificate. Tokens that are not authenticated are
+// passed on to the shards unchanged.
+type Authentication struct {
+	// BuiltIn are the OIDC and webhook token authentication options.
+	BuiltIn *kubeoptions.BuiltInAuthenticationOptions
+
+	// ServiceAccountTokens enables the authentication of kcp service account tokens by a
+	// TokenReview against the shard of the workspace the service account lives in.
+	ServiceAccountTokens bool
+}
+
+func NewAuthentication() *Authentication {
+	return &Authentication{
+		BuiltIn: kubeoptions.NewBuiltInAuthenticationOptions().
+			WithOIDC().
+			WithWebHook(),
+	}
+}
+
+func (o *Authentication) AddFlags(fs *pflag.FlagSet) {
+	o.BuiltIn.AddFlags(fs)
+
+	fs.BoolVar(&o.ServiceAccountTokens, "authentication-service-account-tokens", o.ServiceAccountTokens, "Authenticate kcp service account tokens "+
+		"by a TokenReview against the shard of the workspace the service account lives in, and pass the user on in the user, group and extra headers")
+}
+
+func (o *Authentication) Validate() []error {
+	return o.BuiltIn.Validate()
+}
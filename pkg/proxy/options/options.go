@@ -18,21 +18,66 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 type Options struct {
 	MappingFile string
+
+	Authentication Authentication
+
+	// MaxIdleConnsPerShard is the maximum number of idle connections kept open to every shard.
+	MaxIdleConnsPerShard int
+	// MaxConnsPerShard is the maximum number of connections to every shard, or 0 for no limit.
+	MaxConnsPerShard int
+	// IdleConnTimeout is the time after which idle connections to the shards are closed.
+	IdleConnTimeout time.Duration
+
+	// ShardRequestRetries is the number of times safe requests are retried when a shard cannot be reached.
+	ShardRequestRetries int
+	// ShardRetryBackoff is the initial backoff between retries, doubled with every retry.
+	ShardRetryBackoff time.Duration
+	// ShardFailureThreshold is the number of consecutive failures after which a shard is considered unhealthy.
+	ShardFailureThreshold int
+	// ShardUnhealthyDuration is the time for which requests to an unhealthy shard are rejected.
+	ShardUnhealthyDuration time.Duration
+
+	// MetricsBindAddress is the address the metrics are served on, or empty to not serve metrics.
+	MetricsBindAddress string
 }
 
 func NewOptions() *Options {
-	o := &Options{}
+	o := &Options{
+		Authentication: *NewAuthentication(),
+
+		MaxIdleConnsPerShard: 100,
+		IdleConnTimeout:      90 * time.Second,
+
+		ShardRequestRetries:    2,
+		ShardRetryBackoff:      100 * time.Millisecond,
+		ShardFailureThreshold:  5,
+		ShardUnhealthyDuration: 10 * time.Second,
+	}
 	return o
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
+
+	o.Authentication.AddFlags(fs)
+
+	fs.IntVar(&o.MaxIdleConnsPerShard, "max-idle-conns-per-shard", o.MaxIdleConnsPerShard, "Maximum number of idle connections kept open to every shard")
+	fs.IntVar(&o.MaxConnsPerShard, "max-conns-per-shard", o.MaxConnsPerShard, "Maximum number of connections to every shard, 0 for no limit")
+	fs.DurationVar(&o.IdleConnTimeout, "idle-conn-timeout", o.IdleConnTimeout, "Time after which idle connections to the shards are closed")
+
+	fs.IntVar(&o.ShardRequestRetries, "shard-request-retries", o.ShardRequestRetries, "Number of times safe requests (GET, HEAD, OPTIONS) are retried when a shard cannot be reached")
+	fs.DurationVar(&o.ShardRetryBackoff, "shard-retry-backoff", o.ShardRetryBackoff, "Initial backoff between retries of requests to a shard, doubled with every retry")
+	fs.IntVar(&o.ShardFailureThreshold, "shard-failure-threshold", o.ShardFailureThreshold, "Number of consecutive failures after which a shard is considered unhealthy and requests to it are rejected")
+	fs.DurationVar(&o.ShardUnhealthyDuration, "shard-unhealthy-duration", o.ShardUnhealthyDuration, "Time for which requests to an unhealthy shard are rejected before it is tried again")
+
+	fs.StringVar(&o.MetricsBindAddress, "metrics-bind-address", o.MetricsBindAddress, "Address to serve the Prometheus metrics on, e.g. :8080. Metrics are not served if empty")
 }
 
 func (o *Options) Complete() error {
@@ -45,6 +90,28 @@ func (o *Options) Validate() []error {
 	if o.MappingFile == "" {
 		errs = append(errs, fmt.Errorf("--mapping-file is required"))
 	}
+	errs = append(errs, o.Authentication.Validate()...)
+	if o.MaxIdleConnsPerShard < 0 {
+		errs = append(errs, fmt.Errorf("--max-idle-conns-per-shard must not be negative"))
+	}
+	if o.MaxConnsPerShard < 0 {
+		errs = append(errs, fmt.Errorf("--max-conns-per-shard must not be negative"))
+	}
+	if o.IdleConnTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--idle-conn-timeout must not be negative"))
+	}
+	if o.ShardRequestRetries < 0 {
+		errs = append(errs, fmt.Errorf("--shard-request-retries must not be negative"))
+	}
+	if o.ShardRetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("--shard-retry-backoff must not be negative"))
+	}
+	if o.ShardFailureThreshold < 1 {
+		errs = append(errs, fmt.Errorf("--shard-failure-threshold must be at least 1"))
+	}
+	if o.ShardUnhealthyDuration <= 0 {
+		errs = append(errs, fmt.Errorf("--shard-unhealthy-duration must be positive"))
+	}
 
 	return errs
 }
@@ -20,15 +20,23 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
+	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	userinfo "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
 )
 
 func newTransport(clientCert, clientKeyFile, caFile string) (*http.Transport, error) {
@@ -54,24 +62,43 @@ func newTransport(clientCert, clientKeyFile, caFile string) (*http.Transport, er
 	return transport, nil
 }
 
-// WithProxyAuthHeaders does client cert termination by extracting the user and groups and
-// passing them through access headers to the shard.
-func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader string) http.HandlerFunc {
+// WithProxyAuthHeaders does client cert and token termination by extracting the user, groups and
+// extra of the authenticated user and passing them through access headers to the shard. Access
+// headers sent by the client are always removed, such that they cannot be used to impersonate.
+func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader, ExtraHeaderPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		removeAuthHeaders(r.Header, UserHeader, GroupHeader, ExtraHeaderPrefix)
 		if u, ok := request.UserFrom(r.Context()); ok {
-			appendClientCertAuthHeaders(r.Header, u, UserHeader, GroupHeader)
+			appendClientCertAuthHeaders(r.Header, u, UserHeader, GroupHeader, ExtraHeaderPrefix)
 		}
 
 		delegate.ServeHTTP(w, r)
 	}
 }
 
-func appendClientCertAuthHeaders(header http.Header, user userinfo.Info, UserHeader, GroupHeader string) {
+func removeAuthHeaders(header http.Header, UserHeader, GroupHeader, ExtraHeaderPrefix string) {
+	header.Del(UserHeader)
+	header.Del(GroupHeader)
+	for key := range header {
+		if strings.HasPrefix(strings.ToLower(key), strings.ToLower(ExtraHeaderPrefix)) {
+			delete(header, key)
+		}
+	}
+}
+
+func appendClientCertAuthHeaders(header http.Header, user userinfo.Info, UserHeader, GroupHeader, ExtraHeaderPrefix string) {
 	header.Set(UserHeader, user.GetName())
 
 	for _, group := range user.GetGroups() {
 		header.Add(GroupHeader, group)
 	}
+
+	// extra keys are escaped as the requestheader authenticator of the shards unescapes them.
+	for key, values := range user.GetExtra() {
+		for _, value := range values {
+			header.Add(ExtraHeaderPrefix+url.PathEscape(key), value)
+		}
+	}
 }
 
 func newShardReverseProxy() *httputil.ReverseProxy {
@@ -88,7 +115,26 @@ func newShardReverseProxy() *httputil.ReverseProxy {
 		req.URL.Scheme = shardURL.Scheme
 		req.URL.Host = shardURL.Host
 	}
-	return &httputil.ReverseProxy{Director: director}
+	return &httputil.ReverseProxy{Director: director, ErrorHandler: shardErrorHandler}
+}
+
+// shardErrorHandler answers requests that could not be proxied to the shard. Unavailable
+// shards are answered with 503 and a Retry-After header, other errors with 502.
+func shardErrorHandler(w http.ResponseWriter, req *http.Request, err error) {
+	var unavailable *shardUnavailableError
+	if errors.As(err, &unavailable) {
+		statusErr := apierrors.NewServiceUnavailable("the shard of the workspace is unavailable, please retry later")
+		statusErr.ErrStatus.Details = &metav1.StatusDetails{RetryAfterSeconds: unavailable.retryAfterSeconds()}
+		w.Header().Set("Retry-After", strconv.Itoa(int(unavailable.retryAfterSeconds())))
+		responsewriters.ErrorNegotiated(statusErr, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
+		return
+	}
+
+	if req.Context().Err() == nil {
+		runtime.HandleError(fmt.Errorf("failed to proxy %s %q to %s: %w", req.Method, req.URL.Path, req.URL.Host, err))
+	}
+	statusErr := apierrors.NewGenericServerResponse(http.StatusBadGateway, req.Method, schema.GroupResource{}, "", "error trying to reach the shard of the workspace", 0, false)
+	responsewriters.ErrorNegotiated(statusErr, kubernetesscheme.Codecs, schema.GroupVersion{}, w, req)
 }
 
 type shardKey int
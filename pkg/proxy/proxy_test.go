@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestWithProxyAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       user.Info
+		wantHeader http.Header
+	}{
+		{
+			name: "authenticated user",
+			user: &user.DefaultInfo{
+				Name:   "system:serviceaccount:default:default",
+				Groups: []string{"system:serviceaccounts", "system:authenticated"},
+				Extra: map[string][]string{
+					"authentication.kubernetes.io/cluster-name": {"root:org:ws"},
+				},
+			},
+			wantHeader: http.Header{
+				"Accept":         {"application/json"},
+				"X-Remote-User":  {"system:serviceaccount:default:default"},
+				"X-Remote-Group": {"system:serviceaccounts", "system:authenticated"},
+				"X-Remote-Extra-Authentication.kubernetes.io%2fcluster-Name": {"root:org:ws"},
+			},
+		},
+		{
+			name: "unauthenticated user",
+			wantHeader: http.Header{
+				"Accept": {"application/json"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader http.Header
+			handler := WithProxyAuthHeaders(func(w http.ResponseWriter, req *http.Request) {
+				gotHeader = req.Header
+			}, "X-Remote-User", "X-Remote-Group", "X-Remote-Extra-")
+
+			req := httptest.NewRequest(http.MethodGet, "/clusters/root/api", nil)
+			req.Header.Set("Accept", "application/json")
+			// headers sent by the client must not be passed on.
+			req.Header.Set("X-Remote-User", "admin")
+			req.Header.Add("X-Remote-Group", "system:masters")
+			req.Header.Add("X-Remote-Extra-Scopes", "all")
+			if tt.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tt.user))
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tt.wantHeader, gotHeader)
+		})
+	}
+}
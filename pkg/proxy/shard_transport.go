@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/proxy/metrics"
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+// shardUnavailableError is returned for requests to shards that are considered unhealthy.
+type shardUnavailableError struct {
+	shard      string
+	retryAfter time.Duration
+}
+
+func (e *shardUnavailableError) Error() string {
+	return fmt.Sprintf("shard %s is unavailable", e.shard)
+}
+
+// retryAfterSeconds returns the seconds after which the request should be retried, rounded up.
+func (e *shardUnavailableError) retryAfterSeconds() int32 {
+	return int32(math.Ceil(e.retryAfter.Seconds()))
+}
+
+type shardHealth struct {
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// shardTransport is the transport of the requests proxied to the shards. It keeps a transport,
+// and hence a connection pool, per shard, retries safe requests that fail without a response, and
+// considers shards unhealthy after a number of consecutive failures. Requests to unhealthy shards
+// are rejected with a shardUnavailableError until the shard is tried again after some time.
+//
+// Retries are sent to the same shard URL, which usually balances the requests over the replicas
+// of the shard.
+type shardTransport struct {
+	base    *http.Transport
+	options *proxyoptions.Options
+	now     func() time.Time
+
+	lock       sync.Mutex
+	transports map[string]http.RoundTripper
+	health     map[string]*shardHealth
+}
+
+func newShardTransport(base *http.Transport, options *proxyoptions.Options) *shardTransport {
+	return &shardTransport{
+		base:    base,
+		options: options,
+		now:     time.Now,
+
+		transports: map[string]http.RoundTripper{},
+		health:     map[string]*shardHealth{},
+	}
+}
+
+func (t *shardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	shard := req.URL.Host
+
+	if retryAfter, unhealthy := t.unhealthy(shard); unhealthy {
+		metrics.RecordShardRejection(shard)
+		return nil, &shardUnavailableError{shard: shard, retryAfter: retryAfter}
+	}
+
+	attempts := 1
+	if isRetriable(req) {
+		attempts += t.options.ShardRequestRetries
+	}
+	backoff := wait.Backoff{
+		Duration: t.options.ShardRetryBackoff,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    attempts,
+	}
+
+	transport := t.transportFor(shard)
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			metrics.RecordShardRetry(shard)
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(backoff.Step()):
+			}
+		}
+
+		start := time.Now()
+		var resp *http.Response
+		resp, err = transport.RoundTrip(req)
+		metrics.RecordShardRequest(shard, resp, err, start)
+		if err == nil {
+			t.recordSuccess(shard)
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			// the client went away, which says nothing about the shard.
+			return nil, err
+		}
+
+		klog.V(4).Infof("Request %s %q to shard %s failed: %v", req.Method, req.URL.Path, shard, err)
+		if retryAfter, unhealthy := t.recordFailure(shard); unhealthy {
+			return nil, &shardUnavailableError{shard: shard, retryAfter: retryAfter}
+		}
+	}
+
+	return nil, err
+}
+
+// transportFor returns the transport of the given shard, creating it if it does not exist yet.
+func (t *shardTransport) transportFor(shard string) http.RoundTripper {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if transport, found := t.transports[shard]; found {
+		return transport
+	}
+
+	transport := t.base.Clone()
+	transport.MaxIdleConnsPerHost = t.options.MaxIdleConnsPerShard
+	transport.MaxConnsPerHost = t.options.MaxConnsPerShard
+	transport.IdleConnTimeout = t.options.IdleConnTimeout
+	t.transports[shard] = transport
+
+	return transport
+}
+
+// unhealthy returns whether the shard is unhealthy, and for how long.
+func (t *shardTransport) unhealthy(shard string) (time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	health, found := t.health[shard]
+	if !found {
+		return 0, false
+	}
+	if retryAfter := health.unhealthyUntil.Sub(t.now()); retryAfter > 0 {
+		return retryAfter, true
+	}
+	return 0, false
+}
+
+func (t *shardTransport) recordSuccess(shard string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if health, found := t.health[shard]; found {
+		delete(t.health, shard)
+		if health.failures >= t.options.ShardFailureThreshold {
+			klog.Infof("Shard %s is healthy again", shard)
+			metrics.SetShardUnhealthy(shard, false)
+		}
+	}
+}
+
+// recordFailure counts a failed request to the shard, and marks it unhealthy when it has failed
+// too many times in a row. A shard that is tried again after being unhealthy is marked unhealthy
+// again on the first failure.
+func (t *shardTransport) recordFailure(shard string) (time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	health, found := t.health[shard]
+	if !found {
+		health = &shardHealth{}
+		t.health[shard] = health
+	}
+	health.failures++
+
+	if health.failures < t.options.ShardFailureThreshold {
+		return 0, false
+	}
+	if health.failures == t.options.ShardFailureThreshold {
+		klog.Warningf("Shard %s failed %d times in a row, considering it unhealthy for %s", shard, health.failures, t.options.ShardUnhealthyDuration)
+	}
+	health.unhealthyUntil = t.now().Add(t.options.ShardUnhealthyDuration)
+	metrics.SetShardUnhealthy(shard, true)
+
+	return t.options.ShardUnhealthyDuration, true
+}
+
+// isRetriable returns whether the request is safe to be sent again.
+func isRetriable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	default:
+		return false
+	}
+}
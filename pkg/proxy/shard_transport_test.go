@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+// fakeRoundTripper fails the first failures requests, and answers the others with 200.
+type fakeRoundTripper struct {
+	failures int
+	requests int
+}
+
+func (rt *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests++
+	if rt.requests <= rt.failures {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func newTestShardTransport(shard string, rt http.RoundTripper, now *time.Time) *shardTransport {
+	options := proxyoptions.NewOptions()
+	options.ShardRetryBackoff = time.Millisecond
+	options.ShardFailureThreshold = 3
+
+	t := newShardTransport(http.DefaultTransport.(*http.Transport), options)
+	t.transports[shard] = rt
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestShardTransportRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		failures     int
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "GET succeeding after a retry",
+			method:       http.MethodGet,
+			failures:     1,
+			wantRequests: 2,
+		},
+		{
+			name:         "GET failing more often than retried",
+			method:       http.MethodGet,
+			failures:     3,
+			wantRequests: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "POST is not retried",
+			method:       http.MethodPost,
+			failures:     1,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			rt := &fakeRoundTripper{failures: tt.failures}
+			transport := newTestShardTransport("shard-1:6443", rt, &now)
+
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader("{}")
+			}
+			req := httptest.NewRequest(tt.method, "https://shard-1:6443/clusters/root/api/v1/namespaces", body)
+
+			resp, err := transport.RoundTrip(req)
+			require.Equal(t, tt.wantRequests, rt.requests)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestShardTransportHealth(t *testing.T) {
+	now := time.Now()
+	rt := &fakeRoundTripper{failures: 4}
+	transport := newTestShardTransport("shard-1:6443", rt, &now)
+	transport.options.ShardRequestRetries = 0
+
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "https://shard-1:6443/clusters/root/api/v1/namespaces", nil)
+	}
+
+	// fails twice without being unhealthy.
+	for i := 0; i < 2; i++ {
+		_, err := transport.RoundTrip(newRequest())
+		require.Error(t, err)
+		var unavailable *shardUnavailableError
+		require.False(t, errors.As(err, &unavailable))
+	}
+
+	// the third failure makes the shard unhealthy.
+	_, err := transport.RoundTrip(newRequest())
+	var unavailable *shardUnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	require.Equal(t, int32(10), unavailable.retryAfterSeconds())
+
+	// requests are rejected without being sent.
+	now = now.Add(5 * time.Second)
+	_, err = transport.RoundTrip(newRequest())
+	require.True(t, errors.As(err, &unavailable))
+	require.Equal(t, int32(5), unavailable.retryAfterSeconds())
+	require.Equal(t, 3, rt.requests)
+
+	// the shard is tried again, and a single failure makes it unhealthy again.
+	now = now.Add(5 * time.Second)
+	_, err = transport.RoundTrip(newRequest())
+	require.True(t, errors.As(err, &unavailable))
+	require.Equal(t, 4, rt.requests)
+
+	// a success makes it healthy again.
+	now = now.Add(10 * time.Second)
+	resp, err := transport.RoundTrip(newRequest())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, unhealthy := transport.unhealthy("shard-1:6443")
+	require.False(t, unhealthy)
+}
+
+func TestShardErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	shardErrorHandler(w, httptest.NewRequest(http.MethodGet, "/clusters/root/api", nil), &shardUnavailableError{shard: "shard-1:6443", retryAfter: 1500 * time.Millisecond})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	shardErrorHandler(w, httptest.NewRequest(http.MethodGet, "/clusters/root/api", nil), errors.New("connection refused"))
+	require.Equal(t, http.StatusBadGateway, w.Code)
+}
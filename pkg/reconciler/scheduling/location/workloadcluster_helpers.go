@@ -68,3 +68,19 @@ func FilterNonEvicting(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1
 	}
 	return ret
 }
+
+// FilterEvicting returns the ready sync targets whose EvictAfter time has passed, whether
+// they are schedulable or not.
+func FilterEvicting(syncTargets []*workloadv1alpha1.SyncTarget) []*workloadv1alpha1.SyncTarget {
+	ret := make([]*workloadv1alpha1.SyncTarget, 0, len(syncTargets))
+	now := time.Now()
+	for _, wc := range syncTargets {
+		if !conditions.IsTrue(wc, conditionsapi.ReadyCondition) {
+			continue
+		}
+		if wc.Spec.EvictAfter != nil && !now.Before(wc.Spec.EvictAfter.Time) {
+			ret = append(ret, wc)
+		}
+	}
+	return ret
+}
@@ -146,9 +146,27 @@ func NewController(
 	)
 
 	placementInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    c.enqueuePlacement,
-		UpdateFunc: func(_, obj interface{}) { c.enqueuePlacement(obj) },
-		DeleteFunc: c.enqueuePlacement,
+		AddFunc: func(obj interface{}) {
+			c.enqueuePlacement(obj)
+			c.enqueueOtherPlacements(obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueuePlacement(obj)
+
+			// the location constraints of the other placements depend on the labels and the selected
+			// location of this placement.
+			oldPlacement := old.(*schedulingv1alpha1.Placement)
+			newPlacement := obj.(*schedulingv1alpha1.Placement)
+			if !reflect.DeepEqual(oldPlacement.Labels, newPlacement.Labels) ||
+				!reflect.DeepEqual(oldPlacement.Status.SelectedLocation, newPlacement.Status.SelectedLocation) ||
+				oldPlacement.Status.Phase != newPlacement.Status.Phase {
+				c.enqueueOtherPlacements(obj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueuePlacement(obj)
+			c.enqueueOtherPlacements(obj)
+		},
 	})
 
 	return c, nil
@@ -184,6 +202,32 @@ func (c *controller) enqueuePlacement(obj interface{}) {
 	c.queue.Add(key)
 }
 
+// enqueueOtherPlacements enqueues the other placements with location constraints in the workspace of the placement.
+func (c *controller) enqueueOtherPlacements(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+
+	placements, err := c.placementIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, obj := range placements {
+		placement := obj.(*schedulingv1alpha1.Placement)
+		if placement.Name == name || !hasLocationConstraints(placement) {
+			continue
+		}
+		klog.V(2).Infof("Queueing placement %s|%s because Placement %s|%s changed", clusterName, placement.Name, clusterName, name)
+		key := clusters.ToClusterAwareKey(logicalcluster.From(placement), placement.Name)
+		c.queue.Add(key)
+	}
+}
+
 // enqueueNamespace enqueues all placements for the namespace.
 func (c *controller) enqueueNamespace(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
@@ -41,7 +41,8 @@ type reconciler interface {
 func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) error {
 	reconcilers := []reconciler{
 		&placementReconciler{
-			listLocations: c.listLocations,
+			listLocations:  c.listLocations,
+			listPlacements: c.listPlacements,
 		},
 		&placementNamespaceReconciler{
 			listNamespacesWithAnnotation: c.listNamespacesWithAnnotation,
@@ -77,6 +78,18 @@ func (c *controller) listLocations(clusterName logicalcluster.Name) ([]*scheduli
 	return ret, nil
 }
 
+func (c *controller) listPlacements(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+	items, err := c.placementIndexer.ByIndex(byWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.Placement, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.Placement))
+	}
+	return ret, nil
+}
+
 func (c *controller) listNamespacesWithAnnotation(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
 	items, err := c.namespaceIndexer.ByIndex(byWorkspace, clusterName.String())
 	if err != nil {
@@ -18,7 +18,9 @@ package placement
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster"
 
@@ -34,7 +36,8 @@ import (
 // placementReconciler watches namespaces within a cluster workspace and assigns those to location from
 // the location domain of the cluster workspace.
 type placementReconciler struct {
-	listLocations func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	listLocations  func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	listPlacements func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
 }
 
 func (r *placementReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -52,6 +55,31 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 		return reconcileStatusContinue, placement, err
 	}
 
+	// the valid locations satisfying the location anti-affinity and spread constraints of the placement.
+	satisfyingLocationNames := validLocationNames
+	if hasLocationConstraints(placement) {
+		if err := validateLocationConstraints(placement); err != nil {
+			// invalid constraints are a user error, which is reported until the placement is fixed.
+			conditions.MarkFalse(placement, schedulingv1alpha1.PlacementLocationConstraintsSatisfied, schedulingv1alpha1.LocationConstraintsInvalidReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
+			if placement.Status.Phase == schedulingv1alpha1.PlacementPending || !isValidLocationSelected(placement, locationWorkspace, validLocationNames) {
+				placement.Status.Phase = schedulingv1alpha1.PlacementPending
+				placement.Status.SelectedLocation = nil
+				conditions.MarkFalse(placement, schedulingv1alpha1.PlacementReady, schedulingv1alpha1.LocationConstraintsInvalidReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
+				return reconcileStatusContinue, placement, nil
+			}
+			conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
+			return reconcileStatusContinue, placement, nil
+		}
+
+		satisfyingLocationNames, err = r.filterByLocationConstraints(placement, locationWorkspace, validLocationNames)
+		if err != nil {
+			conditions.MarkFalse(placement, schedulingv1alpha1.PlacementReady, schedulingv1alpha1.LocationNotFoundReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
+			return reconcileStatusContinue, placement, err
+		}
+	} else {
+		conditions.Delete(placement, schedulingv1alpha1.PlacementLocationConstraintsSatisfied)
+	}
+
 	switch placement.Status.Phase {
 	case schedulingv1alpha1.PlacementBound:
 		// if selected location becomes invalid when placement is in bound state, set PlacementReady
@@ -67,11 +95,30 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 			return reconcileStatusContinue, placement, nil
 		}
 
+		// the location of a bound placement is kept even if it does not satisfy the constraints anymore,
+		// e.g. because another placement selected the same location concurrently.
+		if hasLocationConstraints(placement) {
+			if isValidLocationSelected(placement, locationWorkspace, satisfyingLocationNames) {
+				conditions.MarkTrue(placement, schedulingv1alpha1.PlacementLocationConstraintsSatisfied)
+			} else {
+				conditions.MarkFalse(
+					placement,
+					schedulingv1alpha1.PlacementLocationConstraintsSatisfied,
+					schedulingv1alpha1.LocationConstraintsViolatedReason,
+					conditionsv1alpha1.ConditionSeverityWarning,
+					"Selected location does not satisfy the location constraints of the placement anymore",
+				)
+			}
+		}
+
 		conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
 		return reconcileStatusContinue, placement, nil
 	case schedulingv1alpha1.PlacementUnbound:
-		if isValidLocationSelected(placement, locationWorkspace, validLocationNames) {
+		if isValidLocationSelected(placement, locationWorkspace, satisfyingLocationNames) {
 			// if the selected location is valid, keep it.
+			if hasLocationConstraints(placement) {
+				conditions.MarkTrue(placement, schedulingv1alpha1.PlacementLocationConstraintsSatisfied)
+			}
 			conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
 			return reconcileStatusContinue, placement, nil
 		}
@@ -90,19 +137,41 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 		return reconcileStatusContinue, placement, nil
 	}
 
-	candidates := make([]string, 0, validLocationNames.Len())
-	for loc := range validLocationNames {
+	if satisfyingLocationNames.Len() == 0 {
+		placement.Status.Phase = schedulingv1alpha1.PlacementPending
+		placement.Status.SelectedLocation = nil
+		message := fmt.Sprintf("None of the valid locations %s satisfies the location anti-affinity and spread constraints of the placement", strings.Join(validLocationNames.List(), ", "))
+		conditions.MarkFalse(
+			placement,
+			schedulingv1alpha1.PlacementLocationConstraintsSatisfied,
+			schedulingv1alpha1.LocationConstraintsUnsatisfiableReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			message)
+		conditions.MarkFalse(
+			placement,
+			schedulingv1alpha1.PlacementReady,
+			schedulingv1alpha1.LocationConstraintsUnsatisfiableReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			message)
+		return reconcileStatusContinue, placement, nil
+	}
+
+	candidates := make([]string, 0, satisfyingLocationNames.Len())
+	for loc := range satisfyingLocationNames {
 		candidates = append(candidates, loc)
 	}
 
-	// TODO(qiujian16): two placements could select the same location. We should
-	// consider whether placements in a workspace should always select different locations.
+	// placements in a workspace can select the same location, unless prevented by their location
+	// anti-affinity or spread constraints.
 	chosenLocation := candidates[rand.Intn(len(candidates))]
 	placement.Status.SelectedLocation = &schedulingv1alpha1.LocationReference{
 		Path:         locationWorkspace.String(),
 		LocationName: chosenLocation,
 	}
 	placement.Status.Phase = schedulingv1alpha1.PlacementUnbound
+	if hasLocationConstraints(placement) {
+		conditions.MarkTrue(placement, schedulingv1alpha1.PlacementLocationConstraintsSatisfied)
+	}
 	conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
 
 	return reconcileStatusContinue, placement, nil
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kube-openapi/pkg/util/sets"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+func hasLocationConstraints(placement *schedulingv1alpha1.Placement) bool {
+	return placement.Spec.LocationAntiAffinity != nil || len(placement.Spec.LocationSpreadConstraints) > 0
+}
+
+// validateLocationConstraints returns an error if a placement selector of the location anti-affinity or
+// spread constraints of the placement is invalid.
+func validateLocationConstraints(placement *schedulingv1alpha1.Placement) error {
+	if antiAffinity := placement.Spec.LocationAntiAffinity; antiAffinity != nil {
+		if _, err := metav1.LabelSelectorAsSelector(&antiAffinity.PlacementSelector); err != nil {
+			return fmt.Errorf("invalid placement selector of the location anti-affinity: %w", err)
+		}
+	}
+	for i := range placement.Spec.LocationSpreadConstraints {
+		if _, err := metav1.LabelSelectorAsSelector(&placement.Spec.LocationSpreadConstraints[i].PlacementSelector); err != nil {
+			return fmt.Errorf("invalid placement selector of location spread constraint %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// filterByLocationConstraints returns the valid locations satisfying the location anti-affinity and spread
+// constraints of the placement, given the locations selected by the other placements of the workspace in the
+// same location workspace.
+func (r *placementReconciler) filterByLocationConstraints(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, validLocationNames sets.String) (sets.String, error) {
+	locations, err := r.listLocations(locationWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	locationLabels := make(map[string]labels.Set, len(locations))
+	for _, loc := range locations {
+		locationLabels[loc.Name] = loc.Labels
+	}
+
+	placements, err := r.listPlacements(logicalcluster.From(placement))
+	if err != nil {
+		return nil, err
+	}
+	var others []*schedulingv1alpha1.Placement
+	for _, other := range placements {
+		if other.Name == placement.Name {
+			continue
+		}
+		if other.Status.Phase == schedulingv1alpha1.PlacementPending || other.Status.SelectedLocation == nil {
+			continue
+		}
+		if other.Status.SelectedLocation.Path != locationWorkspace.String() {
+			continue
+		}
+		others = append(others, other)
+	}
+
+	ret := sets.NewString()
+	for _, name := range validLocationNames.List() {
+		if antiAffinity := placement.Spec.LocationAntiAffinity; antiAffinity != nil {
+			violated, err := violatesAntiAffinity(antiAffinity, name, locationLabels, others)
+			if err != nil {
+				return nil, err
+			}
+			if violated {
+				continue
+			}
+		}
+
+		satisfied := true
+		for i := range placement.Spec.LocationSpreadConstraints {
+			ok, err := satisfiesSpreadConstraint(&placement.Spec.LocationSpreadConstraints[i], name, validLocationNames, locationLabels, others)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			ret.Insert(name)
+		}
+	}
+
+	return ret, nil
+}
+
+// violatesAntiAffinity returns true if one of the other placements selected by the anti-affinity has selected
+// the location, or a location in the same topology domain. Locations without the topology key label are
+// topology domains of their own.
+func violatesAntiAffinity(antiAffinity *schedulingv1alpha1.LocationAntiAffinity, locationName string, locationLabels map[string]labels.Set, others []*schedulingv1alpha1.Placement) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&antiAffinity.PlacementSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid placement selector of the location anti-affinity: %w", err)
+	}
+
+	for _, other := range others {
+		if !selector.Matches(labels.Set(other.Labels)) {
+			continue
+		}
+
+		selected := other.Status.SelectedLocation.LocationName
+		if selected == locationName {
+			return true, nil
+		}
+		if antiAffinity.TopologyKey == "" {
+			continue
+		}
+
+		domain, found := locationLabels[locationName][antiAffinity.TopologyKey]
+		if !found {
+			continue
+		}
+		if otherDomain, found := locationLabels[selected][antiAffinity.TopologyKey]; found && otherDomain == domain {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// satisfiesSpreadConstraint returns true if selecting the location keeps the difference of the number of
+// selected placements between any two topology domains of the valid locations within the max skew.
+func satisfiesSpreadConstraint(constraint *schedulingv1alpha1.LocationSpreadConstraint, locationName string, validLocationNames sets.String, locationLabels map[string]labels.Set, others []*schedulingv1alpha1.Placement) (bool, error) {
+	domain, found := locationLabels[locationName][constraint.TopologyKey]
+	if !found {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&constraint.PlacementSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid placement selector of the location spread constraint: %w", err)
+	}
+
+	// every topology domain of the valid locations is counted, even without any placement.
+	counts := map[string]int32{}
+	for name := range validLocationNames {
+		if d, found := locationLabels[name][constraint.TopologyKey]; found {
+			counts[d] = 0
+		}
+	}
+	for _, other := range others {
+		if !selector.Matches(labels.Set(other.Labels)) {
+			continue
+		}
+		d, found := locationLabels[other.Status.SelectedLocation.LocationName][constraint.TopologyKey]
+		if !found {
+			continue
+		}
+		if _, eligible := counts[d]; eligible {
+			counts[d]++
+		}
+	}
+
+	min := counts[domain]
+	for _, count := range counts {
+		if count < min {
+			min = count
+		}
+	}
+
+	maxSkew := constraint.MaxSkew
+	if maxSkew < 1 {
+		maxSkew = 1
+	}
+	return counts[domain]+1-min <= maxSkew, nil
+}
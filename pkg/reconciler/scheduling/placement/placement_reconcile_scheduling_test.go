@@ -205,7 +205,11 @@ func TestPlacementScheduling(t *testing.T) {
 				return testCase.locations, testCase.listLocationsError
 			}
 
-			reconciler := &placementReconciler{listLocations: listLoaction}
+			listPlacements := func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+				return []*schedulingv1alpha1.Placement{testPlacement}, nil
+			}
+
+			reconciler := &placementReconciler{listLocations: listLoaction, listPlacements: listPlacements}
 			_, updated, err := reconciler.reconcile(context.TODO(), testPlacement)
 
 			if testCase.wantError {
@@ -224,6 +228,245 @@ func TestPlacementScheduling(t *testing.T) {
 	}
 }
 
+func TestPlacementLocationConstraints(t *testing.T) {
+	appA := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}}
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}}}
+
+	testCases := []struct {
+		name                      string
+		phase                     schedulingv1alpha1.PlacementPhase
+		selectedLocation          *schedulingv1alpha1.LocationReference
+		locationAntiAffinity      *schedulingv1alpha1.LocationAntiAffinity
+		locationSpreadConstraints []schedulingv1alpha1.LocationSpreadConstraint
+		locations                 []*schedulingv1alpha1.Location
+		otherPlacements           []*schedulingv1alpha1.Placement
+
+		wantPhase             schedulingv1alpha1.PlacementPhase
+		wantSelectLocation    *schedulingv1alpha1.LocationReference
+		wantReadyStatus       corev1.ConditionStatus
+		wantConstraintsStatus corev1.ConditionStatus
+		wantConstraintsReason string
+	}{
+		{
+			name:                 "anti-affinity excludes the locations selected by other placements",
+			phase:                schedulingv1alpha1.PlacementPending,
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+				newLocation("l2", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "a"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementUnbound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l2"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                 "anti-affinity ignores the placements it does not select",
+			phase:                schedulingv1alpha1.PlacementPending,
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "b"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementUnbound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                 "anti-affinity with topology key excludes the locations of the same topology domain",
+			phase:                schedulingv1alpha1.PlacementPending,
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA, TopologyKey: "region"},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws", "region": "eu"}),
+				newLocation("l2", map[string]string{"cloud": "aws", "region": "eu"}),
+				newLocation("l3", map[string]string{"cloud": "aws", "region": "us"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "a"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementUnbound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l3"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                 "no location satisfies the anti-affinity",
+			phase:                schedulingv1alpha1.PlacementPending,
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "a"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementPending,
+			wantReadyStatus:       corev1.ConditionFalse,
+			wantConstraintsStatus: corev1.ConditionFalse,
+			wantConstraintsReason: schedulingv1alpha1.LocationConstraintsUnsatisfiableReason,
+		},
+		{
+			name:  "spread constraint selects the topology domain with the fewest placements",
+			phase: schedulingv1alpha1.PlacementPending,
+			locationSpreadConstraints: []schedulingv1alpha1.LocationSpreadConstraint{
+				{PlacementSelector: *appA, TopologyKey: "region", MaxSkew: 1},
+			},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws", "region": "eu"}),
+				newLocation("l2", map[string]string{"cloud": "aws", "region": "us"}),
+				newLocation("l3", map[string]string{"cloud": "aws", "region": "us"}),
+				newLocation("l4", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other-1", map[string]string{"app": "a"}, "l2"),
+				newSelectedPlacement("other-2", map[string]string{"app": "a"}, "l3"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementUnbound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionTrue,
+		},
+		{
+			name:  "spread constraint does not select locations without the topology key label",
+			phase: schedulingv1alpha1.PlacementPending,
+			locationSpreadConstraints: []schedulingv1alpha1.LocationSpreadConstraint{
+				{PlacementSelector: *appA, TopologyKey: "region", MaxSkew: 1},
+			},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementPending,
+			wantReadyStatus:       corev1.ConditionFalse,
+			wantConstraintsStatus: corev1.ConditionFalse,
+			wantConstraintsReason: schedulingv1alpha1.LocationConstraintsUnsatisfiableReason,
+		},
+		{
+			name:                 "reselect the location of an unbound placement violating the anti-affinity",
+			phase:                schedulingv1alpha1.PlacementUnbound,
+			selectedLocation:     &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+				newLocation("l2", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "a"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementUnbound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l2"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                 "keep the location of a bound placement violating the anti-affinity",
+			phase:                schedulingv1alpha1.PlacementBound,
+			selectedLocation:     &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *appA},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+				newLocation("l2", map[string]string{"cloud": "aws"}),
+			},
+			otherPlacements: []*schedulingv1alpha1.Placement{
+				newSelectedPlacement("other", map[string]string{"app": "a"}, "l1"),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementBound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionFalse,
+			wantConstraintsReason: schedulingv1alpha1.LocationConstraintsViolatedReason,
+		},
+		{
+			name:                 "no location is selected with an invalid placement selector",
+			phase:                schedulingv1alpha1.PlacementPending,
+			locationAntiAffinity: &schedulingv1alpha1.LocationAntiAffinity{PlacementSelector: *invalid},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws"}),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementPending,
+			wantReadyStatus:       corev1.ConditionFalse,
+			wantConstraintsStatus: corev1.ConditionFalse,
+			wantConstraintsReason: schedulingv1alpha1.LocationConstraintsInvalidReason,
+		},
+		{
+			name:  "keep the location of a bound placement with an invalid placement selector",
+			phase: schedulingv1alpha1.PlacementBound,
+			locationSpreadConstraints: []schedulingv1alpha1.LocationSpreadConstraint{
+				{PlacementSelector: *invalid, TopologyKey: "region", MaxSkew: 1},
+			},
+			selectedLocation: &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			locations: []*schedulingv1alpha1.Location{
+				newLocation("l1", map[string]string{"cloud": "aws", "region": "eu"}),
+			},
+			wantPhase:             schedulingv1alpha1.PlacementBound,
+			wantSelectLocation:    &schedulingv1alpha1.LocationReference{LocationName: "l1"},
+			wantReadyStatus:       corev1.ConditionTrue,
+			wantConstraintsStatus: corev1.ConditionFalse,
+			wantConstraintsReason: schedulingv1alpha1.LocationConstraintsInvalidReason,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testPlacement := &schedulingv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-placement",
+					Labels: map[string]string{"app": "a"},
+				},
+				Spec: schedulingv1alpha1.PlacementSpec{
+					LocationSelectors: []metav1.LabelSelector{
+						{MatchLabels: map[string]string{"cloud": "aws"}},
+					},
+					LocationAntiAffinity:      testCase.locationAntiAffinity,
+					LocationSpreadConstraints: testCase.locationSpreadConstraints,
+				},
+				Status: schedulingv1alpha1.PlacementStatus{
+					SelectedLocation: testCase.selectedLocation,
+					Phase:            testCase.phase,
+				},
+			}
+
+			reconciler := &placementReconciler{
+				listLocations: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error) {
+					return testCase.locations, nil
+				},
+				listPlacements: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+					return append([]*schedulingv1alpha1.Placement{testPlacement}, testCase.otherPlacements...), nil
+				},
+			}
+			_, updated, err := reconciler.reconcile(context.TODO(), testPlacement)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.wantPhase, updated.Status.Phase)
+			require.Equal(t, testCase.wantSelectLocation, updated.Status.SelectedLocation)
+			ready := conditions.Get(updated, schedulingv1alpha1.PlacementReady)
+			require.NotNil(t, ready)
+			require.Equal(t, testCase.wantReadyStatus, ready.Status)
+			c := conditions.Get(updated, schedulingv1alpha1.PlacementLocationConstraintsSatisfied)
+			require.NotNil(t, c)
+			require.Equal(t, testCase.wantConstraintsStatus, c.Status)
+			require.Equal(t, testCase.wantConstraintsReason, c.Reason)
+		})
+	}
+}
+
+func newSelectedPlacement(name string, labels map[string]string, location string) *schedulingv1alpha1.Placement {
+	return &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Status: schedulingv1alpha1.PlacementStatus{
+			Phase:            schedulingv1alpha1.PlacementBound,
+			SelectedLocation: &schedulingv1alpha1.LocationReference{LocationName: location},
+		},
+	}
+}
+
 func newLocation(name string, labels map[string]string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
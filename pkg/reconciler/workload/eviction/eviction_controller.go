@@ -0,0 +1,399 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eviction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	schedulinginformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/scheduling/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName   = "kcp-workload-eviction"
+	bySyncTargetName = controllerName + "-bySyncTargetName"
+)
+
+// NewController returns a new controller moving the namespaces off the SyncTargets whose EvictAfter time
+// has passed. At most maxUnavailable namespaces of a SyncTarget are moved at the same time, and a namespace
+// is only removed from the evicted SyncTarget once another SyncTarget has synced it.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	kcpClusterClient kcpclient.ClusterInterface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	namespaceInformer coreinformers.NamespaceInformer,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
+	placementInformer schedulinginformers.PlacementInformer,
+	maxUnavailable int,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+		enqueueAfter: func(syncTarget *workloadv1alpha1.SyncTarget, duration time.Duration) {
+			key := clusters.ToClusterAwareKey(logicalcluster.From(syncTarget), syncTarget.Name)
+			queue.AddAfter(key, duration)
+		},
+
+		maxUnavailable: maxUnavailable,
+
+		kubeClusterClient: kubeClusterClient,
+		kcpClusterClient:  kcpClusterClient,
+
+		ddsif: ddsif,
+
+		namespaceIndexer: namespaceInformer.Informer().GetIndexer(),
+
+		syncTargetLister:  syncTargetInformer.Lister(),
+		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
+
+		placementIndexer: placementInformer.Informer().GetIndexer(),
+	}
+
+	if err := indexers.AddIfNotPresent(namespaceInformer.Informer(), cache.Indexers{
+		indexers.ByScheduledSyncTarget: indexers.IndexByScheduledSyncTarget,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
+		bySyncTargetName: indexBySyncTargetName,
+	}); err != nil {
+		return nil, err
+	}
+
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueNamespace,
+		UpdateFunc: func(old, obj interface{}) {
+			oldNS := old.(*corev1.Namespace)
+			newNS := obj.(*corev1.Namespace)
+			if !reflect.DeepEqual(evictionStateLabels(oldNS.Labels), evictionStateLabels(newNS.Labels)) ||
+				!reflect.DeepEqual(evictionStateAnnotations(oldNS.Annotations), evictionStateAnnotations(newNS.Annotations)) {
+				c.enqueueNamespace(obj)
+			}
+		},
+		DeleteFunc: c.enqueueNamespace,
+	})
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueSyncTarget,
+		UpdateFunc: func(old, obj interface{}) {
+			oldSyncTarget := old.(*workloadv1alpha1.SyncTarget)
+			newSyncTarget := obj.(*workloadv1alpha1.SyncTarget)
+			if !equality.Semantic.DeepEqual(oldSyncTarget.Spec, newSyncTarget.Spec) ||
+				!equality.Semantic.DeepEqual(oldSyncTarget.Status.Eviction, newSyncTarget.Status.Eviction) {
+				c.enqueueSyncTarget(obj)
+			}
+		},
+		DeleteFunc: nil, // Nothing to do.
+	})
+
+	return c, nil
+}
+
+// controller
+type controller struct {
+	queue        workqueue.RateLimitingInterface
+	enqueueAfter func(*workloadv1alpha1.SyncTarget, time.Duration)
+
+	maxUnavailable int
+
+	kubeClusterClient kubernetesclient.ClusterInterface
+	kcpClusterClient  kcpclient.ClusterInterface
+
+	ddsif *informer.DynamicDiscoverySharedInformerFactory
+
+	namespaceIndexer cache.Indexer
+
+	syncTargetLister  workloadlisters.SyncTargetLister
+	syncTargetIndexer cache.Indexer
+
+	placementIndexer cache.Indexer
+}
+
+func indexBySyncTargetName(obj interface{}) ([]string, error) {
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a SyncTarget, but is %T", obj)
+	}
+
+	return []string{syncTarget.Name}, nil
+}
+
+func evictionStateLabels(ls map[string]string) map[string]string {
+	ret := make(map[string]string, len(ls))
+	for k, v := range ls {
+		if strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+func evictionStateAnnotations(as map[string]string) map[string]string {
+	ret := make(map[string]string, len(as))
+	for k, v := range as {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix) ||
+			strings.HasPrefix(k, workloadv1alpha1.InternalClusterEvictionAnnotationPrefix) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// enqueueNamespace enqueues the SyncTargets the namespace is scheduled to.
+func (c *controller) enqueueNamespace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a Namespace, but is %T", obj))
+		return
+	}
+
+	syncTargetNames, err := indexers.IndexByScheduledSyncTarget(ns)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, name := range syncTargetNames {
+		// TODO(sttts): use sync-target-uid instead of sync-target-name in the state label
+		syncTargets, err := c.syncTargetIndexer.ByIndex(bySyncTargetName, name)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		for _, syncTarget := range syncTargets {
+			key, err := cache.MetaNamespaceKeyFunc(syncTarget)
+			if err != nil {
+				runtime.HandleError(err)
+				continue
+			}
+			klog.V(4).Infof("Queueing SyncTarget %s because of Namespace %s|%s", key, logicalcluster.From(ns), ns.Name)
+			c.queue.Add(key)
+		}
+	}
+}
+
+func (c *controller) enqueueSyncTarget(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	klog.V(2).Infof("Queueing SyncTarget %s", key)
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		klog.Errorf("invalid key: %q: %v", key, err)
+		return nil
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	obj, err := c.syncTargetLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	r := &evictionReconciler{
+		maxUnavailable:       c.maxUnavailable,
+		listNamespaces:       c.listNamespaces,
+		listPlacements:       c.listPlacements,
+		listNamespaceObjects: c.listNamespaceObjects,
+		patchNamespace:       c.patchNamespace,
+		enqueueAfter:         c.enqueueAfter,
+		now:                  time.Now,
+	}
+	reconcileErr := r.reconcile(ctx, obj)
+
+	// If the object being reconciled changed as a result, update it.
+	if !equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		oldData, err := json.Marshal(workloadv1alpha1.SyncTarget{
+			Status: old.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to Marshal old data for SyncTarget %s|%s: %w", clusterName, name, err)
+		}
+
+		newData, err := json.Marshal(workloadv1alpha1.SyncTarget{
+			ObjectMeta: metav1.ObjectMeta{
+				UID:             old.UID,
+				ResourceVersion: old.ResourceVersion,
+			}, // to ensure they appear in the patch as preconditions
+			Status: obj.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to Marshal new data for SyncTarget %s|%s: %w", clusterName, name, err)
+		}
+
+		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+		if err != nil {
+			return fmt.Errorf("failed to create patch for SyncTarget %s|%s: %w", clusterName, name, err)
+		}
+		klog.V(2).Infof("Patching SyncTarget %s|%s with patch %s", clusterName, name, string(patchBytes))
+		if _, err := c.kcpClusterClient.Cluster(clusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+			return err
+		}
+	}
+
+	return reconcileErr
+}
+
+// listNamespaces returns the namespaces of all workspaces that are scheduled to a SyncTarget with the given name.
+func (c *controller) listNamespaces(syncTargetName string) ([]*corev1.Namespace, error) {
+	items, err := c.namespaceIndexer.ByIndex(indexers.ByScheduledSyncTarget, syncTargetName)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*corev1.Namespace, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*corev1.Namespace))
+	}
+	return ret, nil
+}
+
+// listPlacements returns the placements of the given workspace.
+func (c *controller) listPlacements(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+	items, err := c.placementIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*schedulingv1alpha1.Placement, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.Placement))
+	}
+	return ret, nil
+}
+
+// listNamespaceObjects returns the objects of all the resource types in the given namespace.
+func (c *controller) listNamespaceObjects(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error) {
+	listers, notSynced := c.ddsif.Listers()
+	if len(notSynced) > 0 {
+		return nil, fmt.Errorf("informers for %v are not synced yet", notSynced)
+	}
+
+	var ret []*unstructured.Unstructured
+	for _, lister := range listers {
+		objs, err := lister.ByNamespace(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range objs {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+
+			// TODO(ncdc): remove this when we have namespaced listers that only return for the scoped cluster (https://github.com/kcp-dev/kcp/issues/685).
+			if logicalcluster.From(u) != clusterName {
+				continue
+			}
+			ret = append(ret, u)
+		}
+	}
+	return ret, nil
+}
+
+func (c *controller) patchNamespace(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+	klog.V(2).Infof("Patching namespace %s|%s with patch %s", clusterName, name, string(data))
+	return c.kubeClusterClient.Cluster(clusterName).CoreV1().Namespaces().Patch(ctx, name, pt, data, opts, subresources...)
+}
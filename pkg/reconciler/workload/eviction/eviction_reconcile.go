@@ -0,0 +1,307 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eviction
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// evictionResyncPeriod is the period at which an eviction in progress is checked, for the namespaces
+// being evicted to be synced by other sync targets.
+const evictionResyncPeriod = 10 * time.Second
+
+// evictionReconciler moves the namespaces off an evicting sync target in batches. The eviction of a namespace
+// starts by setting the eviction annotation of the sync target on the namespace, which makes the namespace
+// scheduler schedule the namespace to another sync target. Once the other sync targets have synced the
+// namespace, the deletion timestamp annotation of the evicted sync target is set, and the namespace
+// scheduler removes the namespace from the evicted sync target.
+type evictionReconciler struct {
+	// maxUnavailable is the maximum number of namespaces being evicted that are not synced by
+	// another sync target yet.
+	maxUnavailable int
+
+	listNamespaces       func(syncTargetName string) ([]*corev1.Namespace, error)
+	listPlacements       func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
+	listNamespaceObjects func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error)
+
+	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
+
+	enqueueAfter func(*workloadv1alpha1.SyncTarget, time.Duration)
+
+	now func() time.Time
+}
+
+func (r *evictionReconciler) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) error {
+	namespaces, err := r.scheduledNamespaces(syncTarget)
+	if err != nil {
+		return err
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return clusters.ToClusterAwareKey(logicalcluster.From(namespaces[i]), namespaces[i].Name) <
+			clusters.ToClusterAwareKey(logicalcluster.From(namespaces[j]), namespaces[j].Name)
+	})
+
+	evictionKey := workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + syncTarget.Name
+	deletionKey := workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + syncTarget.Name
+
+	now := r.now()
+	var errs []error
+
+	if syncTarget.Spec.EvictAfter == nil || now.Before(syncTarget.Spec.EvictAfter.Time) {
+		// the eviction has not started yet, or it was cancelled. Stop evicting the namespaces which
+		// are not synced by another sync target yet.
+		syncTarget.Status.Eviction = nil
+		if syncTarget.Spec.EvictAfter != nil {
+			r.enqueueAfter(syncTarget, syncTarget.Spec.EvictAfter.Time.Sub(now))
+		}
+
+		for _, ns := range namespaces {
+			_, evicting := ns.Annotations[evictionKey]
+			_, removing := ns.Annotations[deletionKey]
+			if !evicting || removing {
+				continue
+			}
+
+			klog.V(2).Infof("Stopping the eviction of namespace %s|%s from SyncTarget %s|%s", logicalcluster.From(ns), ns.Name, logicalcluster.From(syncTarget), syncTarget.Name)
+			if err := r.patchNamespaceAnnotations(ctx, ns, map[string]interface{}{evictionKey: nil}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return utilerrors.NewAggregate(errs)
+	}
+
+	eviction := syncTarget.Status.Eviction
+	if eviction == nil {
+		klog.V(2).Infof("Starting the eviction of SyncTarget %s|%s", logicalcluster.From(syncTarget), syncTarget.Name)
+		eviction = &workloadv1alpha1.SyncTargetEviction{StartTime: metav1.NewTime(now)}
+	}
+
+	var pending []*corev1.Namespace
+	evicting, removing := 0, 0
+	for _, ns := range namespaces {
+		if _, found := ns.Annotations[deletionKey]; found {
+			removing++
+			continue
+		}
+		if _, found := ns.Annotations[evictionKey]; !found {
+			pending = append(pending, ns)
+			continue
+		}
+
+		synced, err := r.syncedByOtherSyncTargets(ns, syncTarget.Name)
+		if err != nil {
+			errs = append(errs, err)
+			evicting++
+			continue
+		}
+		if !synced {
+			evicting++
+			continue
+		}
+
+		klog.V(2).Infof("Removing namespace %s|%s from evicted SyncTarget %s|%s", logicalcluster.From(ns), ns.Name, logicalcluster.From(syncTarget), syncTarget.Name)
+		if err := r.patchNamespaceAnnotations(ctx, ns, map[string]interface{}{
+			evictionKey: nil,
+			deletionKey: now.UTC().Format(time.RFC3339),
+		}); err != nil {
+			errs = append(errs, err)
+			evicting++
+			continue
+		}
+		removing++
+	}
+
+	// start the eviction of the pending namespaces, as long as less than maxUnavailable namespaces are
+	// not synced by another sync target.
+	started := 0
+	for _, ns := range pending {
+		if evicting >= r.maxUnavailable {
+			break
+		}
+
+		klog.V(2).Infof("Evicting namespace %s|%s from SyncTarget %s|%s", logicalcluster.From(ns), ns.Name, logicalcluster.From(syncTarget), syncTarget.Name)
+		if err := r.patchNamespaceAnnotations(ctx, ns, map[string]interface{}{
+			evictionKey: now.UTC().Format(time.RFC3339),
+		}); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		started++
+		evicting++
+	}
+
+	eviction.PendingNamespaces = int32(len(pending) - started)
+	eviction.EvictingNamespaces = int32(evicting)
+	eviction.RemovingNamespaces = int32(removing)
+	if len(namespaces) == 0 {
+		if eviction.CompletionTime == nil {
+			klog.V(2).Infof("Completed the eviction of SyncTarget %s|%s", logicalcluster.From(syncTarget), syncTarget.Name)
+			eviction.CompletionTime = &metav1.Time{Time: now}
+		}
+	} else {
+		eviction.CompletionTime = nil
+		r.enqueueAfter(syncTarget, evictionResyncPeriod)
+	}
+	syncTarget.Status.Eviction = eviction
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// scheduledNamespaces returns the namespaces scheduled to the sync target. The state labels of the namespaces
+// only hold the sync target name, which is not unique across workspaces, so only the namespaces bound to a
+// placement that selected a location in the workspace of the sync target are returned.
+func (r *evictionReconciler) scheduledNamespaces(syncTarget *workloadv1alpha1.SyncTarget) ([]*corev1.Namespace, error) {
+	// TODO(sttts): use sync-target-uid instead of sync-target-name in the state label
+	namespaces, err := r.listNamespaces(syncTarget.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	syncTargetWorkspace := logicalcluster.From(syncTarget).String()
+	placements := map[logicalcluster.Name][]*schedulingv1alpha1.Placement{}
+	ret := make([]*corev1.Namespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		clusterName := logicalcluster.From(ns)
+		nsPlacements, found := placements[clusterName]
+		if !found {
+			nsPlacements, err = r.listPlacements(clusterName)
+			if err != nil {
+				return nil, err
+			}
+			placements[clusterName] = nsPlacements
+		}
+
+		for _, placement := range nsPlacements {
+			if placement.Status.SelectedLocation == nil || placement.Status.SelectedLocation.Path != syncTargetWorkspace {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(placement.Spec.NamespaceSelector)
+			if err != nil {
+				continue
+			}
+			if selector.Matches(labels.Set(ns.Labels)) {
+				ret = append(ret, ns)
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
+// syncedByOtherSyncTargets returns true if the namespace is scheduled to other sync targets than the evicted
+// one, and all of them have synced the objects of the namespace that are synced by the evicted sync target.
+func (r *evictionReconciler) syncedByOtherSyncTargets(ns *corev1.Namespace, evictedSyncTargetName string) (bool, error) {
+	var syncTargetNames []string
+	for k, v := range ns.Labels {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) {
+			continue
+		}
+		syncTargetName := strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix)
+		if syncTargetName == evictedSyncTargetName {
+			continue
+		}
+		if _, found := ns.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTargetName]; found {
+			continue
+		}
+		if _, found := ns.Annotations[workloadv1alpha1.InternalClusterEvictionAnnotationPrefix+syncTargetName]; found {
+			continue
+		}
+		if v != string(workloadv1alpha1.ResourceStateSync) {
+			return false, nil
+		}
+		syncTargetNames = append(syncTargetNames, syncTargetName)
+	}
+	if len(syncTargetNames) == 0 {
+		return false, nil
+	}
+
+	objs, err := r.listNamespaceObjects(logicalcluster.From(ns), ns.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, obj := range objs {
+		if _, found := obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+evictedSyncTargetName]; !found {
+			continue
+		}
+		for _, syncTargetName := range syncTargetNames {
+			if !objectSynced(obj, syncTargetName) {
+				klog.V(4).Infof("Object %s %s|%s/%s is not synced by SyncTarget %s yet", obj.GetKind(), logicalcluster.From(obj), obj.GetNamespace(), obj.GetName(), syncTargetName)
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// objectSynced returns true if the syncer of the sync target has claimed the object, and did not report a
+// failure to sync it.
+func objectSynced(obj *unstructured.Unstructured, syncTargetName string) bool {
+	if obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTargetName] != string(workloadv1alpha1.ResourceStateSync) {
+		return false
+	}
+	if _, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTargetName]; found {
+		return false
+	}
+
+	hasFinalizer := false
+	for _, finalizer := range obj.GetFinalizers() {
+		if finalizer == shared.SyncerFinalizerNamePrefix+syncTargetName {
+			hasFinalizer = true
+		}
+	}
+	if !hasFinalizer {
+		return false
+	}
+
+	status, found, err := shared.SyncStatusFromAnnotations(obj.GetAnnotations(), syncTargetName)
+	if err != nil {
+		return false
+	}
+	return !found || status.Reason == ""
+}
+
+func (r *evictionReconciler) patchNamespaceAnnotations(ctx context.Context, ns *corev1.Namespace, annotations map[string]interface{}) error {
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.patchNamespace(ctx, logicalcluster.From(ns), ns.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
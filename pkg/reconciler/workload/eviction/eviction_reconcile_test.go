@@ -0,0 +1,340 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eviction
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestEvictionReconcile(t *testing.T) {
+	now := time.Now()
+	now3339 := now.UTC().Format(time.RFC3339)
+	evictAfter := &metav1.Time{Time: now.Add(-time.Minute)}
+
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix
+	evictionKey := workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + "old"
+	deletionKey := workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "old"
+
+	movedLabels := map[string]string{
+		stateLabel + "old": string(workloadv1alpha1.ResourceStateSync),
+		stateLabel + "new": string(workloadv1alpha1.ResourceStateSync),
+	}
+
+	testCases := []struct {
+		name string
+
+		evictAfter     *metav1.Time
+		eviction       *workloadv1alpha1.SyncTargetEviction
+		maxUnavailable int
+		namespaces     []*corev1.Namespace
+		// placements are the placements of the namespace workspaces. By default, the namespaces are bound
+		// to a placement that selected a location in the workspace of the evicted sync target.
+		placements []*schedulingv1alpha1.Placement
+		objects    []*unstructured.Unstructured
+
+		expectedAnnotations map[string]map[string]string
+		expectedEviction    *workloadv1alpha1.SyncTargetEviction
+		wantRequeue         bool
+	}{
+		{
+			name:       "stop the eviction of the namespaces when the eviction is cancelled",
+			evictAfter: nil,
+			eviction:   &workloadv1alpha1.SyncTargetEviction{StartTime: metav1.NewTime(now)},
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", movedLabels, map[string]string{evictionKey: now3339}),
+				namespace("ns2", movedLabels, map[string]string{evictionKey: now3339, deletionKey: now3339}),
+			},
+			expectedAnnotations: map[string]map[string]string{
+				"ns1": {},
+			},
+			expectedEviction: nil,
+		},
+		{
+			name:           "start the eviction of at most maxUnavailable namespaces",
+			evictAfter:     evictAfter,
+			maxUnavailable: 2,
+			namespaces: []*corev1.Namespace{
+				namespace("ns3", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+				namespace("ns1", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+				namespace("ns2", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+			},
+			expectedAnnotations: map[string]map[string]string{
+				"ns1": {evictionKey: now3339},
+				"ns2": {evictionKey: now3339},
+			},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now),
+				PendingNamespaces:  1,
+				EvictingNamespaces: 2,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:           "wait for the new sync target to sync the objects of the namespace",
+			evictAfter:     evictAfter,
+			eviction:       &workloadv1alpha1.SyncTargetEviction{StartTime: metav1.NewTime(now.Add(-time.Minute))},
+			maxUnavailable: 1,
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", movedLabels, map[string]string{evictionKey: now3339}),
+				namespace("ns2", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+			},
+			objects: []*unstructured.Unstructured{
+				object("ns1", movedLabels, nil, []string{shared.SyncerFinalizerNamePrefix + "old"}),
+			},
+			expectedAnnotations: map[string]map[string]string{},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now.Add(-time.Minute)),
+				PendingNamespaces:  1,
+				EvictingNamespaces: 1,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:           "wait for the new sync target to be scheduled",
+			evictAfter:     evictAfter,
+			maxUnavailable: 1,
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, map[string]string{evictionKey: now3339}),
+			},
+			expectedAnnotations: map[string]map[string]string{},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now),
+				EvictingNamespaces: 1,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:           "objects failing to sync to the new sync target block the eviction",
+			evictAfter:     evictAfter,
+			maxUnavailable: 1,
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", movedLabels, map[string]string{evictionKey: now3339}),
+			},
+			objects: []*unstructured.Unstructured{
+				object("ns1", movedLabels,
+					map[string]string{workloadv1alpha1.InternalClusterSyncStatusAnnotationPrefix + "new": `{"observedGeneration":1,"reason":"ApplyFailed"}`},
+					[]string{shared.SyncerFinalizerNamePrefix + "old", shared.SyncerFinalizerNamePrefix + "new"}),
+			},
+			expectedAnnotations: map[string]map[string]string{},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now),
+				EvictingNamespaces: 1,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:           "remove the namespace from the evicted sync target once synced, and evict the next one",
+			evictAfter:     evictAfter,
+			maxUnavailable: 1,
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", movedLabels, map[string]string{evictionKey: now3339}),
+				namespace("ns2", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+			},
+			objects: []*unstructured.Unstructured{
+				object("ns1", movedLabels,
+					map[string]string{workloadv1alpha1.InternalClusterSyncStatusAnnotationPrefix + "new": `{"observedGeneration":1}`},
+					[]string{shared.SyncerFinalizerNamePrefix + "old", shared.SyncerFinalizerNamePrefix + "new"}),
+				object("ns1", map[string]string{stateLabel + "new": string(workloadv1alpha1.ResourceStateSync)}, nil, nil),
+			},
+			expectedAnnotations: map[string]map[string]string{
+				"ns1": {deletionKey: now3339},
+				"ns2": {evictionKey: now3339},
+			},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now),
+				EvictingNamespaces: 1,
+				RemovingNamespaces: 1,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:           "ignore the namespaces scheduled to a sync target with the same name in another workspace",
+			evictAfter:     evictAfter,
+			maxUnavailable: 2,
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil),
+				inWorkspace("root:org:other-ws", namespace("ns2", map[string]string{stateLabel + "old": string(workloadv1alpha1.ResourceStateSync)}, nil)),
+			},
+			placements: []*schedulingv1alpha1.Placement{
+				placement("root:org:ws", "root:org:loc"),
+				placement("root:org:other-ws", "root:org:other-loc"),
+			},
+			expectedAnnotations: map[string]map[string]string{
+				"ns1": {evictionKey: now3339},
+			},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now),
+				EvictingNamespaces: 1,
+			},
+			wantRequeue: true,
+		},
+		{
+			name:       "complete the eviction when no namespace is scheduled anymore",
+			evictAfter: evictAfter,
+			eviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:          metav1.NewTime(now.Add(-time.Minute)),
+				RemovingNamespaces: 1,
+			},
+			maxUnavailable:      1,
+			expectedAnnotations: map[string]map[string]string{},
+			expectedEviction: &workloadv1alpha1.SyncTargetEviction{
+				StartTime:      metav1.NewTime(now.Add(-time.Minute)),
+				CompletionTime: &metav1.Time{Time: now},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			syncTarget := &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "old",
+					ClusterName: "root:org:loc",
+				},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					EvictAfter: testCase.evictAfter,
+				},
+				Status: workloadv1alpha1.SyncTargetStatus{
+					Eviction: testCase.eviction,
+				},
+			}
+
+			placements := testCase.placements
+			if placements == nil {
+				placements = []*schedulingv1alpha1.Placement{placement("root:org:ws", "root:org:loc")}
+			}
+
+			annotations := map[string]map[string]string{}
+			var requeued bool
+			reconciler := &evictionReconciler{
+				maxUnavailable: testCase.maxUnavailable,
+				listNamespaces: func(syncTargetName string) ([]*corev1.Namespace, error) {
+					require.Equal(t, "old", syncTargetName)
+					return testCase.namespaces, nil
+				},
+				listPlacements: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
+					var ret []*schedulingv1alpha1.Placement
+					for _, placement := range placements {
+						if logicalcluster.From(placement) == clusterName {
+							ret = append(ret, placement)
+						}
+					}
+					return ret, nil
+				},
+				listNamespaceObjects: func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error) {
+					var objs []*unstructured.Unstructured
+					for _, obj := range testCase.objects {
+						if obj.GetNamespace() == namespace {
+							objs = append(objs, obj)
+						}
+					}
+					return objs, nil
+				},
+				patchNamespace: func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+					for _, ns := range testCase.namespaces {
+						if ns.Name != name {
+							continue
+						}
+						nsData, err := json.Marshal(ns)
+						require.NoError(t, err)
+						patchedData, err := jsonpatch.MergePatch(nsData, data)
+						require.NoError(t, err)
+						var patched corev1.Namespace
+						require.NoError(t, json.Unmarshal(patchedData, &patched))
+						annotations[name] = patched.Annotations
+						if annotations[name] == nil {
+							annotations[name] = map[string]string{}
+						}
+						return &patched, nil
+					}
+					t.Fatalf("unexpected patch of namespace %s", name)
+					return nil, nil
+				},
+				enqueueAfter: func(*workloadv1alpha1.SyncTarget, time.Duration) { requeued = true },
+				now:          func() time.Time { return now },
+			}
+
+			err := reconciler.reconcile(context.TODO(), syncTarget)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedAnnotations, annotations)
+			require.Equal(t, testCase.expectedEviction, syncTarget.Status.Eviction)
+			require.Equal(t, testCase.wantRequeue, requeued)
+		})
+	}
+}
+
+func namespace(name string, labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: "root:org:ws",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func inWorkspace(clusterName string, ns *corev1.Namespace) *corev1.Namespace {
+	ns.ClusterName = clusterName
+	return ns
+}
+
+func placement(clusterName, locationWorkspace string) *schedulingv1alpha1.Placement {
+	return &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			ClusterName: clusterName,
+		},
+		Spec: schedulingv1alpha1.PlacementSpec{
+			NamespaceSelector: &metav1.LabelSelector{},
+		},
+		Status: schedulingv1alpha1.PlacementStatus{
+			SelectedLocation: &schedulingv1alpha1.LocationReference{
+				Path:         locationWorkspace,
+				LocationName: "us-east1",
+			},
+		},
+	}
+}
+
+func object(namespace string, labels, annotations map[string]string, finalizers []string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace(namespace)
+	obj.SetName("test")
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+	obj.SetFinalizers(finalizers)
+	return obj
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eviction
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		MaxUnavailableNamespaces: 10,
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.IntVar(&o.MaxUnavailableNamespaces, "sync-target-eviction-max-unavailable", o.MaxUnavailableNamespaces, "Maximum number of namespaces of an evicting sync target that are moved at the same time. A namespace is moved once another sync target has synced it")
+	return o
+}
+
+type Options struct {
+	MaxUnavailableNamespaces int
+}
+
+func (o *Options) Validate() error {
+	if o.MaxUnavailableNamespaces <= 0 {
+		return fmt.Errorf("--sync-target-eviction-max-unavailable must be >0 (%d)", o.MaxUnavailableNamespaces)
+	}
+	return nil
+}
@@ -49,6 +49,7 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 const (
@@ -110,6 +111,12 @@ func NewController(
 		return nil, err
 	}
 
+	if err := indexers.AddIfNotPresent(namespaceInformer.Informer(), cache.Indexers{
+		indexers.ByScheduledSyncTarget: indexers.IndexByScheduledSyncTarget,
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
 		byWorkspace:         indexByWorksapce,
 		byLocationWorkspace: indexByLoactionWorkspace,
@@ -163,6 +170,7 @@ func NewController(
 				oldClusterCopy.Status.LastSyncerHeartbeatTime = nil
 				oldClusterCopy.Status.VirtualWorkspaces = nil
 				oldClusterCopy.Status.Capacity = nil
+				oldClusterCopy.Status.Eviction = nil
 
 				newCluster := obj.(*workloadv1alpha1.SyncTarget)
 				newClusterCopy := *newCluster
@@ -170,6 +178,7 @@ func NewController(
 				newClusterCopy.Status.LastSyncerHeartbeatTime = nil
 				newClusterCopy.Status.VirtualWorkspaces = nil
 				newClusterCopy.Status.Capacity = nil
+				newClusterCopy.Status.Eviction = nil
 
 				// compare ignoring heart-beat
 				if !reflect.DeepEqual(oldClusterCopy, newClusterCopy) {
@@ -28,6 +28,7 @@ import (
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 type reconcileStatus int
@@ -54,6 +55,8 @@ func (c *controller) reconcile(ctx context.Context, ns *corev1.Namespace) error
 			enqueueAfter:   c.enqueueAfter,
 			patchNamespace: c.patchNamespace,
 			now:            time.Now,
+
+			listScheduledNamespaces: c.listScheduledNamespaces,
 		},
 		&statusConditionReconciler{
 			patchNamespace: c.patchNamespace,
@@ -101,6 +104,19 @@ func (c *controller) listPlacement(clusterName logicalcluster.Name) ([]*scheduli
 	return ret, nil
 }
 
+// listScheduledNamespaces returns the namespaces of all workspaces that are scheduled to a SyncTarget with the given name.
+func (c *controller) listScheduledNamespaces(syncTargetName string) ([]*corev1.Namespace, error) {
+	items, err := c.namespaceIndexer.ByIndex(indexers.ByScheduledSyncTarget, syncTargetName)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*corev1.Namespace, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*corev1.Namespace))
+	}
+	return ret, nil
+}
+
 func (c *controller) getLocation(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
 	key := clusters.ToClusterAwareKey(clusterName, name)
 	return c.locationLister.Get(key)
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"math/rand"
+	"sort"
 	"strings"
 	"time"
 
@@ -47,6 +48,8 @@ type placementSchedulingReconciler struct {
 	listPlacement  func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
 	getLocation    func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
 
+	listScheduledNamespaces func(syncTargetName string) ([]*corev1.Namespace, error)
+
 	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
 
 	enqueueAfter func(*corev1.Namespace, time.Duration)
@@ -55,31 +58,59 @@ type placementSchedulingReconciler struct {
 }
 
 type locationClusters struct {
-	candidates       map[string]*workloadv1alpha1.SyncTarget
-	scheduledCluster *workloadv1alpha1.SyncTarget
+	candidates        map[string]*workloadv1alpha1.SyncTarget
+	evicting          map[string]*workloadv1alpha1.SyncTarget
+	scheduledClusters map[string]*workloadv1alpha1.SyncTarget
+	scorers           []weightedScorer
+
+	// replicas is the number of clusters to schedule to in this location, or 0 to schedule to all the
+	// candidates.
+	replicas int
 }
 
-func newLocationClusters(clusters []*workloadv1alpha1.SyncTarget) *locationClusters {
+func newLocationClusters(clusters, evicting []*workloadv1alpha1.SyncTarget, replicas int, scorers []weightedScorer) *locationClusters {
 	l := &locationClusters{
-		candidates: map[string]*workloadv1alpha1.SyncTarget{},
+		candidates:        map[string]*workloadv1alpha1.SyncTarget{},
+		evicting:          map[string]*workloadv1alpha1.SyncTarget{},
+		scheduledClusters: map[string]*workloadv1alpha1.SyncTarget{},
+		scorers:           scorers,
+		replicas:          replicas,
 	}
 
 	for _, cluster := range clusters {
 		l.candidates[cluster.Name] = cluster
 	}
+	for _, cluster := range evicting {
+		l.evicting[cluster.Name] = cluster
+	}
 
 	return l
 }
 
+// scheduled returns true if enough clusters are scheduled for this location.
 func (l *locationClusters) scheduled() bool {
-	return l.scheduledCluster != nil
+	if l.replicas == 0 {
+		return len(l.scheduledClusters) > 0 && len(l.unscheduledCandidates()) == 0
+	}
+	return len(l.scheduledClusters) >= l.replicas
+}
+
+// unscheduledCandidates returns the candidates that are not scheduled yet.
+func (l *locationClusters) unscheduledCandidates() []*workloadv1alpha1.SyncTarget {
+	var ret []*workloadv1alpha1.SyncTarget
+	for name, cluster := range l.candidates {
+		if _, found := l.scheduledClusters[name]; !found {
+			ret = append(ret, cluster)
+		}
+	}
+	return ret
 }
 
 func (l *locationClusters) exclude(syncTargetName string) {
 	delete(l.candidates, syncTargetName)
 }
 
-// potentiallySchedule sets a syncTarget as a scheduled cluster for this location if
+// potentiallySchedule adds a syncTarget to the scheduled clusters for this location if
 // this syncTarget is a valid candidate and this location is not scheduled yet, and
 // return true.
 func (l *locationClusters) potentiallySchedule(syncTargetName string) bool {
@@ -92,22 +123,84 @@ func (l *locationClusters) potentiallySchedule(syncTargetName string) bool {
 		return false
 	}
 
-	l.scheduledCluster = cluster
+	l.scheduledClusters[syncTargetName] = cluster
+	return true
+}
+
+// isEvicting returns true if the syncTarget is in this location, and its EvictAfter time has passed.
+func (l *locationClusters) isEvicting(syncTargetName string) bool {
+	_, found := l.evicting[syncTargetName]
+	return found
+}
+
+// potentiallyKeepEvicting keeps an evicting syncTarget as a scheduled cluster for this location
+// if this location is not scheduled yet, and return true. The namespace is moved off the syncTarget
+// by the eviction controller.
+func (l *locationClusters) potentiallyKeepEvicting(syncTargetName string) bool {
+	cluster, found := l.evicting[syncTargetName]
+	if !found {
+		return false
+	}
+
+	if l.scheduled() {
+		return false
+	}
+
+	l.scheduledClusters[syncTargetName] = cluster
 	return true
 }
 
-func (l *locationClusters) schedule() *workloadv1alpha1.SyncTarget {
-	if len(l.candidates) == 0 {
-		return nil
+// schedule adds the candidates with the highest weighted scores to the scheduled clusters for this location
+// until it is scheduled, and returns them. Ties are broken randomly.
+func (l *locationClusters) schedule(ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, error) {
+	candidates := l.unscheduledCandidates()
+	needed := len(candidates)
+	if l.replicas > 0 {
+		needed = l.replicas - len(l.scheduledClusters)
+	}
+	if len(candidates) == 0 || needed <= 0 {
+		return nil, nil
+	}
+
+	totals := make(map[string]int64, len(candidates))
+	for _, s := range l.scorers {
+		scores, err := s.scorer.score(ns, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for name, score := range scores {
+			totals[name] += s.weight * score
+		}
 	}
 
-	var candidates []*workloadv1alpha1.SyncTarget
-	for _, cluster := range l.candidates {
-		candidates = append(candidates, cluster)
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return totals[candidates[i].Name] > totals[candidates[j].Name]
+	})
+	if needed < len(candidates) {
+		candidates = candidates[:needed]
 	}
 
-	l.scheduledCluster = candidates[rand.Intn(len(candidates))]
-	return l.scheduledCluster
+	for _, cluster := range candidates {
+		l.scheduledClusters[cluster.Name] = cluster
+	}
+	return candidates, nil
+}
+
+// syncTargetReplicas returns the number of sync targets of the selected location a namespace bound to the
+// placement is scheduled to, or 0 to schedule it to all of them.
+func syncTargetReplicas(placement *schedulingv1alpha1.Placement) int {
+	selection := placement.Spec.SyncTargets
+	switch {
+	case selection == nil:
+		return 1
+	case selection.Mode == schedulingv1alpha1.SyncTargetSelectionAll:
+		return 0
+	case selection.Replicas < 1:
+		return 1
+	default:
+		return int(selection.Replicas)
+	}
 }
 
 func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev1.Namespace) (reconcileStatus, *corev1.Namespace, error) {
@@ -129,14 +222,14 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	validLocationClusters := map[schedulingv1alpha1.LocationReference]*locationClusters{}
 	var errs []error
 	for _, placement := range validPlacements {
-		clusters, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement, ns)
+		clusters, evicting, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement, ns)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 
-		if len(clusters) > 0 {
-			validLocationClusters[*placement.Status.SelectedLocation] = newLocationClusters(clusters)
+		if len(clusters) > 0 || len(evicting) > 0 {
+			validLocationClusters[*placement.Status.SelectedLocation] = newLocationClusters(clusters, evicting, syncTargetReplicas(placement), r.scorersForPlacement(placement))
 		}
 	}
 
@@ -152,18 +245,27 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	expectedLabels := map[string]interface{}{}      // nil means to remove the key
 
 	for _, cluster := range synced {
+		_, evictionStarted := ns.Annotations[workloadv1alpha1.InternalClusterEvictionAnnotationPrefix+cluster]
 		clusterScheduledByLocation := false
+		clusterEvicting := false
 		for _, locationClusters := range validLocationClusters {
 			// this is non deterministic when the same sync targets are selected in multiple locations.
 			// TODO(qiujian16): consider if we need to save the location/synctarget mappings in the ns.
 			if locationClusters.potentiallySchedule(cluster) {
 				clusterScheduledByLocation = true
+			} else if locationClusters.isEvicting(cluster) {
+				// an evicting cluster stays scheduled until the eviction controller starts to evict the ns,
+				// and is then removed by the eviction controller once another cluster has synced the ns.
+				clusterEvicting = true
+				if !evictionStarted && locationClusters.potentiallyKeepEvicting(cluster) {
+					clusterScheduledByLocation = true
+				}
 			}
 
 			// exclude synced cluster from candidates.
 			locationClusters.exclude(cluster)
 		}
-		if !clusterScheduledByLocation {
+		if !clusterScheduledByLocation && !clusterEvicting {
 			// it is no longer a synced cluster, mark it as removing.
 			now := r.now().UTC().Format(time.RFC3339)
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+cluster] = now
@@ -183,6 +285,9 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		if removingTime.Add(removingGracePeriod).Before(r.now()) {
 			expectedLabels[workloadv1alpha1.ClusterResourceStateLabelPrefix+cluster] = nil
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+cluster] = nil
+			if _, found := ns.Annotations[workloadv1alpha1.InternalClusterEvictionAnnotationPrefix+cluster]; found {
+				expectedAnnotations[workloadv1alpha1.InternalClusterEvictionAnnotationPrefix+cluster] = nil
+			}
 			klog.V(4).Infof("remove cluster %s for ns %s|%s", cluster, clusterName, ns.Name)
 		} else {
 			enqueuDuration := time.Until(removingTime.Add(removingGracePeriod))
@@ -192,7 +297,7 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		}
 	}
 
-	// 5. randomly select a cluster if there is no cluster syncing currently.
+	// 5. select the clusters with the highest scores if there are not enough clusters syncing currently.
 	// TODO(qiujian16): we currently schedule each in each location independently. It cannot guarantee 1 cluster is schedule per location
 	// when the same synctargets are in multiple locations, we need to rethink whether we need a better algorithm or we need location
 	// to be exclusive.
@@ -201,18 +306,28 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 			continue
 		}
 
-		chosenCluster := locationClusters.schedule()
-		if chosenCluster == nil {
+		chosenClusters, err := locationClusters.schedule(ns)
+		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
 
-		expectedLabels[workloadv1alpha1.ClusterResourceStateLabelPrefix+chosenCluster.Name] = string(workloadv1alpha1.ResourceStateSync)
-		klog.V(4).Infof("set cluster %s sync for ns %s|%s", chosenCluster.Name, clusterName, ns.Name)
+		for _, chosenCluster := range chosenClusters {
+			expectedLabels[workloadv1alpha1.ClusterResourceStateLabelPrefix+chosenCluster.Name] = string(workloadv1alpha1.ResourceStateSync)
+			klog.V(4).Infof("set cluster %s sync for ns %s|%s", chosenCluster.Name, clusterName, ns.Name)
+		}
 	}
 
 	if len(expectedLabels) > 0 || len(expectedAnnotations) > 0 {
 		ns, err := r.patchNamespaceLabelAnnotation(ctx, clusterName, ns, expectedLabels, expectedAnnotations)
-		return reconcileStatusContinue, ns, err
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return reconcileStatusContinue, ns, utilerrors.NewAggregate(errs)
+	}
+
+	if len(errs) > 0 {
+		return reconcileStatusContinue, ns, utilerrors.NewAggregate(errs)
 	}
 
 	// 6. Requeue at last to check if removing cluster should be removed later.
@@ -224,9 +339,11 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	return reconcileStatusContinue, ns, nil
 }
 
-func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement, ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, error) {
+// getAllValidSyncTargetsForPlacement returns the sync targets new workloads can be scheduled to, and the
+// evicting sync targets of the selected location of the placement.
+func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement, ns *corev1.Namespace) ([]*workloadv1alpha1.SyncTarget, []*workloadv1alpha1.SyncTarget, error) {
 	if placement.Status.Phase == schedulingv1alpha1.PlacementPending || placement.Status.SelectedLocation == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	locationWorkspace := logicalcluster.New(placement.Status.SelectedLocation.Path)
@@ -235,27 +352,27 @@ func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clust
 		placement.Status.SelectedLocation.LocationName)
 	switch {
 	case errors.IsNotFound(err):
-		return nil, nil
+		return nil, nil, nil
 	case err != nil:
-		return nil, err
+		return nil, nil, err
 	}
 
 	// find all synctargets in the location workspace
 	syncTargets, err := r.listSyncTarget(locationWorkspace)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// filter the sync targets by location
 	locationClusters, err := locationreconciler.LocationSyncTargets(syncTargets, location)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// find all the valid sync targets.
 	validClusters := locationreconciler.FilterNonEvicting(locationreconciler.FilterReady(locationClusters))
 
-	return validClusters, nil
+	return validClusters, locationreconciler.FilterEvicting(locationClusters), nil
 }
 
 func (r *placementSchedulingReconciler) patchNamespaceLabelAnnotation(ctx context.Context, clusterName logicalcluster.Name, ns *corev1.Namespace, labels, annotations map[string]interface{}) (*corev1.Namespace, error) {
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"github.com/kcp-dev/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// maxScore is the highest score a syncTargetScorer gives to a sync target.
+const maxScore int64 = 100
+
+// syncTargetScorer scores the candidate sync targets a namespace can be scheduled to, from 0 to maxScore.
+// The higher the score, the better the sync target fits the namespace.
+type syncTargetScorer interface {
+	score(ns *corev1.Namespace, candidates []*workloadv1alpha1.SyncTarget) (map[string]int64, error)
+}
+
+type weightedScorer struct {
+	weight int64
+	scorer syncTargetScorer
+}
+
+// scorersForPlacement returns the scorers used to schedule the namespaces bound to the placement.
+func (r *placementSchedulingReconciler) scorersForPlacement(placement *schedulingv1alpha1.Placement) []weightedScorer {
+	scorers := []weightedScorer{
+		{weight: 1, scorer: allocatableScorer{}},
+		{weight: 1, scorer: placedNamespacesScorer{listScheduledNamespaces: r.listScheduledNamespaces}},
+	}
+
+	if schedulingv1alpha1.SpreadPolicy(placement.Annotations[schedulingv1alpha1.ExperimentalSpreadPolicyAnnotationKey]) == schedulingv1alpha1.SpreadPolicyWorkspace {
+		scorers = append(scorers, weightedScorer{weight: 2, scorer: placedNamespacesScorer{
+			listScheduledNamespaces: r.listScheduledNamespaces,
+			sameWorkspace:           true,
+		}})
+	}
+
+	return scorers
+}
+
+// allocatableScorer prefers the sync targets with the most allocatable cpu and memory, relative to the
+// other candidates. Sync targets not reporting their allocatable resources get the lowest score.
+type allocatableScorer struct{}
+
+func (allocatableScorer) score(_ *corev1.Namespace, candidates []*workloadv1alpha1.SyncTarget) (map[string]int64, error) {
+	var maxCPU, maxMemory int64
+	for _, cluster := range candidates {
+		cpu, memory := allocatable(cluster)
+		if cpu > maxCPU {
+			maxCPU = cpu
+		}
+		if memory > maxMemory {
+			maxMemory = memory
+		}
+	}
+
+	scores := make(map[string]int64, len(candidates))
+	for _, cluster := range candidates {
+		cpu, memory := allocatable(cluster)
+		var score int64
+		if maxCPU > 0 {
+			score += maxScore * cpu / maxCPU
+		}
+		if maxMemory > 0 {
+			score += maxScore * memory / maxMemory
+		}
+		scores[cluster.Name] = score / 2
+	}
+	return scores, nil
+}
+
+// allocatable returns the allocatable cpu in millicores and memory in bytes of the sync target.
+func allocatable(cluster *workloadv1alpha1.SyncTarget) (int64, int64) {
+	if cluster.Status.Allocatable == nil {
+		return 0, 0
+	}
+	return cluster.Status.Allocatable.Cpu().MilliValue(), cluster.Status.Allocatable.Memory().Value()
+}
+
+// placedNamespacesScorer prefers the sync targets with the fewest namespaces scheduled to them. When
+// sameWorkspace is true, only the namespaces of the workspace of the scheduled namespace are counted,
+// which spreads the namespaces of a workspace across the sync targets.
+type placedNamespacesScorer struct {
+	listScheduledNamespaces func(syncTargetName string) ([]*corev1.Namespace, error)
+	sameWorkspace           bool
+}
+
+func (s placedNamespacesScorer) score(ns *corev1.Namespace, candidates []*workloadv1alpha1.SyncTarget) (map[string]int64, error) {
+	clusterName := logicalcluster.From(ns)
+
+	counts := make(map[string]int64, len(candidates))
+	var total int64
+	for _, cluster := range candidates {
+		// TODO(sttts): use sync-target-uid instead of sync-target-name in the state label
+		namespaces, err := s.listScheduledNamespaces(cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		var count int64
+		for _, scheduled := range namespaces {
+			if s.sameWorkspace && logicalcluster.From(scheduled) != clusterName {
+				continue
+			}
+			if scheduled.Labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+cluster.Name] != string(workloadv1alpha1.ResourceStateSync) {
+				continue
+			}
+			if _, found := scheduled.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+cluster.Name]; found {
+				continue
+			}
+			count++
+		}
+		counts[cluster.Name] = count
+		total += count
+	}
+
+	scores := make(map[string]int64, len(candidates))
+	for _, cluster := range candidates {
+		if total == 0 {
+			scores[cluster.Name] = maxScore
+			continue
+		}
+		scores[cluster.Name] = maxScore * (total - counts[cluster.Name]) / total
+	}
+	return scores, nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestAllocatableScorer(t *testing.T) {
+	testCases := []struct {
+		name        string
+		syncTargets []*workloadv1alpha1.SyncTarget
+
+		expectedScores map[string]int64
+	}{
+		{
+			name: "no sync target reports allocatable resources",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1", nil, corev1.ConditionTrue),
+				newSyncTarget("c2", nil, corev1.ConditionTrue),
+			},
+			expectedScores: map[string]int64{"c1": 0, "c2": 0},
+		},
+		{
+			name: "score relative to the sync target with the most allocatable resources",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newAllocatableSyncTarget("c1", "4", "8Gi"),
+				newAllocatableSyncTarget("c2", "2", "8Gi"),
+				newAllocatableSyncTarget("c3", "1", "2Gi"),
+			},
+			expectedScores: map[string]int64{"c1": 100, "c2": 75, "c3": 25},
+		},
+		{
+			name: "sync targets not reporting allocatable resources get the lowest score",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newAllocatableSyncTarget("c1", "1", "1Gi"),
+				newSyncTarget("c2", nil, corev1.ConditionTrue),
+			},
+			expectedScores: map[string]int64{"c1": 100, "c2": 0},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scores, err := allocatableScorer{}.score(&corev1.Namespace{}, testCase.syncTargets)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedScores, scores)
+		})
+	}
+}
+
+func TestPlacedNamespacesScorer(t *testing.T) {
+	syncLabel := func(syncTarget string) map[string]string {
+		return map[string]string{workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTarget: string(workloadv1alpha1.ResourceStateSync)}
+	}
+
+	scheduled := map[string][]*corev1.Namespace{
+		"c1": {
+			newScheduledNamespace("root:org:ws", "ns1", syncLabel("c1"), nil),
+			newScheduledNamespace("root:org:other", "ns2", syncLabel("c1"), nil),
+			newScheduledNamespace("root:org:other", "ns3", syncLabel("c1"), nil),
+		},
+		"c2": {
+			newScheduledNamespace("root:org:ws", "ns4", syncLabel("c2"), nil),
+			newScheduledNamespace("root:org:ws", "ns5", syncLabel("c2"), nil),
+			newScheduledNamespace("root:org:ws", "ns6", syncLabel("c2"), map[string]string{
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "c2": "2022-07-15T00:00:00Z",
+			}),
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		sameWorkspace bool
+		syncTargets   []string
+
+		expectedScores map[string]int64
+	}{
+		{
+			name:           "no namespace is scheduled",
+			syncTargets:    []string{"c3", "c4"},
+			expectedScores: map[string]int64{"c3": 100, "c4": 100},
+		},
+		{
+			name:           "prefer the sync target with the fewest namespaces, ignoring removing namespaces",
+			syncTargets:    []string{"c1", "c2", "c3"},
+			expectedScores: map[string]int64{"c1": 40, "c2": 60, "c3": 100},
+		},
+		{
+			name:           "only count the namespaces of the same workspace",
+			sameWorkspace:  true,
+			syncTargets:    []string{"c1", "c2"},
+			expectedScores: map[string]int64{"c1": 66, "c2": 33},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var syncTargets []*workloadv1alpha1.SyncTarget
+			for _, name := range testCase.syncTargets {
+				syncTargets = append(syncTargets, newSyncTarget(name, nil, corev1.ConditionTrue))
+			}
+
+			scorer := placedNamespacesScorer{
+				listScheduledNamespaces: func(syncTargetName string) ([]*corev1.Namespace, error) {
+					return scheduled[syncTargetName], nil
+				},
+				sameWorkspace: testCase.sameWorkspace,
+			}
+
+			scores, err := scorer.score(newScheduledNamespace("root:org:ws", "test", nil, nil), syncTargets)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedScores, scores)
+		})
+	}
+}
+
+func TestScheduleByScore(t *testing.T) {
+	testCases := []struct {
+		name         string
+		spreadPolicy schedulingv1alpha1.SpreadPolicy
+		syncTargets  []*workloadv1alpha1.SyncTarget
+		scheduled    map[string]int
+
+		expectedCluster string
+	}{
+		{
+			name: "schedule to the sync target with the most allocatable resources",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newAllocatableSyncTarget("c1", "2", "4Gi"),
+				newAllocatableSyncTarget("c2", "8", "16Gi"),
+			},
+			expectedCluster: "c2",
+		},
+		{
+			name: "schedule to the sync target with the fewest namespaces",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1", nil, corev1.ConditionTrue),
+				newSyncTarget("c2", nil, corev1.ConditionTrue),
+			},
+			scheduled:       map[string]int{"c1": 1, "c2": 3},
+			expectedCluster: "c1",
+		},
+		{
+			name: "placed namespaces outweigh allocatable resources with the workspace spread policy",
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newAllocatableSyncTarget("c1", "4", "8Gi"),
+				newAllocatableSyncTarget("c2", "2", "4Gi"),
+			},
+			spreadPolicy:    schedulingv1alpha1.SpreadPolicyWorkspace,
+			scheduled:       map[string]int{"c1": 3, "c2": 1},
+			expectedCluster: "c2",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			reconciler := &placementSchedulingReconciler{
+				listScheduledNamespaces: func(syncTargetName string) ([]*corev1.Namespace, error) {
+					var ret []*corev1.Namespace
+					for i := 0; i < testCase.scheduled[syncTargetName]; i++ {
+						ret = append(ret, newScheduledNamespace("root:org:ws", "ns", map[string]string{
+							workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetName: string(workloadv1alpha1.ResourceStateSync),
+						}, nil))
+					}
+					return ret, nil
+				},
+			}
+
+			placement := newPlacement("test-placement", "test-location")
+			if testCase.spreadPolicy != "" {
+				placement.Annotations = map[string]string{
+					schedulingv1alpha1.ExperimentalSpreadPolicyAnnotationKey: string(testCase.spreadPolicy),
+				}
+			}
+
+			l := newLocationClusters(testCase.syncTargets, nil, 1, reconciler.scorersForPlacement(placement))
+			chosen, err := l.schedule(newScheduledNamespace("root:org:ws", "test", nil, nil))
+			require.NoError(t, err)
+			require.Len(t, chosen, 1)
+			require.Equal(t, testCase.expectedCluster, chosen[0].Name)
+		})
+	}
+}
+
+func newAllocatableSyncTarget(name, cpu, memory string) *workloadv1alpha1.SyncTarget {
+	syncTarget := newSyncTarget(name, nil, corev1.ConditionTrue)
+	syncTarget.Status.Allocatable = &corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+	return syncTarget
+}
+
+func newScheduledNamespace(clusterName, name string, labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+			ClusterName: clusterName,
+		},
+	}
+}
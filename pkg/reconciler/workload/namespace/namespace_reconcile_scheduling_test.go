@@ -121,6 +121,75 @@ func TestScheduling(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "schedule all synctargets in All mode",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			placement: newSyncTargetsPlacement(schedulingv1alpha1.SyncTargetSelectionAll, 0),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionTrue),
+				newSyncTarget("test-cluster-1", nil, corev1.ConditionTrue),
+				newSyncTarget("test-cluster-2", nil, corev1.ConditionFalse),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster":   string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "schedule additional synctargets up to the replicas",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newSyncTargetsPlacement(schedulingv1alpha1.SyncTargetSelectionReplicas, 3),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionTrue),
+				newSyncTarget("test-cluster-1", nil, corev1.ConditionTrue),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster":   string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "no update when enough synctargets are scheduled",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster":   string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newSyncTargetsPlacement(schedulingv1alpha1.SyncTargetSelectionReplicas, 2),
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionTrue),
+				newSyncTarget("test-cluster-1", nil, corev1.ConditionTrue),
+				newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster":   string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "no update when synctargets is scheduled",
 			annotations: map[string]string{
@@ -213,6 +282,77 @@ func TestScheduling(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-1": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "evicting synctarget stays scheduled until the eviction starts",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newEvictingSyncTarget("test-cluster", now),
+				newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue),
+			},
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "select a new synctarget without removing the evicting synctarget when the eviction started",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                 "",
+				workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + "test-cluster": now3339,
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newEvictingSyncTarget("test-cluster", now),
+				newSyncTarget("test-cluster-2", nil, corev1.ConditionTrue),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                 "",
+				workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + "test-cluster": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster":   string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster-2": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "evicting synctarget becomes not ready",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                 "",
+				workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + "test-cluster": now3339,
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: testPlacement,
+			location:  testLocation,
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("test-cluster", nil, corev1.ConditionFalse),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                          "",
+				workloadv1alpha1.InternalClusterEvictionAnnotationPrefix + "test-cluster":          now3339,
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "test-cluster": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "test-cluster": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "remove clusters which is removing after grace period",
 			annotations: map[string]string{
@@ -272,6 +412,8 @@ func TestScheduling(t *testing.T) {
 				patchNamespace: patchNamespaceFunc(&patched, ns),
 				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
 				now:            func() time.Time { return now },
+
+				listScheduledNamespaces: func(string) ([]*corev1.Namespace, error) { return nil, nil },
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -460,6 +602,8 @@ func TestMultiplePlacements(t *testing.T) {
 				patchNamespace: patchNamespaceFunc(&patched, ns),
 				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
 				now:            func() time.Time { return now },
+
+				listScheduledNamespaces: func(string) ([]*corev1.Namespace, error) { return nil, nil },
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -488,6 +632,22 @@ func newSyncTarget(name string, labels map[string]string, status corev1.Conditio
 	}
 }
 
+func newEvictingSyncTarget(name string, now time.Time) *workloadv1alpha1.SyncTarget {
+	syncTarget := newSyncTarget(name, nil, corev1.ConditionTrue)
+	syncTarget.Spec.Unschedulable = true
+	syncTarget.Spec.EvictAfter = &metav1.Time{Time: now.Add(-time.Minute)}
+	return syncTarget
+}
+
+func newSyncTargetsPlacement(mode schedulingv1alpha1.SyncTargetSelectionMode, replicas int32) *schedulingv1alpha1.Placement {
+	placement := newPlacement("test-placement", "test-location")
+	placement.Spec.SyncTargets = &schedulingv1alpha1.SyncTargetSelection{
+		Mode:     mode,
+		Replicas: replicas,
+	}
+	return placement
+}
+
 func newLocation(name string, selector map[string]string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
@@ -74,34 +74,45 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 		annotationPatch = propagateDeletionTimestamp(obj, annotationPatch)
 	}
 
-	// create patch
-	if len(labelPatch) == 0 && len(annotationPatch) == 0 {
-		return nil
+	if *gvr == deploymentsGVR && obj.GetDeletionTimestamp() == nil {
+		replicasPatch, err := computeReplicasSpecDiffs(ns, obj)
+		if err != nil {
+			return fmt.Errorf("error dividing the replicas of %s|%s/%s: %w", lclusterName, obj.GetNamespace(), obj.GetName(), err)
+		}
+		if len(replicasPatch) > 0 && annotationPatch == nil {
+			annotationPatch = map[string]interface{}{}
+		}
+		for k, v := range replicasPatch {
+			annotationPatch[k] = v
+		}
 	}
 
-	patch := map[string]interface{}{}
-	if len(labelPatch) > 0 {
-		if err := unstructured.SetNestedField(patch, labelPatch, "metadata", "labels"); err != nil {
-			klog.Errorf("unexpected unstructured error: %v", err)
-			return err // should never happen
+	// create patch
+	if len(labelPatch) > 0 || len(annotationPatch) > 0 {
+		patch := map[string]interface{}{}
+		if len(labelPatch) > 0 {
+			if err := unstructured.SetNestedField(patch, labelPatch, "metadata", "labels"); err != nil {
+				klog.Errorf("unexpected unstructured error: %v", err)
+				return err // should never happen
+			}
 		}
-	}
-	if len(annotationPatch) > 0 {
-		if err := unstructured.SetNestedField(patch, annotationPatch, "metadata", "annotations"); err != nil {
-			klog.Errorf("unexpected unstructured error: %v", err)
-			return err // should never happen
+		if len(annotationPatch) > 0 {
+			if err := unstructured.SetNestedField(patch, annotationPatch, "metadata", "annotations"); err != nil {
+				klog.Errorf("unexpected unstructured error: %v", err)
+				return err // should never happen
+			}
+		}
+		patchBytes, err := json.Marshal(patch)
+		if err != nil {
+			klog.Errorf("unexpected marshal error: %v", err)
+			return err
 		}
-	}
-	patchBytes, err := json.Marshal(patch)
-	if err != nil {
-		klog.Errorf("unexpected marshal error: %v", err)
-		return err
-	}
 
-	klog.V(2).Infof("Patching %q %s|%s/%s: %s", gvr, lclusterName, ns.Name, obj.GetName(), string(patchBytes))
-	if _, err := c.dynClient.Cluster(lclusterName).Resource(*gvr).Namespace(ns.Name).
-		Patch(ctx, obj.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
-		return err
+		klog.V(2).Infof("Patching %q %s|%s/%s: %s", gvr, lclusterName, ns.Name, obj.GetName(), string(patchBytes))
+		if _, err := c.dynClient.Cluster(lclusterName).Resource(*gvr).Namespace(ns.Name).
+			Patch(ctx, obj.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+			return err
+		}
 	}
 
 	return nil
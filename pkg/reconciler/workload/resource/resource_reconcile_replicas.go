@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+var deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+// activeLocations returns the sync targets the namespace is synced to, without the ones it is removed from.
+func activeLocations(ns *corev1.Namespace) sets.String {
+	active := sets.NewString()
+	for k, v := range ns.Labels {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) || v != string(workloadv1alpha1.ResourceStateSync) {
+			continue
+		}
+		loc := strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix)
+		if _, found := ns.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]; found {
+			continue
+		}
+		active.Insert(loc)
+	}
+	return active
+}
+
+type replicasSpecDiff struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value int64  `json:"value"`
+}
+
+// isReplicasSpecDiff returns true if the spec diff only replaces the replicas, i.e. if it is
+// managed by computeReplicasSpecDiffs.
+func isReplicasSpecDiff(value string) bool {
+	var diff []replicasSpecDiff
+	if err := json.Unmarshal([]byte(value), &diff); err != nil {
+		return false
+	}
+	return len(diff) == 1 && diff[0].Op == "replace" && diff[0].Path == "/replicas"
+}
+
+// computeReplicasSpecDiffs divides the replicas of a deployment among the sync targets its namespace is
+// synced to, by setting spec diff annotations replacing the replicas on each sync target. The remainder
+// of the division goes to the first sync targets in alphabetical order. Spec diffs set by users, i.e. not
+// only replacing the replicas, are left untouched. Nil means to remove the key.
+func computeReplicasSpecDiffs(ns *corev1.Namespace, obj *unstructured.Unstructured) (map[string]interface{}, error) {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		replicas = 1
+	}
+
+	active := activeLocations(ns)
+	annotations := obj.GetAnnotations()
+
+	patch := map[string]interface{}{}
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterSpecDiffAnnotationPrefix) || !isReplicasSpecDiff(v) {
+			continue
+		}
+		if loc := strings.TrimPrefix(k, workloadv1alpha1.ClusterSpecDiffAnnotationPrefix); active.Len() <= 1 || !active.Has(loc) {
+			patch[k] = nil
+		}
+	}
+
+	if active.Len() > 1 {
+		locs := active.List()
+		for i, loc := range locs {
+			share := replicas / int64(len(locs))
+			if int64(i) < replicas%int64(len(locs)) {
+				share++
+			}
+
+			key := workloadv1alpha1.ClusterSpecDiffAnnotationPrefix + loc
+			existing, found := annotations[key]
+			if found && !isReplicasSpecDiff(existing) {
+				continue
+			}
+			value, err := json.Marshal([]replicasSpecDiff{{Op: "replace", Path: "/replicas", Value: share}})
+			if err != nil {
+				return nil, err
+			}
+			if existing != string(value) {
+				patch[key] = string(value)
+			}
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil, nil
+	}
+	return patch, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func deployment(replicas *int64, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("ns")
+	obj.SetName("test")
+	obj.SetAnnotations(annotations)
+	if replicas != nil {
+		_ = unstructured.SetNestedField(obj.Object, *replicas, "spec", "replicas")
+	}
+	return obj
+}
+
+func TestComputeReplicasSpecDiffs(t *testing.T) {
+	five := int64(5)
+	sync := string(workloadv1alpha1.ResourceStateSync)
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix
+	specDiff := workloadv1alpha1.ClusterSpecDiffAnnotationPrefix
+
+	tests := []struct {
+		name        string
+		nsLabels    map[string]string
+		nsAnnots    map[string]string
+		replicas    *int64
+		annotations map[string]string
+		want        map[string]interface{}
+	}{
+		{name: "single sync target",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync},
+			replicas: &five,
+		},
+		{name: "divide the replicas, the remainder going to the first sync targets",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync},
+			replicas: &five,
+			want: map[string]interface{}{
+				specDiff + "cluster-1": `[{"op":"replace","path":"/replicas","value":3}]`,
+				specDiff + "cluster-2": `[{"op":"replace","path":"/replicas","value":2}]`,
+			},
+		},
+		{name: "default to one replica",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync},
+			want: map[string]interface{}{
+				specDiff + "cluster-1": `[{"op":"replace","path":"/replicas","value":1}]`,
+				specDiff + "cluster-2": `[{"op":"replace","path":"/replicas","value":0}]`,
+			},
+		},
+		{name: "already divided",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync},
+			replicas: &five,
+			annotations: map[string]string{
+				specDiff + "cluster-1": `[{"op":"replace","path":"/replicas","value":3}]`,
+				specDiff + "cluster-2": `[{"op":"replace","path":"/replicas","value":2}]`,
+			},
+		},
+		{name: "sync targets being removed are ignored, and user spec diffs are kept",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync, stateLabel + "cluster-3": sync},
+			nsAnnots: map[string]string{workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "cluster-3": "2002-10-02T10:00:00-05:00"},
+			replicas: &five,
+			annotations: map[string]string{
+				specDiff + "cluster-1": `[{"op":"replace","path":"/paused","value":true}]`,
+				specDiff + "cluster-3": `[{"op":"replace","path":"/replicas","value":1}]`,
+			},
+			want: map[string]interface{}{
+				specDiff + "cluster-2": `[{"op":"replace","path":"/replicas","value":2}]`,
+				specDiff + "cluster-3": nil,
+			},
+		},
+		{name: "remove the spec diffs when back to a single sync target",
+			nsLabels: map[string]string{stateLabel + "cluster-1": sync},
+			replicas: &five,
+			annotations: map[string]string{
+				specDiff + "cluster-1": `[{"op":"replace","path":"/replicas","value":3}]`,
+				specDiff + "cluster-2": `[{"op":"replace","path":"/replicas","value":2}]`,
+			},
+			want: map[string]interface{}{
+				specDiff + "cluster-1": nil,
+				specDiff + "cluster-2": nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeReplicasSpecDiffs(namespace(tt.nsAnnots, tt.nsLabels), deployment(tt.replicas, tt.annotations))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
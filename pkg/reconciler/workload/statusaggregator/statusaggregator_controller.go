@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const controllerName = "kcp-workload-status-aggregator"
+
+// NewController returns a new controller merging the statuses written by the syncers of the SyncTargets
+// into the experimental.status.workload.kcp.dev/<sync-target-name> annotations into the status of the
+// upstream objects. The aggregators are looked up by group resource, falling back to GenericAggregator.
+func NewController(
+	dynamicClusterClient dynamic.ClusterInterface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	aggregators map[schema.GroupResource]Aggregator,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+
+		dynClient: dynamicClusterClient,
+
+		ddsif: ddsif,
+
+		aggregators: aggregators,
+	}
+
+	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueue(gvr, obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueue(gvr, obj) },
+		DeleteFunc: nil, // Nothing to do.
+	})
+
+	return c, nil
+}
+
+// controller
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	dynClient dynamic.ClusterInterface
+
+	ddsif *informer.DynamicDiscoverySharedInformerFactory
+
+	aggregators map[schema.GroupResource]Aggregator
+}
+
+func (c *controller) enqueue(gvr schema.GroupVersionResource, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || !hasStatusAnnotations(u) {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	gvrstr := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".")
+	klog.V(4).Infof("Queueing %s %s", gvrstr, key)
+	c.queue.Add(gvrstr + "::" + key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// key is gvr::KEY
+func (c *controller) process(ctx context.Context, key string) error {
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		klog.Errorf("Error parsing key %q; dropping", key)
+		return nil
+	}
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		klog.Errorf("Error parsing GVR %q; dropping", parts[0])
+		return nil
+	}
+	key = parts[1]
+
+	inf, err := c.ddsif.InformerForResource(*gvr)
+	if err != nil {
+		return err
+	}
+	obj, exists, err := inf.Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		klog.V(3).Infof("object %q GVR %q does not exist", key, gvr)
+		return nil
+	}
+	old, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		klog.Errorf("object was not Unstructured, dropping: %T", obj)
+		return nil
+	}
+	u := old.DeepCopy()
+
+	aggregator, found := c.aggregators[gvr.GroupResource()]
+	if !found {
+		aggregator = GenericAggregator
+	}
+
+	changed, err := reconcile(u, aggregator)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		klog.Errorf("invalid key: %q: %v", key, err)
+		return nil
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	oldData, err := json.Marshal(map[string]interface{}{
+		"status": old.Object["status"],
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for %s %s|%s/%s: %w", gvr, clusterName, namespace, name, err)
+	}
+	newData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"uid":             old.GetUID(),
+			"resourceVersion": old.GetResourceVersion(),
+		}, // to ensure they appear in the patch as preconditions
+		"status": u.Object["status"],
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for %s %s|%s/%s: %w", gvr, clusterName, namespace, name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for %s %s|%s/%s: %w", gvr, clusterName, namespace, name, err)
+	}
+
+	klog.V(2).Infof("Patching status of %s %s|%s/%s with patch %s", gvr, clusterName, namespace, name, string(patchBytes))
+	_, err = c.dynClient.Cluster(clusterName).Resource(*gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
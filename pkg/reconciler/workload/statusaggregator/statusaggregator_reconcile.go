@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregator
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// syncTargetStatuses returns the statuses of the object on the sync targets it is synced to, keyed by sync
+// target name. The sync targets the object is being removed from are ignored.
+func syncTargetStatuses(obj *unstructured.Unstructured) (map[string]map[string]interface{}, error) {
+	statuses := map[string]map[string]interface{}{}
+	annotations := obj.GetAnnotations()
+	for k, v := range obj.GetLabels() {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) || v != string(workloadv1alpha1.ResourceStateSync) {
+			continue
+		}
+		syncTarget := strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix)
+		if _, found := annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTarget]; found {
+			continue
+		}
+		value, found := annotations[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+syncTarget]
+		if !found {
+			continue
+		}
+
+		var status map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &status); err != nil {
+			return nil, fmt.Errorf("invalid status annotation for sync target %q: %w", syncTarget, err)
+		}
+		statuses[syncTarget] = status
+	}
+	return statuses, nil
+}
+
+// hasStatusAnnotations returns true if the object has the status annotation of any sync target.
+func hasStatusAnnotations(obj *unstructured.Unstructured) bool {
+	for k := range obj.GetAnnotations() {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcile sets the status of the object to the aggregation of its statuses on the sync targets. It
+// returns true if the status changed. Objects without any status reported by a sync target are left
+// untouched.
+func reconcile(obj *unstructured.Unstructured, aggregator Aggregator) (bool, error) {
+	statuses, err := syncTargetStatuses(obj)
+	if err != nil {
+		return false, err
+	}
+	if len(statuses) == 0 {
+		return false, nil
+	}
+
+	status, err := aggregator.Aggregate(statuses)
+	if err != nil {
+		return false, err
+	}
+	if status == nil {
+		return false, nil
+	}
+
+	existing, _, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return false, err
+	}
+	if equality.Semantic.DeepEqual(existing, status) {
+		return false, nil
+	}
+
+	if err := unstructured.SetNestedMap(obj.Object, status, "status"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	sync := string(workloadv1alpha1.ResourceStateSync)
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix
+	statusAnnotation := workloadv1alpha1.InternalClusterStatusAnnotationPrefix
+	deletionAnnotation := workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		status      map[string]interface{}
+
+		wantChanged bool
+		wantStatus  map[string]interface{}
+		wantErr     bool
+	}{
+		{name: "no status reported",
+			labels: map[string]string{stateLabel + "cluster-1": sync},
+		},
+		{name: "aggregate the statuses of the synced sync targets only",
+			labels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync, stateLabel + "cluster-3": sync, stateLabel + "cluster-4": ""},
+			annotations: map[string]string{
+				statusAnnotation + "cluster-1":   `{"count":1}`,
+				statusAnnotation + "cluster-2":   `{"count":2}`,
+				statusAnnotation + "cluster-3":   `{"count":4}`,
+				deletionAnnotation + "cluster-3": "2002-10-02T10:00:00-05:00",
+				statusAnnotation + "cluster-4":   `{"count":8}`,
+				statusAnnotation + "cluster-5":   `{"count":16}`,
+			},
+			wantChanged: true,
+			wantStatus:  map[string]interface{}{"count": int64(3)},
+		},
+		{name: "status already aggregated",
+			labels: map[string]string{stateLabel + "cluster-1": sync, stateLabel + "cluster-2": sync},
+			annotations: map[string]string{
+				statusAnnotation + "cluster-1": `{"count":1}`,
+				statusAnnotation + "cluster-2": `{"count":2}`,
+			},
+			status:     map[string]interface{}{"count": int64(3)},
+			wantStatus: map[string]interface{}{"count": int64(3)},
+		},
+		{name: "invalid status annotation",
+			labels: map[string]string{stateLabel + "cluster-1": sync},
+			annotations: map[string]string{
+				statusAnnotation + "cluster-1": `{`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetLabels(tt.labels)
+			obj.SetAnnotations(tt.annotations)
+			if tt.status != nil {
+				require.NoError(t, unstructured.SetNestedMap(obj.Object, tt.status, "status"))
+			}
+
+			changed, err := reconcile(obj, GenericAggregator)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantChanged, changed)
+
+			status, _, err := unstructured.NestedMap(obj.Object, "status")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregator
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Aggregator merges the statuses of an object on its sync targets, keyed by sync target name, into
+// the status of the upstream object.
+type Aggregator interface {
+	Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error)
+}
+
+// AggregatorFunc is an Aggregator implemented by a function.
+type AggregatorFunc func(statuses map[string]map[string]interface{}) (map[string]interface{}, error)
+
+func (f AggregatorFunc) Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	return f(statuses)
+}
+
+// DefaultAggregators returns the built-in aggregators of the well-known resources. Other resources are
+// aggregated with GenericAggregator.
+func DefaultAggregators() map[schema.GroupResource]Aggregator {
+	return map[schema.GroupResource]Aggregator{
+		{Group: "apps", Resource: "deployments"}: &CountersAndConditionsAggregator{
+			Counters: []string{"replicas", "updatedReplicas", "readyReplicas", "availableReplicas", "unavailableReplicas"},
+		},
+		{Group: "apps", Resource: "statefulsets"}: &CountersAndConditionsAggregator{
+			Counters: []string{"replicas", "readyReplicas", "currentReplicas", "updatedReplicas", "availableReplicas"},
+		},
+		{Group: "", Resource: "services"}:                   AggregatorFunc(aggregateLoadBalancer),
+		{Group: "networking.k8s.io", Resource: "ingresses"}: AggregatorFunc(aggregateLoadBalancer),
+	}
+}
+
+// GenericAggregator is the aggregator of the resources without a built-in aggregator. It sums up all the
+// top-level integer fields and ANDs the conditions.
+var GenericAggregator Aggregator = &CountersAndConditionsAggregator{}
+
+// CountersAndConditionsAggregator sums up the counters over the sync targets, takes the lowest
+// observedGeneration, and ANDs the conditions: a condition is True only if it is True on every sync
+// target. The other fields are taken from the first sync target in alphabetical order.
+type CountersAndConditionsAggregator struct {
+	// Counters are the top-level integer fields summed up. If empty, all the top-level integer fields
+	// but observedGeneration are summed up.
+	Counters []string
+}
+
+func (a *CountersAndConditionsAggregator) Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	names := sortedNames(statuses)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ret := runtime.DeepCopyJSON(statuses[names[0]])
+
+	counters := a.Counters
+	if len(counters) == 0 {
+		for k, v := range ret {
+			if _, ok := v.(int64); ok && k != "observedGeneration" {
+				counters = append(counters, k)
+			}
+		}
+	}
+	for _, counter := range counters {
+		var sum int64
+		var found bool
+		for _, name := range names {
+			if n, ok := statuses[name][counter].(int64); ok {
+				sum += n
+				found = true
+			}
+		}
+		if found {
+			ret[counter] = sum
+		}
+	}
+
+	if _, found := ret["observedGeneration"]; found {
+		min, _ := ret["observedGeneration"].(int64)
+		for _, name := range names {
+			if n, ok := statuses[name]["observedGeneration"].(int64); ok && n < min {
+				min = n
+			}
+		}
+		ret["observedGeneration"] = min
+	}
+
+	if conditions := aggregateConditions(names, statuses); conditions != nil {
+		ret["conditions"] = conditions
+	}
+
+	return ret, nil
+}
+
+// aggregateConditions ANDs the conditions of the sync targets, by type, in the order of the first sync
+// target reporting them. A condition is True if it is True on all the sync targets reporting it, False
+// if it is False on any of them, and Unknown otherwise. The reason and message are those of the first
+// sync target deciding the status.
+func aggregateConditions(names []string, statuses map[string]map[string]interface{}) []interface{} {
+	var types []string
+	byType := map[string]map[string]interface{}{}
+	for _, name := range names {
+		conditions, ok := statuses[name]["conditions"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditionType, ok := condition["type"].(string)
+			if !ok {
+				continue
+			}
+
+			existing, found := byType[conditionType]
+			if !found {
+				types = append(types, conditionType)
+				byType[conditionType] = condition
+				continue
+			}
+			if conditionRank(condition) > conditionRank(existing) {
+				byType[conditionType] = condition
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	ret := make([]interface{}, 0, len(types))
+	for _, conditionType := range types {
+		ret = append(ret, runtime.DeepCopyJSONValue(byType[conditionType]))
+	}
+	return ret
+}
+
+// conditionRank orders the condition statuses by precedence when ANDing them.
+func conditionRank(condition map[string]interface{}) int {
+	switch condition["status"] {
+	case "True":
+		return 0
+	case "False":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// aggregateLoadBalancer merges the load balancer ingress points of the sync targets, without duplicates.
+// The conditions are ANDed.
+func aggregateLoadBalancer(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	names := sortedNames(statuses)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ret := runtime.DeepCopyJSON(statuses[names[0]])
+
+	var ingresses []interface{}
+	for _, name := range names {
+		loadBalancer, ok := statuses[name]["loadBalancer"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		points, ok := loadBalancer["ingress"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, point := range points {
+			if !containsValue(ingresses, point) {
+				ingresses = append(ingresses, runtime.DeepCopyJSONValue(point))
+			}
+		}
+	}
+	if len(ingresses) > 0 {
+		ret["loadBalancer"] = map[string]interface{}{"ingress": ingresses}
+	}
+
+	if conditions := aggregateConditions(names, statuses); conditions != nil {
+		ret["conditions"] = conditions
+	}
+
+	return ret, nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if equality.Semantic.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(statuses map[string]map[string]interface{}) []string {
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+func TestAggregators(t *testing.T) {
+	tests := []struct {
+		name          string
+		groupResource schema.GroupResource
+		statuses      map[string]string
+		want          string
+	}{
+		{name: "deployments sum up the replicas and AND the conditions",
+			groupResource: schema.GroupResource{Group: "apps", Resource: "deployments"},
+			statuses: map[string]string{
+				"cluster-1": `{"observedGeneration":3,"replicas":2,"readyReplicas":2,"availableReplicas":2,"collisionCount":1,
+					"conditions":[{"type":"Available","status":"True","reason":"MinimumReplicasAvailable"}]}`,
+				"cluster-2": `{"observedGeneration":2,"replicas":3,"readyReplicas":1,"availableReplicas":1,"unavailableReplicas":2,"collisionCount":1,
+					"conditions":[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable"},{"type":"Progressing","status":"True"}]}`,
+			},
+			want: `{"observedGeneration":2,"replicas":5,"readyReplicas":3,"availableReplicas":3,"unavailableReplicas":2,"collisionCount":1,
+				"conditions":[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable"},{"type":"Progressing","status":"True"}]}`,
+		},
+		{name: "statefulsets sum up the replicas and keep the revisions of the first sync target",
+			groupResource: schema.GroupResource{Group: "apps", Resource: "statefulsets"},
+			statuses: map[string]string{
+				"cluster-2": `{"replicas":1,"currentReplicas":1,"currentRevision":"web-2"}`,
+				"cluster-1": `{"replicas":2,"currentReplicas":1,"currentRevision":"web-1"}`,
+			},
+			want: `{"replicas":3,"currentReplicas":2,"currentRevision":"web-1"}`,
+		},
+		{name: "services merge the load balancer ingress points",
+			groupResource: schema.GroupResource{Resource: "services"},
+			statuses: map[string]string{
+				"cluster-1": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"},{"hostname":"lb.example.com"}]}}`,
+				"cluster-2": `{"loadBalancer":{"ingress":[{"hostname":"lb.example.com"},{"ip":"10.0.0.2"}]}}`,
+				"cluster-3": `{"loadBalancer":{}}`,
+			},
+			want: `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"},{"hostname":"lb.example.com"},{"ip":"10.0.0.2"}]}}`,
+		},
+		{name: "ingresses merge the load balancer ingress points",
+			groupResource: schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
+			statuses: map[string]string{
+				"cluster-1": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]}}`,
+				"cluster-2": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.2"}]}}`,
+			},
+			want: `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}]}}`,
+		},
+		{name: "other resources sum up the counters and AND the conditions",
+			groupResource: schema.GroupResource{Group: "example.com", Resource: "widgets"},
+			statuses: map[string]string{
+				"cluster-1": `{"observedGeneration":1,"count":1,"phase":"Running","conditions":[{"type":"Ready","status":"True"}]}`,
+				"cluster-2": `{"observedGeneration":1,"count":4,"phase":"Pending","conditions":[{"type":"Ready","status":"Unknown"}]}`,
+			},
+			want: `{"observedGeneration":1,"count":5,"phase":"Running","conditions":[{"type":"Ready","status":"Unknown"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := map[string]map[string]interface{}{}
+			for name, status := range tt.statuses {
+				var s map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(status), &s))
+				statuses[name] = s
+			}
+			var want map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.want), &want))
+
+			aggregator, found := DefaultAggregators()[tt.groupResource]
+			if !found {
+				aggregator = GenericAggregator
+			}
+			got, err := aggregator.Aggregate(statuses)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
@@ -70,9 +70,11 @@ import (
 	workloadsapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	workloadsapiexportcreate "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexportcreate"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/defaultplacement"
+	workloadeviction "github.com/kcp-dev/kcp/pkg/reconciler/workload/eviction"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	workloadresource "github.com/kcp-dev/kcp/pkg/reconciler/workload/resource"
+	workloadstatusaggregator "github.com/kcp-dev/kcp/pkg/reconciler/workload/statusaggregator"
 	virtualworkspaceurlscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/virtualworkspaceurls"
 )
 
@@ -361,6 +363,35 @@ func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *r
 	return nil
 }
 
+func (s *Server) installWorkloadStatusAggregator(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workload-status-aggregator")
+	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	statusAggregator, err := workloadstatusaggregator.NewController(
+		dynamicClusterClient,
+		ddsif,
+		workloadstatusaggregator.DefaultAggregators(),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.AddPostStartHook("kcp-install-workload-status-aggregator", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workload-status-aggregator: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go statusAggregator.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
 func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config) error {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-scheduler")
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
@@ -837,6 +868,48 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 	return nil
 }
 
+func (s *Server) installWorkloadEvictionController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-workload-eviction"
+	config = rest.AddUserAgent(rest.CopyConfig(config), controllerName)
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := workloadeviction.NewController(
+		kubeClusterClient,
+		kcpClusterClient,
+		s.dynamicDiscoverySharedInformerFactory,
+		s.kubeSharedInformerFactory.Core().V1().Namespaces(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.kcpSharedInformerFactory.Scheduling().V1alpha1().Placements(),
+		s.options.Controllers.SyncTargetEviction.MaxUnavailableNamespaces,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook(controllerName, func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook %s: %v", controllerName, err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Server) installSchedulingPlacementController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	controllerName := "kcp-scheduling-placement-controller"
 	config = rest.AddUserAgent(rest.CopyConfig(config), controllerName)
@@ -274,6 +274,7 @@ func (s *Server) Run(ctx context.Context) error {
 		kcpadmissioninitializers.NewKubeClusterClientInitializer(kubeClusterClient),
 		kcpadmissioninitializers.NewKcpClusterClientInitializer(kcpClusterClient),
 		kcpadmissioninitializers.NewShardBaseURLInitializer(s.options.Extra.ShardBaseURL),
+		kcpadmissioninitializers.NewShardVirtualWorkspaceURLInitializer(s.options.Virtual.ExternalVirtualWorkspaceAddress),
 		// The external address is provided as a function, as its value may be updated
 		// with the default secure port, when the config is later completed.
 		kcpadmissioninitializers.NewExternalAddressInitializer(func() string { return genericConfig.ExternalAddress }),
@@ -544,6 +545,12 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.options.Controllers.EnableAll || enabled.Has("status-aggregator") {
+		if err := s.installWorkloadStatusAggregator(ctx, controllerConfig, s.dynamicDiscoverySharedInformerFactory); err != nil {
+			return err
+		}
+	}
+
 	if s.options.Controllers.EnableAll || enabled.Has("apibinding") {
 		if err := s.installAPIBindingController(ctx, controllerConfig, server, s.dynamicDiscoverySharedInformerFactory); err != nil {
 			return err
@@ -561,6 +568,9 @@ func (s *Server) Run(ctx context.Context) error {
 			if err := s.installWorkloadNamespaceScheduler(ctx, controllerConfig, server); err != nil {
 				return err
 			}
+			if err := s.installWorkloadEvictionController(ctx, controllerConfig, server); err != nil {
+				return err
+			}
 			if err := s.installSchedulingLocationStatusController(ctx, controllerConfig, server); err != nil {
 				return err
 			}
@@ -40,6 +40,7 @@ import (
 	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/crdpuller"
 	clusterctl "github.com/kcp-dev/kcp/pkg/reconciler/workload/basecontroller"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
 )
 
 var clusterKind = reflect.TypeOf(workloadv1alpha1.SyncTarget{}).Name()
@@ -169,6 +170,7 @@ func (i *APIImporter) Stop() {
 func (i *APIImporter) ImportAPIs(ctx context.Context) {
 	klog.Infof("Importing APIs from location %s in logical cluster %s (resources=%v)", i.location, i.logicalClusterName, i.resourcesToSync)
 	crds, err := i.schemaPuller.PullCRDs(ctx, i.resourcesToSync...)
+	metrics.RecordAPIImport(err)
 	if err != nil {
 		klog.Errorf("error pulling CRDs: %v", err)
 		return
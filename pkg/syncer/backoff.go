@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// BackoffOptions configures how the syncer spreads its periodic requests to kcp, and how it retries
+// failed requests, to avoid a thundering herd when many syncers reconnect to kcp at the same time.
+type BackoffOptions struct {
+	// HeartbeatInterval is the interval at which the SyncTarget heartbeat is sent.
+	HeartbeatInterval time.Duration
+	// DiscoveryPollInterval is the interval at which the syncer virtual workspace is polled
+	// to learn about new resource types to sync, or forget about old ones.
+	DiscoveryPollInterval time.Duration
+	// Jitter is the maximum factor by which the intervals and the retry delays are randomly increased.
+	Jitter float64
+	// RetryInitialInterval is the delay before the first retry of a failed request. The delay
+	// doubles after every failed retry, up to RetryMaxInterval.
+	RetryInitialInterval time.Duration
+	// RetryMaxInterval is the maximum delay between retries of a failed request.
+	RetryMaxInterval time.Duration
+	// MaxConcurrentReconnects is the maximum number of syncer virtual workspaces the syncer connects
+	// to at the same time. 0 means no limit.
+	MaxConcurrentReconnects int
+}
+
+// DefaultBackoffOptions returns the default backoff options of the syncer.
+func DefaultBackoffOptions() BackoffOptions {
+	return BackoffOptions{
+		// TODO(marun) Coordinate this value with the interval configured for the heartbeat controller
+		HeartbeatInterval:       20 * time.Second,
+		DiscoveryPollInterval:   30 * time.Second,
+		Jitter:                  0.2,
+		RetryInitialInterval:    1 * time.Second,
+		RetryMaxInterval:        2 * time.Minute,
+		MaxConcurrentReconnects: 2,
+	}
+}
+
+// withDefaults returns the options with the unset intervals replaced by their default.
+func (o BackoffOptions) withDefaults() BackoffOptions {
+	defaults := DefaultBackoffOptions()
+	if o.HeartbeatInterval <= 0 {
+		o.HeartbeatInterval = defaults.HeartbeatInterval
+	}
+	if o.DiscoveryPollInterval <= 0 {
+		o.DiscoveryPollInterval = defaults.DiscoveryPollInterval
+	}
+	if o.RetryInitialInterval <= 0 {
+		o.RetryInitialInterval = defaults.RetryInitialInterval
+	}
+	if o.RetryMaxInterval <= 0 {
+		o.RetryMaxInterval = defaults.RetryMaxInterval
+	}
+	if o.RetryMaxInterval < o.RetryInitialInterval {
+		o.RetryMaxInterval = o.RetryInitialInterval
+	}
+	return o
+}
+
+// retryBackoff returns an exponential backoff with jitter, that never stops growing until RetryMaxInterval.
+func (o BackoffOptions) retryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: o.RetryInitialInterval,
+		Factor:   2,
+		Jitter:   o.Jitter,
+		Steps:    math.MaxInt32,
+		Cap:      o.RetryMaxInterval,
+	}
+}
+
+// retryUntilSucceeded calls fn until it returns true or ctx is done, waiting between
+// the attempts according to backoff.
+func retryUntilSucceeded(ctx context.Context, backoff wait.Backoff, fn func(ctx context.Context) bool) {
+	for !fn(ctx) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffOptionsWithDefaults(t *testing.T) {
+	tests := map[string]struct {
+		options  BackoffOptions
+		expected BackoffOptions
+	}{
+		"unset intervals are defaulted": {
+			options: BackoffOptions{Jitter: 0.5},
+			expected: BackoffOptions{
+				HeartbeatInterval:     20 * time.Second,
+				DiscoveryPollInterval: 30 * time.Second,
+				Jitter:                0.5,
+				RetryInitialInterval:  time.Second,
+				RetryMaxInterval:      2 * time.Minute,
+			},
+		},
+		"set intervals are kept": {
+			options: BackoffOptions{
+				HeartbeatInterval:       time.Minute,
+				DiscoveryPollInterval:   2 * time.Minute,
+				RetryInitialInterval:    5 * time.Second,
+				RetryMaxInterval:        10 * time.Minute,
+				MaxConcurrentReconnects: 3,
+			},
+			expected: BackoffOptions{
+				HeartbeatInterval:       time.Minute,
+				DiscoveryPollInterval:   2 * time.Minute,
+				RetryInitialInterval:    5 * time.Second,
+				RetryMaxInterval:        10 * time.Minute,
+				MaxConcurrentReconnects: 3,
+			},
+		},
+		"max retry interval is not lower than the initial one": {
+			options: BackoffOptions{
+				RetryInitialInterval: 5 * time.Minute,
+				RetryMaxInterval:     time.Minute,
+			},
+			expected: BackoffOptions{
+				HeartbeatInterval:     20 * time.Second,
+				DiscoveryPollInterval: 30 * time.Second,
+				RetryInitialInterval:  5 * time.Minute,
+				RetryMaxInterval:      5 * time.Minute,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.options.withDefaults())
+		})
+	}
+}
+
+func TestRetryBackoffIsCapped(t *testing.T) {
+	backoff := BackoffOptions{RetryInitialInterval: time.Second, RetryMaxInterval: 10 * time.Second}.retryBackoff()
+
+	var delays []time.Duration
+	for i := 0; i < 6; i++ {
+		delays = append(delays, backoff.Step())
+	}
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}, delays)
+}
+
+func TestRetryUntilSucceeded(t *testing.T) {
+	backoff := BackoffOptions{RetryInitialInterval: time.Millisecond, RetryMaxInterval: time.Millisecond}.retryBackoff()
+
+	attempts := 0
+	retryUntilSucceeded(context.Background(), backoff, func(ctx context.Context) bool {
+		attempts++
+		return attempts == 3
+	})
+	require.Equal(t, 3, attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	retryUntilSucceeded(ctx, backoff, func(ctx context.Context) bool {
+		attempts++
+		return false
+	})
+	require.Equal(t, 1, attempts, "retries should stop once the context is done")
+}
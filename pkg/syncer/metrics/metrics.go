@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the syncer.
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	// Register the metrics of the workqueues of the spec and status syncers.
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+const (
+	namespace = "kcp"
+	subsystem = "syncer"
+
+	// SpecSyncer and StatusSyncer are the values of the syncer label.
+	SpecSyncer   = "spec"
+	StatusSyncer = "status"
+
+	// UpsertOperation and DeleteOperation are the values of the operation label.
+	UpsertOperation = "upsert"
+	DeleteOperation = "delete"
+
+	successResult = "success"
+	failureResult = "failure"
+
+	// unknownReason is the reason of the errors that are not SyncErrors.
+	unknownReason = "Unknown"
+)
+
+var (
+	syncedObjects = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "synced_objects_total",
+			Help:           "Number of objects upserted or deleted by the syncer, by syncer, resource and operation.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"syncer", "resource", "operation"},
+	)
+
+	syncErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "sync_errors_total",
+			Help:           "Number of failed syncs, by syncer, resource and reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"syncer", "resource", "reason"},
+	)
+
+	processDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "process_duration_seconds",
+			Help:           "Duration of the processing of a work item, by syncer and resource.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"syncer", "resource"},
+	)
+
+	propagationDelay = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "propagation_delay_seconds",
+			Help:           "Delay between the observation of an upstream change by the syncer and its successful propagation downstream, by resource.",
+			Buckets:        metrics.ExponentialBuckets(0.01, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	statusUpdateDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "status_update_duration_seconds",
+			Help:           "Duration of the requests writing the downstream status upstream, by resource.",
+			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	heartbeats = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "heartbeats_total",
+			Help:           "Number of SyncTarget heartbeats, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	apiImports = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "api_imports_total",
+			Help:           "Number of imports of the APIs of the physical cluster, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+)
+
+var registerOnce sync.Once
+
+// Register registers the syncer metrics in the legacy registry.
+func Register() {
+	registerOnce.Do(func() {
+		legacyregistry.MustRegister(syncedObjects)
+		legacyregistry.MustRegister(syncErrors)
+		legacyregistry.MustRegister(processDuration)
+		legacyregistry.MustRegister(propagationDelay)
+		legacyregistry.MustRegister(statusUpdateDuration)
+		legacyregistry.MustRegister(heartbeats)
+		legacyregistry.MustRegister(apiImports)
+	})
+}
+
+// resourceLabel returns the value of the resource label of a GVR, e.g. "deployments.apps".
+func resourceLabel(gvr schema.GroupVersionResource) string {
+	return gvr.GroupResource().String()
+}
+
+// RecordSyncedObject counts an object upserted or deleted by a syncer.
+func RecordSyncedObject(syncer string, gvr schema.GroupVersionResource, operation string) {
+	syncedObjects.WithLabelValues(syncer, resourceLabel(gvr), operation).Inc()
+}
+
+// RecordProcessed records the duration of the processing of a work item that started at start,
+// and counts the error it failed with, if any. The reason of SyncErrors is kept, other errors
+// are counted with the Unknown reason.
+func RecordProcessed(syncer string, gvr schema.GroupVersionResource, start time.Time, err error) {
+	processDuration.WithLabelValues(syncer, resourceLabel(gvr)).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+	reason := unknownReason
+	var syncErr *shared.SyncError
+	if errors.As(err, &syncErr) {
+		reason = syncErr.Reason
+	}
+	syncErrors.WithLabelValues(syncer, resourceLabel(gvr), reason).Inc()
+}
+
+// RecordPropagationDelay records the delay between the observation of an upstream change at observed,
+// and its propagation downstream.
+func RecordPropagationDelay(gvr schema.GroupVersionResource, observed time.Time) {
+	propagationDelay.WithLabelValues(resourceLabel(gvr)).Observe(time.Since(observed).Seconds())
+}
+
+// RecordStatusUpdate records the duration of an upstream status write that started at start.
+func RecordStatusUpdate(gvr schema.GroupVersionResource, start time.Time) {
+	statusUpdateDuration.WithLabelValues(resourceLabel(gvr)).Observe(time.Since(start).Seconds())
+}
+
+// RecordHeartbeat counts a successful or failed SyncTarget heartbeat.
+func RecordHeartbeat(err error) {
+	heartbeats.WithLabelValues(result(err)).Inc()
+}
+
+// RecordAPIImport counts a successful or failed API import.
+func RecordAPIImport(err error) {
+	apiImports.WithLabelValues(result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return failureResult
+	}
+	return successResult
+}
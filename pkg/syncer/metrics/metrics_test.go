@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestRecordProcessed(t *testing.T) {
+	Register()
+	syncErrors.Reset()
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	RecordProcessed(SpecSyncer, deployments, time.Now(), nil)
+	RecordProcessed(SpecSyncer, deployments, time.Now(), shared.NewSyncError(shared.ApplyFailedReason, errors.New("conflict")))
+	RecordProcessed(SpecSyncer, deployments, time.Now(), fmt.Errorf("wrapped: %w", shared.NewSyncError(shared.ApplyFailedReason, errors.New("conflict"))))
+	RecordProcessed(StatusSyncer, services, time.Now(), errors.New("connection refused"))
+
+	expected := `
+# HELP kcp_syncer_sync_errors_total [ALPHA] Number of failed syncs, by syncer, resource and reason.
+# TYPE kcp_syncer_sync_errors_total counter
+kcp_syncer_sync_errors_total{reason="ApplyFailed",resource="deployments.apps",syncer="spec"} 2
+kcp_syncer_sync_errors_total{reason="Unknown",resource="services",syncer="status"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "kcp_syncer_sync_errors_total"))
+}
+
+func TestRecordHeartbeat(t *testing.T) {
+	Register()
+	heartbeats.Reset()
+
+	RecordHeartbeat(nil)
+	RecordHeartbeat(nil)
+	RecordHeartbeat(errors.New("forbidden"))
+
+	expected := `
+# HELP kcp_syncer_heartbeats_total [ALPHA] Number of SyncTarget heartbeats, by result.
+# TYPE kcp_syncer_heartbeats_total counter
+kcp_syncer_heartbeats_total{result="failure"} 1
+kcp_syncer_heartbeats_total{result="success"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(legacyregistry.DefaultGatherer, strings.NewReader(expected), "kcp_syncer_heartbeats_total"))
+}
@@ -0,0 +1,645 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcesync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/third_party/keyfunctions"
+)
+
+const (
+	resyncPeriod = 10 * time.Hour
+
+	// initialDiscoveryInterval is the interval used to retry the initial discovery until it succeeds.
+	initialDiscoveryInterval = 1 * time.Second
+
+	byWorkspaceIndexName = "syncer-ByWorkspace"
+
+	// workspaceLookupTimeout bounds the lookup of a workspace through the syncer virtual workspace URL.
+	workspaceLookupTimeout = 10 * time.Second
+	// notServedWorkspaceTTL is the time during which a workspace found not to be served through the
+	// syncer virtual workspace URL is not looked up again.
+	notServedWorkspaceTTL = time.Minute
+)
+
+var namespaceGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+// DiscoveryOptions configures how the syncer virtual workspace is polled for the resource types to sync.
+type DiscoveryOptions struct {
+	// PollInterval is the interval at which the resource types are discovered.
+	PollInterval time.Duration
+	// PollJitter is the maximum factor by which PollInterval is randomly increased, to spread
+	// the discovery requests of many syncers.
+	PollJitter float64
+	// RetryBackoff is the backoff used to retry the initial discovery until it succeeds.
+	// If unset, the initial discovery is retried every second.
+	RetryBackoff wait.Backoff
+	// InitialAttempts is the number of attempts of the initial discovery after which Start gives up.
+	// 0 means the initial discovery is retried until it succeeds.
+	InitialAttempts int
+}
+
+// WorkspaceLookup configures how the workspaces whose objects have not been seen by the upstream informers,
+// e.g. because they have all been deleted before the syncer restarted, are looked up through the syncer
+// virtual workspace URL.
+type WorkspaceLookup struct {
+	// UpstreamClusterClient is the client of the syncer virtual workspace URL.
+	UpstreamClusterClient dynamic.ClusterInterface
+	// SoleVirtualWorkspace returns whether the URL is the only syncer virtual workspace URL of the SyncTarget.
+	// All the workspaces the URL accepts requests for are then served through it.
+	SoleVirtualWorkspace func() bool
+}
+
+// SyncerInformer holds the upstream and downstream informers of a synced resource type.
+type SyncerInformer struct {
+	UpstreamInformer   cache.SharedIndexInformer
+	DownstreamInformer cache.SharedIndexInformer
+
+	stopCh chan struct{}
+}
+
+// HasSynced returns true if both the upstream and the downstream informers have synced.
+func (i *SyncerInformer) HasSynced() bool {
+	return i.UpstreamInformer.HasSynced() && i.DownstreamInformer.HasSynced()
+}
+
+// SyncerInformerFactory discovers the resource types exposed for a SyncTarget by the
+// syncer virtual workspace, and dynamically starts and stops the upstream and downstream
+// informers of those types as they appear or disappear.
+type SyncerInformerFactory struct {
+	upstreamClient          dynamic.Interface
+	downstreamClient        dynamic.Interface
+	upstreamDiscoveryClient discovery.DiscoveryInterface
+	syncTargetName          string
+	resourcesToSync         sets.String
+	// clusterScopedResourcesToSync are the cluster-scoped resource types that are opted in for syncing.
+	// Other cluster-scoped resource types are never synced.
+	clusterScopedResourcesToSync sets.String
+	discoveryOptions             DiscoveryOptions
+
+	upstreamIndexers cache.Indexers
+
+	// handlersLock protects the event handlers, which can be added concurrently
+	// with informers being started.
+	handlersLock       sync.RWMutex
+	upstreamHandlers   []informer.GVREventHandler
+	downstreamHandlers []informer.GVREventHandler
+
+	upstreamNamespaceInformer   cache.SharedIndexInformer
+	downstreamNamespaceInformer cache.SharedIndexInformer
+
+	// workspacesLock protects workspaces, the workspaces whose objects have been seen by the upstream informers
+	// or that have been found to be served through the syncer virtual workspace URL, and notServedUntil, the
+	// workspaces found not to be served through it and until when they are not looked up again.
+	workspacesLock  sync.RWMutex
+	workspaces      sets.String
+	notServedUntil  map[string]time.Time
+	workspaceLookup *WorkspaceLookup
+
+	mu        sync.RWMutex
+	informers map[schema.GroupVersionResource]*SyncerInformer
+}
+
+// NewSyncerInformerFactory returns a factory of upstream and downstream informers for
+// the resource types discovered on upstreamDiscoveryClient that match resourcesToSync.
+// Cluster-scoped resource types are only synced if they are in clusterScopedResourcesToSync.
+// Upstream informers only see objects scheduled to the SyncTarget, and downstream
+// informers only see objects created by the syncer of this SyncTarget.
+func NewSyncerInformerFactory(
+	upstreamClient dynamic.Interface,
+	downstreamClient dynamic.Interface,
+	upstreamDiscoveryClient discovery.DiscoveryInterface,
+	syncTargetName string,
+	resourcesToSync sets.String,
+	clusterScopedResourcesToSync sets.String,
+	discoveryOptions DiscoveryOptions,
+) *SyncerInformerFactory {
+	if discoveryOptions.RetryBackoff.Duration == 0 {
+		discoveryOptions.RetryBackoff = wait.Backoff{Duration: initialDiscoveryInterval}
+	}
+
+	f := &SyncerInformerFactory{
+		upstreamClient:               upstreamClient,
+		downstreamClient:             downstreamClient,
+		upstreamDiscoveryClient:      upstreamDiscoveryClient,
+		syncTargetName:               syncTargetName,
+		resourcesToSync:              resourcesToSync.Union(clusterScopedResourcesToSync),
+		clusterScopedResourcesToSync: clusterScopedResourcesToSync,
+		discoveryOptions:             discoveryOptions,
+		upstreamIndexers:             cache.Indexers{},
+		workspaces:                   sets.NewString(),
+		notServedUntil:               map[string]time.Time{},
+		informers:                    map[schema.GroupVersionResource]*SyncerInformer{},
+	}
+
+	f.upstreamNamespaceInformer = f.newUpstreamInformer(namespaceGVR)
+	f.downstreamNamespaceInformer = f.newDownstreamInformer(namespaceGVR)
+	f.upstreamNamespaceInformer.AddEventHandler(f.workspaceRecordingHandler())
+
+	return f
+}
+
+// UpstreamNamespaceInformer returns the informer of the upstream namespaces scheduled to the
+// SyncTarget. It is always started, independently of the discovered resource types.
+func (f *SyncerInformerFactory) UpstreamNamespaceInformer() cache.SharedIndexInformer {
+	return f.upstreamNamespaceInformer
+}
+
+// DownstreamNamespaceInformer returns the informer of the downstream namespaces. It is
+// always started, independently of the discovered resource types.
+func (f *SyncerInformerFactory) DownstreamNamespaceInformer() cache.SharedIndexInformer {
+	return f.downstreamNamespaceInformer
+}
+
+// SetWorkspaceLookup configures the lookup of the workspaces whose objects have not been seen by the upstream
+// informers. Without it, such workspaces are not considered served. It must be called before Start.
+func (f *SyncerInformerFactory) SetWorkspaceLookup(lookup WorkspaceLookup) {
+	f.workspacesLock.Lock()
+	defer f.workspacesLock.Unlock()
+
+	f.workspaceLookup = &lookup
+}
+
+// ServesWorkspace returns true if the given workspace is served through the syncer virtual workspace URL of the
+// upstream informers. The syncers started for the different syncer virtual workspace URLs of a SyncTarget share
+// the same downstream cluster, so a downstream object must only be handled by the syncers of the URL that serves
+// the workspace it has been synced from.
+//
+// A workspace is served if the upstream informers see, or have seen, objects of the workspace. Otherwise, e.g.
+// when all its objects have been deleted before the syncer restarted, the workspace is looked up through the URL:
+// it is served if the URL has objects of the workspace, or if the URL is the only one of the SyncTarget and accepts
+// requests for the workspace.
+func (f *SyncerInformerFactory) ServesWorkspace(workspace logicalcluster.Name) bool {
+	f.workspacesLock.RLock()
+	seen := f.workspaces.Has(workspace.String())
+	notServedUntil, notServed := f.notServedUntil[workspace.String()]
+	lookup := f.workspaceLookup
+	f.workspacesLock.RUnlock()
+	if seen {
+		return true
+	}
+
+	// The event handlers recording the workspaces may lag behind the informer stores.
+	indexers := []cache.Indexer{f.upstreamNamespaceInformer.GetIndexer()}
+	f.mu.RLock()
+	for _, inf := range f.informers {
+		indexers = append(indexers, inf.UpstreamInformer.GetIndexer())
+	}
+	f.mu.RUnlock()
+	for _, indexer := range indexers {
+		objs, err := indexer.ByIndex(byWorkspaceIndexName, workspace.String())
+		if err != nil {
+			klog.Errorf("Failed to list the upstream objects of workspace %s: %v", workspace, err)
+			continue
+		}
+		if len(objs) > 0 {
+			return true
+		}
+	}
+
+	if lookup == nil || (notServed && time.Now().Before(notServedUntil)) {
+		return false
+	}
+	served, err := f.lookupWorkspace(lookup, workspace)
+	if err != nil {
+		klog.Errorf("Failed to look up workspace %s through the syncer virtual workspace: %v", workspace, err)
+		return false
+	}
+
+	f.workspacesLock.Lock()
+	defer f.workspacesLock.Unlock()
+	if served {
+		f.workspaces.Insert(workspace.String())
+		delete(f.notServedUntil, workspace.String())
+	} else {
+		f.notServedUntil[workspace.String()] = time.Now().Add(notServedWorkspaceTTL)
+	}
+	return served
+}
+
+// lookupWorkspace lists the namespaces of the workspace through the syncer virtual workspace URL, to find whether
+// the workspace is served through it.
+func (f *SyncerInformerFactory) lookupWorkspace(lookup *WorkspaceLookup, workspace logicalcluster.Name) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceLookupTimeout)
+	defer cancel()
+
+	namespaces, err := lookup.UpstreamClusterClient.Cluster(workspace).Resource(namespaceGVR).List(ctx, metav1.ListOptions{
+		LabelSelector: workloadv1alpha1.ClusterResourceStateLabelPrefix + f.syncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync),
+		Limit:         1,
+	})
+	if errors.IsNotFound(err) || errors.IsForbidden(err) {
+		// The workspace is not served through this URL.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(namespaces.Items) > 0 || (lookup.SoleVirtualWorkspace != nil && lookup.SoleVirtualWorkspace()), nil
+}
+
+// AddUpstreamIndexers adds indexers to every upstream informer. It must be called before Start.
+func (f *SyncerInformerFactory) AddUpstreamIndexers(indexers cache.Indexers) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, indexer := range indexers {
+		if _, found := f.upstreamIndexers[name]; found {
+			return fmt.Errorf("indexer %q already exists", name)
+		}
+		f.upstreamIndexers[name] = indexer
+	}
+	return nil
+}
+
+// AddUpstreamEventHandler adds a handler that is called for events of every upstream informer.
+func (f *SyncerInformerFactory) AddUpstreamEventHandler(handler informer.GVREventHandler) {
+	f.handlersLock.Lock()
+	defer f.handlersLock.Unlock()
+
+	f.upstreamHandlers = append(f.upstreamHandlers, handler)
+}
+
+// AddDownstreamEventHandler adds a handler that is called for events of every downstream informer,
+// apart from the downstream namespace informer.
+func (f *SyncerInformerFactory) AddDownstreamEventHandler(handler informer.GVREventHandler) {
+	f.handlersLock.Lock()
+	defer f.handlersLock.Unlock()
+
+	f.downstreamHandlers = append(f.downstreamHandlers, handler)
+}
+
+// InformerForResource returns the informers of the given resource type, if the type is currently synced.
+func (f *SyncerInformerFactory) InformerForResource(gvr schema.GroupVersionResource) (*SyncerInformer, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	inf, ok := f.informers[gvr]
+	return inf, ok
+}
+
+// SyncedGVRs returns the resource types that are currently synced.
+func (f *SyncerInformerFactory) SyncedGVRs() []schema.GroupVersionResource {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(f.informers))
+	for gvr := range f.informers {
+		gvrs = append(gvrs, gvr)
+	}
+	return gvrs
+}
+
+// Start starts the upstream and downstream namespace informers, and blocks until the synced resource types
+// have been discovered once and their informers started. Discovery is then polled in the
+// background, to start informers for new resource types and stop informers of resource types
+// that have disappeared. All informers are stopped when ctx is done.
+// It returns an error if the initial discovery still fails after the configured number of attempts,
+// or if ctx is done before it succeeds.
+func (f *SyncerInformerFactory) Start(ctx context.Context) error {
+	go f.upstreamNamespaceInformer.Run(ctx.Done())
+	go f.downstreamNamespaceInformer.Run(ctx.Done())
+
+	backoff := f.discoveryOptions.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := f.discoverTypes()
+		if err == nil {
+			break
+		}
+		if f.discoveryOptions.InitialAttempts > 0 && attempt >= f.discoveryOptions.InitialAttempts {
+			return fmt.Errorf("failed to discover the resource types to sync for SyncTarget %s after %d attempts: %w", f.syncTargetName, attempt, err)
+		}
+		klog.Errorf("Failed to discover the resource types to sync for SyncTarget %s: %v", f.syncTargetName, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff.Step()):
+		}
+	}
+
+	go func() {
+		defer f.stopAll()
+
+		// The first discovery has just happened, so wait for the poll interval before the next one.
+		delay := f.discoveryOptions.PollInterval
+		if f.discoveryOptions.PollJitter > 0 {
+			delay = wait.Jitter(delay, f.discoveryOptions.PollJitter)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		wait.JitterUntilWithContext(ctx, func(ctx context.Context) {
+			if err := f.discoverTypes(); err != nil {
+				klog.Errorf("Failed to discover the resource types to sync for SyncTarget %s: %v", f.syncTargetName, err)
+			}
+		}, f.discoveryOptions.PollInterval, f.discoveryOptions.PollJitter, false)
+	}()
+
+	return nil
+}
+
+// WaitForCacheSync waits for the namespace informers, and for all the informers
+// started so far, to be synced.
+func (f *SyncerInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	f.mu.RLock()
+	informers := make(map[schema.GroupVersionResource]*SyncerInformer, len(f.informers))
+	for gvr, inf := range f.informers {
+		informers[gvr] = inf
+	}
+	f.mu.RUnlock()
+
+	res := map[schema.GroupVersionResource]bool{
+		namespaceGVR: cache.WaitForCacheSync(stopCh, f.upstreamNamespaceInformer.HasSynced, f.downstreamNamespaceInformer.HasSynced),
+	}
+	for gvr, inf := range informers {
+		res[gvr] = cache.WaitForCacheSync(stopCh, inf.UpstreamInformer.HasSynced, inf.DownstreamInformer.HasSynced)
+	}
+	return res
+}
+
+func (f *SyncerInformerFactory) discoverTypes() error {
+	gvrs, err := getAllGVRs(f.upstreamDiscoveryClient, f.clusterScopedResourcesToSync, f.resourcesToSync.List()...)
+	if err != nil {
+		return err
+	}
+	latest := make(map[schema.GroupVersionResource]struct{}, len(gvrs))
+	for _, gvr := range gvrs {
+		latest[gvr] = struct{}{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for gvr := range latest {
+		if _, found := f.informers[gvr]; found {
+			continue
+		}
+
+		klog.Infof("Starting informers for %q for SyncTarget %s", gvr, f.syncTargetName)
+		inf := &SyncerInformer{
+			UpstreamInformer:   f.newUpstreamInformer(gvr),
+			DownstreamInformer: f.newDownstreamInformer(gvr),
+			stopCh:             make(chan struct{}),
+		}
+		if err := inf.UpstreamInformer.AddIndexers(f.upstreamIndexers); err != nil {
+			return err
+		}
+		inf.UpstreamInformer.AddEventHandler(f.workspaceRecordingHandler())
+		inf.UpstreamInformer.AddEventHandler(f.dispatchingHandler(gvr, &f.upstreamHandlers))
+		inf.DownstreamInformer.AddEventHandler(f.dispatchingHandler(gvr, &f.downstreamHandlers))
+
+		go inf.UpstreamInformer.Run(inf.stopCh)
+		go inf.DownstreamInformer.Run(inf.stopCh)
+
+		f.informers[gvr] = inf
+	}
+
+	for gvr, inf := range f.informers {
+		if _, found := latest[gvr]; found {
+			continue
+		}
+
+		klog.Infof("Stopping informers for %q for SyncTarget %s", gvr, f.syncTargetName)
+		close(inf.stopCh)
+		delete(f.informers, gvr)
+	}
+
+	return nil
+}
+
+func (f *SyncerInformerFactory) stopAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for gvr, inf := range f.informers {
+		close(inf.stopCh)
+		delete(f.informers, gvr)
+	}
+}
+
+// dispatchingHandler returns an event handler that calls the given GVR-aware handlers.
+func (f *SyncerInformerFactory) dispatchingHandler(gvr schema.GroupVersionResource, handlers *[]informer.GVREventHandler) cache.ResourceEventHandler {
+	currentHandlers := func() []informer.GVREventHandler {
+		f.handlersLock.RLock()
+		defer f.handlersLock.RUnlock()
+		return *handlers
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			for _, h := range currentHandlers() {
+				h.OnAdd(gvr, obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			for _, h := range currentHandlers() {
+				h.OnUpdate(gvr, oldObj, newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			for _, h := range currentHandlers() {
+				h.OnDelete(gvr, obj)
+			}
+		},
+	}
+}
+
+// workspaceRecordingHandler returns an event handler that records the workspaces of the upstream objects,
+// so that downstream objects are still handled once their upstream objects have been deleted.
+func (f *SyncerInformerFactory) workspaceRecordingHandler() cache.ResourceEventHandler {
+	record := func(obj interface{}) {
+		metaObj, ok := obj.(metav1.Object)
+		if !ok {
+			return
+		}
+		workspace := logicalcluster.From(metaObj).String()
+
+		f.workspacesLock.RLock()
+		seen := f.workspaces.Has(workspace)
+		f.workspacesLock.RUnlock()
+		if seen {
+			return
+		}
+
+		f.workspacesLock.Lock()
+		defer f.workspacesLock.Unlock()
+		f.workspaces.Insert(workspace)
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    record,
+		UpdateFunc: func(_, obj interface{}) { record(obj) },
+	}
+}
+
+func (f *SyncerInformerFactory) newUpstreamInformer(gvr schema.GroupVersionResource) cache.SharedIndexInformer {
+	return newFilteredDynamicInformer(f.upstreamClient, gvr, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + f.syncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync)
+	},
+		cache.WithResyncPeriod(resyncPeriod),
+		cache.WithIndexers(cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			byWorkspaceIndexName: indexByWorkspace,
+		}),
+	)
+}
+
+func (f *SyncerInformerFactory) newDownstreamInformer(gvr schema.GroupVersionResource) cache.SharedIndexInformer {
+	return newFilteredDynamicInformer(f.downstreamClient, gvr, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + f.syncTargetName
+	},
+		cache.WithResyncPeriod(resyncPeriod),
+		cache.WithIndexers(cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc),
+	)
+}
+
+// indexByWorkspace is a cache.IndexFunc that indexes upstream objects by their workspace.
+func indexByWorkspace(obj interface{}) ([]string, error) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
+	}
+	return []string{logicalcluster.From(metaObj).String()}, nil
+}
+
+func newFilteredDynamicInformer(client dynamic.Interface, gvr schema.GroupVersionResource, tweakListOptions func(*metav1.ListOptions), opts ...cache.SharedInformerOption) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformerWithOptions(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				tweakListOptions(&options)
+				return client.Resource(gvr).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				tweakListOptions(&options)
+				return client.Resource(gvr).Watch(context.TODO(), options)
+			},
+		},
+		&unstructured.Unstructured{},
+		opts...,
+	)
+}
+
+func contains(ss []string, s string) bool {
+	for _, n := range ss {
+		if n == s {
+			return true
+		}
+	}
+	return false
+}
+
+// getAllGVRs returns the resource types to sync among the ones published by the discovery client.
+// Requested resource types that are not published (yet) are logged and skipped, so that they are
+// picked up by a later discovery. Cluster-scoped resource types are skipped, unless they are
+// in clusterScopedResourcesToSync.
+func getAllGVRs(discoveryClient discovery.DiscoveryInterface, clusterScopedResourcesToSync sets.String, resourcesToSync ...string) ([]schema.GroupVersionResource, error) {
+	toSyncSet := sets.NewString(resourcesToSync...)
+	willBeSyncedSet := sets.NewString()
+	rs, err := discovery.ServerPreferredResources(discoveryClient)
+	if err != nil {
+		// The "unable to retrieve the complete list of server APIs" error may occur when some API
+		// resources added from CRDs are not completely ready. Just retry on the next discovery.
+		return nil, err
+	}
+	// TODO(jmprusi): Added Configmaps and Secrets to the default syncing, but we should figure out
+	//                a way to avoid doing that: https://github.com/kcp-dev/kcp/issues/727
+	gvrstrs := sets.NewString("configmaps.v1.", "secrets.v1.") // A syncer should always watch secrets and configmaps.
+	for _, r := range rs {
+		// v1 -> v1.
+		// apps/v1 -> v1.apps
+		// tekton.dev/v1beta1 -> v1beta1.tekton.dev
+		groupVersion, err := schema.ParseGroupVersion(r.GroupVersion)
+		if err != nil {
+			klog.Warningf("Unable to parse GroupVersion %s : %v", r.GroupVersion, err)
+			continue
+		}
+		vr := groupVersion.Version + "." + groupVersion.Group
+		for _, ai := range r.APIResources {
+			var willBeSynced string
+			groupResource := schema.GroupResource{
+				Group:    groupVersion.Group,
+				Resource: ai.Name,
+			}
+
+			if toSyncSet.Has(groupResource.String()) {
+				willBeSynced = groupResource.String()
+			} else if toSyncSet.Has(ai.Name) {
+				willBeSynced = ai.Name
+			} else {
+				// We're not interested in this resource type
+				continue
+			}
+			if strings.Contains(ai.Name, "/") {
+				// foo/status, pods/exec, namespace/finalize, etc.
+				continue
+			}
+			if !ai.Namespaced && !clusterScopedResourcesToSync.Has(willBeSynced) {
+				// Ignore cluster-scoped things, unless opted in.
+				continue
+			}
+			if !contains(ai.Verbs, "watch") {
+				klog.Infof("resource %s %s is not watchable: %v", vr, ai.Name, ai.Verbs)
+				continue
+			}
+			gvrstrs.Insert(fmt.Sprintf("%s.%s", ai.Name, vr))
+			willBeSyncedSet.Insert(willBeSynced)
+		}
+	}
+
+	if notFoundResourceTypes := toSyncSet.Difference(willBeSyncedSet); notFoundResourceTypes.Len() != 0 {
+		// Some of the API resources expected to be there are still not published by KCP.
+		// They will be synced as soon as they are published as API resources.
+		klog.V(2).Infof("The following resource types were requested to be synced, but were not found in the KCP logical cluster: %v", notFoundResourceTypes.List())
+	}
+
+	gvrs := make([]schema.GroupVersionResource, 0, gvrstrs.Len())
+	for _, gvrstr := range gvrstrs.List() {
+		gvr, _ := schema.ParseResourceArg(gvrstr)
+		if gvr == nil {
+			klog.Warningf("Unable to parse resource %q as <resource>.<version>.<group>", gvrstr)
+			continue
+		}
+		gvrs = append(gvrs, *gvr)
+	}
+	return gvrs, nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcesync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestGetAllGVRs(t *testing.T) {
+	resources := []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "services", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+				{Name: "services/status", Namespaced: true, Verbs: metav1.Verbs{"get", "update"}},
+				{Name: "persistentvolumes", Namespaced: false, Verbs: metav1.Verbs{"get", "list", "watch"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+				{Name: "statefulsets", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		resourcesToSync              []string
+		clusterScopedResourcesToSync []string
+		want                         []schema.GroupVersionResource
+	}{
+		"configmaps and secrets are always synced": {
+			want: []schema.GroupVersionResource{
+				{Version: "v1", Resource: "configmaps"},
+				{Version: "v1", Resource: "secrets"},
+			},
+		},
+		"requested resources are synced, by resource or group resource": {
+			resourcesToSync: []string{"services", "deployments.apps"},
+			want: []schema.GroupVersionResource{
+				{Version: "v1", Resource: "configmaps"},
+				{Group: "apps", Version: "v1", Resource: "deployments"},
+				{Version: "v1", Resource: "secrets"},
+				{Version: "v1", Resource: "services"},
+			},
+		},
+		"cluster-scoped, unwatchable and missing resources are skipped": {
+			resourcesToSync: []string{"persistentvolumes", "statefulsets.apps", "ingresses.networking.k8s.io"},
+			want: []schema.GroupVersionResource{
+				{Version: "v1", Resource: "configmaps"},
+				{Version: "v1", Resource: "secrets"},
+			},
+		},
+		"opted-in cluster-scoped resources are synced": {
+			resourcesToSync:              []string{"services", "persistentvolumes"},
+			clusterScopedResourcesToSync: []string{"persistentvolumes"},
+			want: []schema.GroupVersionResource{
+				{Version: "v1", Resource: "configmaps"},
+				{Version: "v1", Resource: "persistentvolumes"},
+				{Version: "v1", Resource: "secrets"},
+				{Version: "v1", Resource: "services"},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+			got, err := getAllGVRs(discoveryClient, sets.NewString(tc.clusterScopedResourcesToSync...), tc.resourcesToSync...)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.want, got)
+		})
+	}
+}
+
+func TestStartInitialDiscoveryAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An invalid group version makes every discovery fail.
+	discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "invalid/group/version"},
+	}}}
+	newClient := func() *dynamicfake.FakeDynamicClient {
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			namespaceGVR: "NamespaceList",
+		})
+	}
+	f := NewSyncerInformerFactory(newClient(), newClient(), discoveryClient, "us-west1", sets.NewString(), sets.NewString(), DiscoveryOptions{
+		PollInterval:    time.Hour,
+		RetryBackoff:    wait.Backoff{Duration: time.Millisecond},
+		InitialAttempts: 3,
+	})
+
+	require.Error(t, f.Start(ctx))
+	discoveries := 0
+	for _, action := range discoveryClient.Actions() {
+		if action.GetResource().Resource == "group" {
+			discoveries++
+		}
+	}
+	require.Equal(t, 3, discoveries)
+}
+
+type fakeDynamicClusterClient struct {
+	client dynamic.Interface
+}
+
+func (c *fakeDynamicClusterClient) Cluster(_ logicalcluster.Name) dynamic.Interface {
+	return c.client
+}
+
+func TestServesWorkspaceLookup(t *testing.T) {
+	syncedNamespace := &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test",
+			Labels: map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+		},
+	}
+
+	tests := map[string]struct {
+		lookup     bool
+		namespaces []runtime.Object
+		forbidden  bool
+		sole       bool
+		want       bool
+	}{
+		"no lookup": {
+			sole: true,
+		},
+		"objects of the workspace through the URL": {
+			lookup:     true,
+			namespaces: []runtime.Object{syncedNamespace},
+			want:       true,
+		},
+		"no objects through the only URL": {
+			lookup: true,
+			sole:   true,
+			want:   true,
+		},
+		"no objects through one of several URLs": {
+			lookup: true,
+		},
+		"workspace rejected by the URL": {
+			lookup:    true,
+			forbidden: true,
+			sole:      true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+			upstreamClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.namespaces...)
+			if tc.forbidden {
+				upstreamClient.PrependReactor("list", "namespaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.NewForbidden(namespaceGVR.GroupResource(), "", fmt.Errorf("workspace not served"))
+				})
+			}
+			newClient := func() *dynamicfake.FakeDynamicClient {
+				return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+					namespaceGVR: "NamespaceList",
+				})
+			}
+			f := NewSyncerInformerFactory(newClient(), newClient(), &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}, "us-west1",
+				sets.NewString(), sets.NewString(), DiscoveryOptions{})
+			if tc.lookup {
+				f.SetWorkspaceLookup(WorkspaceLookup{
+					UpstreamClusterClient: &fakeDynamicClusterClient{client: upstreamClient},
+					SoleVirtualWorkspace:  func() bool { return tc.sole },
+				})
+			}
+
+			require.Equal(t, tc.want, f.ServesWorkspace(logicalcluster.New("root:org:ws")))
+
+			// The result of the lookup is remembered.
+			lists := len(upstreamClient.Actions())
+			require.Equal(t, tc.want, f.ServesWorkspace(logicalcluster.New("root:org:ws")))
+			require.Len(t, upstreamClient.Actions(), lists)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/martinlindhe/base36"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	ResourceLocatorAnnotation = "kcp.dev/resource-locator"
+)
+
+// ResourceLocator stores the logical cluster and name of a cluster-scoped
+// upstream resource, and is set on its copy in a physical cluster to track
+// which upstream resource owns it.
+type ResourceLocator struct {
+	SyncTarget SyncTargetLocator   `json:"syncTarget"`
+	Workspace  logicalcluster.Name `json:"workspace,omitempty"`
+	Name       string              `json:"name"`
+}
+
+func NewResourceLocator(workspace, syncTargetWorkspace logicalcluster.Name, syncTargetUID types.UID, workloadLogicalClusterName, upstreamName string) ResourceLocator {
+	return ResourceLocator{
+		SyncTarget: SyncTargetLocator{
+			Path: syncTargetWorkspace,
+			Name: workloadLogicalClusterName,
+			UID:  syncTargetUID,
+		},
+		Workspace: workspace,
+		Name:      upstreamName,
+	}
+}
+
+func ResourceLocatorFromAnnotations(annotations map[string]string) (*ResourceLocator, bool, error) {
+	annotation, ok := annotations[ResourceLocatorAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	var locator ResourceLocator
+	if err := json.Unmarshal([]byte(annotation), &locator); err != nil {
+		return nil, false, err
+	}
+	return &locator, true, nil
+}
+
+// PhysicalClusterResourceName returns the name of a cluster-scoped resource
+// in a physical cluster. Resources with the same name in different workspaces
+// are given different names, by prefixing the upstream name with a hash of
+// the workspace and the SyncTarget. The encoding is repeatable.
+func PhysicalClusterResourceName(l ResourceLocator) (string, error) {
+	b, err := json.Marshal(NamespaceLocator{
+		SyncTarget: l.SyncTarget,
+		Workspace:  l.Workspace,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum224(b[:])
+	base36hash := strings.ToLower(base36.EncodeBytes(hash[:]))
+	name := fmt.Sprintf("kcp-%s-%s", base36hash[:12], l.Name)
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		return "", fmt.Errorf("name %q of the cluster-scoped resource in the physical cluster is longer than %d characters", name, validation.DNS1123SubdomainMaxLength)
+	}
+	return name, nil
+}
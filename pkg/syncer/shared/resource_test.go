@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhysicalClusterResourceName(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+
+	name, err := PhysicalClusterResourceName(NewResourceLocator(logicalcluster.New("root:org:ws1"), syncTargetWorkspace, "uid", "us-west1", "admin"))
+	require.NoError(t, err)
+	require.Regexp(t, "^kcp-[a-z0-9]{12}-admin$", name)
+
+	sameName, err := PhysicalClusterResourceName(NewResourceLocator(logicalcluster.New("root:org:ws1"), syncTargetWorkspace, "uid", "us-west1", "admin"))
+	require.NoError(t, err)
+	require.Equal(t, name, sameName, "names should be deterministic")
+
+	otherWorkspaceName, err := PhysicalClusterResourceName(NewResourceLocator(logicalcluster.New("root:org:ws2"), syncTargetWorkspace, "uid", "us-west1", "admin"))
+	require.NoError(t, err)
+	require.NotEqual(t, name, otherWorkspaceName, "names should differ across workspaces")
+
+	otherSyncTargetName, err := PhysicalClusterResourceName(NewResourceLocator(logicalcluster.New("root:org:ws1"), syncTargetWorkspace, "uid2", "us-east1", "admin"))
+	require.NoError(t, err)
+	require.NotEqual(t, name, otherSyncTargetName, "names should differ across sync targets")
+
+	_, err = PhysicalClusterResourceName(NewResourceLocator(logicalcluster.New("root:org:ws1"), syncTargetWorkspace, "uid", "us-west1", strings.Repeat("a", 250)))
+	require.Error(t, err, "too long names should be rejected")
+}
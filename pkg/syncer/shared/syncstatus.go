@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"encoding/json"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+const (
+	// InvalidSpecDiffReason means that the spec diff annotation of the upstream object could not be applied.
+	InvalidSpecDiffReason = "InvalidSpecDiff"
+	// InvalidSpecOverridesReason means that the spec overrides of the SyncTargetOverrides selecting the upstream object could not be applied.
+	InvalidSpecOverridesReason = "InvalidSpecOverrides"
+	// MutationFailedReason means that the upstream object could not be transformed for the sync target,
+	// for example because the token of its service account is missing.
+	MutationFailedReason = "MutationFailed"
+	// NamespaceCollisionReason means that the downstream namespace of the upstream object is owned by another
+	// upstream namespace.
+	NamespaceCollisionReason = "NamespaceCollision"
+	// ApplyFailedReason means that the downstream object could not be applied.
+	ApplyFailedReason = "ApplyFailed"
+)
+
+// SyncStatus is the value of the experimental.sync-status.workload.kcp.dev/<sync-target-name> annotation.
+type SyncStatus struct {
+	// ObservedGeneration is the generation of the upstream object the syncer last attempted to sync.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// Reason is a CamelCase reason for the last sync failure. It is empty if the last sync succeeded.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message describing the last sync failure.
+	Message string `json:"message,omitempty"`
+}
+
+// SyncStatusFromAnnotations returns the sync status stored for the given SyncTarget in the annotations.
+func SyncStatusFromAnnotations(annotations map[string]string, syncTargetName string) (*SyncStatus, bool, error) {
+	annotation, ok := annotations[workloadv1alpha1.InternalClusterSyncStatusAnnotationPrefix+syncTargetName]
+	if !ok {
+		return nil, false, nil
+	}
+	var status SyncStatus
+	if err := json.Unmarshal([]byte(annotation), &status); err != nil {
+		return nil, false, err
+	}
+	return &status, true, nil
+}
+
+// SyncError is an error that happened while syncing an upstream object, with the reason
+// reported to the users in the sync status of the upstream object.
+type SyncError struct {
+	Reason string
+	Err    error
+}
+
+func NewSyncError(reason string, err error) *SyncError {
+	return &SyncError{Reason: reason, Err: err}
+}
+
+func (e *SyncError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ListDownstreamIngressesFunc lists the ingresses synced to the physical cluster.
+type ListDownstreamIngressesFunc func() ([]*unstructured.Unstructured, error)
+
+// DownstreamNamespaceFunc returns the namespace of the physical cluster a namespace of a workspace is synced to.
+type DownstreamNamespaceFunc func(clusterName logicalcluster.Name, namespace string) (string, error)
+
+// IngressMutator rejects the ingresses using hosts that are already used by the ingresses of another
+// namespace of the physical cluster, e.g. synced from another workspace, as the ingress controller of
+// the physical cluster would route the traffic of these hosts to either of them.
+type IngressMutator struct {
+	listDownstreamIngresses ListDownstreamIngressesFunc
+	downstreamNamespace     DownstreamNamespaceFunc
+}
+
+func (im *IngressMutator) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "networking.k8s.io",
+		Version:  "v1",
+		Resource: "ingresses",
+	}
+}
+
+func NewIngressMutator(listDownstreamIngresses ListDownstreamIngressesFunc, downstreamNamespace DownstreamNamespaceFunc) *IngressMutator {
+	return &IngressMutator{
+		listDownstreamIngresses: listDownstreamIngresses,
+		downstreamNamespace:     downstreamNamespace,
+	}
+}
+
+// Mutate applies the mutator changes to the object.
+func (im *IngressMutator) Mutate(obj *unstructured.Unstructured) error {
+	hosts, err := ingressHosts(obj)
+	if err != nil {
+		return err
+	}
+	if hosts.Len() == 0 || im.listDownstreamIngresses == nil || im.downstreamNamespace == nil {
+		return nil
+	}
+
+	namespace, err := im.downstreamNamespace(logicalcluster.From(obj), obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+	ingresses, err := im.listDownstreamIngresses()
+	if err != nil {
+		return err
+	}
+	for _, ingress := range ingresses {
+		if ingress.GetNamespace() == namespace {
+			continue
+		}
+		otherHosts, err := ingressHosts(ingress)
+		if err != nil {
+			continue
+		}
+		if conflicts := hosts.Intersection(otherHosts); conflicts.Len() > 0 {
+			return fmt.Errorf("host %q is already used by the ingress %s/%s of another namespace of the physical cluster", conflicts.List()[0], ingress.GetNamespace(), ingress.GetName())
+		}
+	}
+	return nil
+}
+
+// ingressHosts returns the hosts of the rules and of the TLS configuration of the ingress.
+func ingressHosts(obj *unstructured.Unstructured) (sets.String, error) {
+	hosts := sets.NewString()
+
+	rules, _, err := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule, ok := rule.(map[string]interface{}); ok {
+			if host, ok := rule["host"].(string); ok && host != "" {
+				hosts.Insert(host)
+			}
+		}
+	}
+
+	tls, _, err := unstructured.NestedSlice(obj.Object, "spec", "tls")
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tls {
+		t, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tlsHosts, _, err := unstructured.NestedStringSlice(t, "hosts")
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range tlsHosts {
+			if host != "" {
+				hosts.Insert(host)
+			}
+		}
+	}
+	return hosts, nil
+}
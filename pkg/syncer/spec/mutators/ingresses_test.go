@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIngressMutate(t *testing.T) {
+	ingress := func(namespace, clusterName string, ruleHosts []string, tlsHosts ...string) *unstructured.Unstructured {
+		obj := &networkingv1.Ingress{
+			TypeMeta:   metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-ingress", Namespace: namespace, ClusterName: clusterName},
+		}
+		for _, host := range ruleHosts {
+			obj.Spec.Rules = append(obj.Spec.Rules, networkingv1.IngressRule{Host: host})
+		}
+		if len(tlsHosts) > 0 {
+			obj.Spec.TLS = []networkingv1.IngressTLS{{Hosts: tlsHosts}}
+		}
+		u, err := toUnstructured(obj)
+		require.NoError(t, err)
+		return u
+	}
+	// The namespace "test" of the workspace "root:org:ws" is synced to the downstream namespace "kcp-abc".
+	downstreamNamespace := func(clusterName logicalcluster.Name, namespace string) (string, error) {
+		require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+		require.Equal(t, "test", namespace)
+		return "kcp-abc", nil
+	}
+
+	for _, c := range []struct {
+		desc                string
+		ingress             *unstructured.Unstructured
+		downstreamIngresses []*unstructured.Unstructured
+		wantErr             bool
+	}{{
+		desc:    "no downstream ingress",
+		ingress: ingress("test", "root:org:ws", []string{"app.example.com"}),
+	}, {
+		desc:    "host of an ingress of the same downstream namespace",
+		ingress: ingress("test", "root:org:ws", []string{"app.example.com"}),
+		downstreamIngresses: []*unstructured.Unstructured{
+			ingress("kcp-abc", "", []string{"app.example.com"}),
+		},
+	}, {
+		desc:    "other hosts of an ingress of another downstream namespace",
+		ingress: ingress("test", "root:org:ws", []string{"app.example.com"}),
+		downstreamIngresses: []*unstructured.Unstructured{
+			ingress("kcp-def", "", []string{"other.example.com"}),
+		},
+	}, {
+		desc:    "rule host of an ingress of another downstream namespace",
+		ingress: ingress("test", "root:org:ws", []string{"app.example.com"}),
+		downstreamIngresses: []*unstructured.Unstructured{
+			ingress("kcp-def", "", []string{"other.example.com", "app.example.com"}),
+		},
+		wantErr: true,
+	}, {
+		desc:    "TLS host of an ingress of another downstream namespace",
+		ingress: ingress("test", "root:org:ws", nil, "app.example.com"),
+		downstreamIngresses: []*unstructured.Unstructured{
+			ingress("kcp-def", "", nil, "app.example.com"),
+		},
+		wantErr: true,
+	}, {
+		desc:    "ingress without host",
+		ingress: ingress("test", "root:org:ws", []string{""}),
+		downstreamIngresses: []*unstructured.Unstructured{
+			ingress("kcp-def", "", []string{""}),
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			im := NewIngressMutator(func() ([]*unstructured.Unstructured, error) {
+				return c.downstreamIngresses, nil
+			}, downstreamNamespace)
+			original := c.ingress.DeepCopy()
+
+			err := im.Mutate(c.ingress)
+			if c.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, original, c.ingress, "the ingress should not be modified")
+		})
+	}
+}
@@ -23,7 +23,6 @@ import (
 
 	"github.com/kcp-dev/logicalcluster"
 
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,45 +32,87 @@ import (
 
 type ListSecretFunc func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error)
 
-type DeploymentMutator struct {
+// podSpecableResources are the built-in resource types that embed a pod spec,
+// with the path of the pod spec in their objects.
+var podSpecableResources = map[schema.GroupVersionResource][]string{
+	{Group: "", Version: "v1", Resource: "pods"}:                   {"spec"},
+	{Group: "apps", Version: "v1", Resource: "deployments"}:        {"spec", "template", "spec"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"}:       {"spec", "template", "spec"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"}:         {"spec", "template", "spec"},
+	{Group: "apps", Version: "v1", Resource: "replicasets"}:        {"spec", "template", "spec"},
+	{Group: "batch", Version: "v1", Resource: "jobs"}:              {"spec", "template", "spec"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"}:          {"spec", "jobTemplate", "spec", "template", "spec"},
+	{Group: "batch", Version: "v1beta1", Resource: "cronjobs"}:     {"spec", "jobTemplate", "spec", "template", "spec"},
+	{Group: "", Version: "v1", Resource: "replicationcontrollers"}: {"spec", "template", "spec"},
+}
+
+// PodSpecableMutator mutates the pod spec of workloads, so that their pods
+// talk to kcp instead of the API server of the physical cluster: the service
+// account token is replaced by the kcp one, and the KUBERNETES_SERVICE_* envs
+// point to kcp.
+type PodSpecableMutator struct {
+	gvr         schema.GroupVersionResource
+	podSpecPath []string
 	upstreamURL *url.URL
 	listSecrets ListSecretFunc
 }
 
-func (dm *DeploymentMutator) GVR() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "apps",
-		Version:  "v1",
-		Resource: "deployments",
-	}
+func (pm *PodSpecableMutator) GVR() schema.GroupVersionResource {
+	return pm.gvr
 }
 
-func NewDeploymentMutator(upstreamURL *url.URL, secretLister ListSecretFunc) *DeploymentMutator {
-	return &DeploymentMutator{
+// NewPodSpecableMutator returns a mutator for the resource type gvr, whose objects
+// embed a pod spec at podSpecPath.
+func NewPodSpecableMutator(gvr schema.GroupVersionResource, podSpecPath []string, upstreamURL *url.URL, secretLister ListSecretFunc) *PodSpecableMutator {
+	return &PodSpecableMutator{
+		gvr:         gvr,
+		podSpecPath: podSpecPath,
 		upstreamURL: upstreamURL,
 		listSecrets: secretLister,
 	}
 }
 
+func NewDeploymentMutator(upstreamURL *url.URL, secretLister ListSecretFunc) *PodSpecableMutator {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	return NewPodSpecableMutator(gvr, podSpecableResources[gvr], upstreamURL, secretLister)
+}
+
+// newPodSpecableMutators returns a mutator for every built-in resource type that embeds a pod spec.
+func newPodSpecableMutators(upstreamURL *url.URL, secretLister ListSecretFunc) []Mutator {
+	mutators := make([]Mutator, 0, len(podSpecableResources))
+	for gvr, podSpecPath := range podSpecableResources {
+		mutators = append(mutators, NewPodSpecableMutator(gvr, podSpecPath, upstreamURL, secretLister))
+	}
+	return mutators
+}
+
 // Mutate applies the mutator changes to the object.
-func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
-	var deployment appsv1.Deployment
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(
-		obj.UnstructuredContent(),
-		&deployment)
+func (pm *PodSpecableMutator) Mutate(obj *unstructured.Unstructured) error {
+	podSpecContent, found, err := unstructured.NestedMap(obj.UnstructuredContent(), pm.podSpecPath...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	var podSpec corev1.PodSpec
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(
+		podSpecContent,
+		&podSpec)
 	if err != nil {
 		return err
 	}
 	upstreamLogicalName := logicalcluster.From(obj)
+	namespace := obj.GetNamespace()
 
-	templateSpec := &deployment.Spec.Template.Spec
+	templateSpec := &podSpec
 
 	desiredServiceAccountName := "default"
 	if templateSpec.ServiceAccountName != "" && templateSpec.ServiceAccountName != "default" {
 		desiredServiceAccountName = templateSpec.ServiceAccountName
 	}
 
-	secretList, err := dm.listSecrets(upstreamLogicalName, deployment.Namespace)
+	secretList, err := pm.listSecrets(upstreamLogicalName, namespace)
 	if err != nil {
 		return fmt.Errorf("error listing secrets for workspace %s: %w", upstreamLogicalName.String(), err)
 	}
@@ -99,7 +140,7 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 	}
 
 	if desiredSecretName == "" {
-		return fmt.Errorf("couldn't find a token upstream for the serviceaccount %s/%s in workspace %s", desiredServiceAccountName, deployment.Namespace, upstreamLogicalName.String())
+		return fmt.Errorf("couldn't find a token upstream for the serviceaccount %s/%s in workspace %s", desiredServiceAccountName, namespace, upstreamLogicalName.String())
 	}
 
 	// Setting AutomountServiceAccountToken to false allow us to control the ServiceAccount
@@ -108,8 +149,8 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 	// Set to empty the serviceAccountName on podTemplate as we are not syncing the serviceAccount down to the workload cluster.
 	templateSpec.ServiceAccountName = ""
 
-	kcpExternalHost := dm.upstreamURL.Hostname()
-	kcpExternalPort := dm.upstreamURL.Port()
+	kcpExternalHost := pm.upstreamURL.Hostname()
+	kcpExternalPort := pm.upstreamURL.Port()
 
 	overrideEnvs := []corev1.EnvVar{
 		{Name: "KUBERNETES_SERVICE_PORT", Value: kcpExternalPort},
@@ -117,14 +158,14 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 		{Name: "KUBERNETES_SERVICE_HOST", Value: kcpExternalHost},
 	}
 
-	// This is the VolumeMount that we will append to all the containers of the deployment
+	// This is the VolumeMount that we will append to all the containers of the pod spec
 	serviceAccountMount := corev1.VolumeMount{
 		Name:      "kcp-api-access",
 		MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
 		ReadOnly:  true,
 	}
 
-	// This is the Volume that we will add to the Deployment in order to control
+	// This is the Volume that we will add to the pod spec in order to control
 	// the name of the ca.crt references (kcp-root-ca.crt vs kube-root-ca.crt)
 	// and the serviceaccount reference.
 	serviceAccountVolume := corev1.Volume{
@@ -169,11 +210,11 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 	}
 
 	// Override Envs, resolve downwardAPI FieldRef and add the VolumeMount to all the containers
-	for i := range deployment.Spec.Template.Spec.Containers {
+	for i := range templateSpec.Containers {
 		for _, overrideEnv := range overrideEnvs {
 			templateSpec.Containers[i].Env = updateEnv(templateSpec.Containers[i].Env, overrideEnv)
 		}
-		templateSpec.Containers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.Containers[i].Env, deployment)
+		templateSpec.Containers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.Containers[i].Env, namespace)
 		templateSpec.Containers[i].VolumeMounts = updateVolumeMount(templateSpec.Containers[i].VolumeMounts, serviceAccountMount)
 	}
 
@@ -182,7 +223,7 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 		for _, overrideEnv := range overrideEnvs {
 			templateSpec.InitContainers[i].Env = updateEnv(templateSpec.InitContainers[i].Env, overrideEnv)
 		}
-		templateSpec.InitContainers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.InitContainers[i].Env, deployment)
+		templateSpec.InitContainers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.InitContainers[i].Env, namespace)
 		templateSpec.InitContainers[i].VolumeMounts = updateVolumeMount(templateSpec.InitContainers[i].VolumeMounts, serviceAccountMount)
 	}
 
@@ -191,12 +232,12 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 		for _, overrideEnv := range overrideEnvs {
 			templateSpec.EphemeralContainers[i].Env = updateEnv(templateSpec.EphemeralContainers[i].Env, overrideEnv)
 		}
-		templateSpec.EphemeralContainers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.EphemeralContainers[i].Env, deployment)
+		templateSpec.EphemeralContainers[i].Env = resolveDownwardAPIFieldRefEnv(templateSpec.EphemeralContainers[i].Env, namespace)
 		templateSpec.EphemeralContainers[i].VolumeMounts = updateVolumeMount(templateSpec.EphemeralContainers[i].VolumeMounts, serviceAccountMount)
 	}
 
 	// Add the ServiceAccount volume with our overrides.
-	found := false
+	found = false
 	for i := range templateSpec.Volumes {
 		if templateSpec.Volumes[i].Name == "kcp-api-access" {
 			templateSpec.Volumes[i] = serviceAccountVolume
@@ -207,25 +248,23 @@ func (dm *DeploymentMutator) Mutate(obj *unstructured.Unstructured) error {
 		templateSpec.Volumes = append(templateSpec.Volumes, serviceAccountVolume)
 	}
 
-	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&deployment)
+	unstructuredPodSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&podSpec)
 	if err != nil {
 		return err
 	}
 
 	// Set the changes back into the obj.
-	obj.SetUnstructuredContent(unstructured)
-
-	return nil
+	return unstructured.SetNestedMap(obj.UnstructuredContent(), unstructuredPodSpec, pm.podSpecPath...)
 }
 
-// resolveDownwardAPIFieldRefEnv replaces the downwardAPI FieldRef EnvVars with the value from the deployment, right now it only replaces the metadata.namespace
-func resolveDownwardAPIFieldRefEnv(envs []corev1.EnvVar, deployment appsv1.Deployment) []corev1.EnvVar {
+// resolveDownwardAPIFieldRefEnv replaces the downwardAPI FieldRef EnvVars with the value from the workload, right now it only replaces the metadata.namespace
+func resolveDownwardAPIFieldRefEnv(envs []corev1.EnvVar, namespace string) []corev1.EnvVar {
 	var result []corev1.EnvVar
 	for _, env := range envs {
 		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.FieldPath == "metadata.namespace" {
 			result = append(result, corev1.EnvVar{
 				Name:  env.Name,
-				Value: deployment.Namespace,
+				Value: namespace,
 			})
 		} else {
 			result = append(result, env)
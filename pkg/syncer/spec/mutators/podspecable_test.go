@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilspointer "k8s.io/utils/pointer"
+)
+
+func TestPodSpecableMutate(t *testing.T) {
+	originalPodSpec := func() corev1.PodSpec {
+		return corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "test-container",
+					Image: "test-image",
+					Env: []corev1.EnvVar{
+						{
+							Name: "NAMESPACE",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	expectedPodSpec := func() corev1.PodSpec {
+		return corev1.PodSpec{
+			AutomountServiceAccountToken: utilspointer.BoolPtr(false),
+			Containers: []corev1.Container{
+				{
+					Name:  "test-container",
+					Image: "test-image",
+					Env: []corev1.EnvVar{
+						{Name: "NAMESPACE", Value: "namespace"},
+						{Name: "KUBERNETES_SERVICE_PORT", Value: "12345"},
+						{Name: "KUBERNETES_SERVICE_PORT_HTTPS", Value: "12345"},
+						{Name: "KUBERNETES_SERVICE_HOST", Value: "4.5.6.7"},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						kcpApiAccessVolumeMount,
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				kcpApiAccessVolume,
+			},
+		}
+	}
+	objectMeta := metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   "namespace",
+		ClusterName: "root:default:testing",
+	}
+
+	tests := map[string]struct {
+		gvr      schema.GroupVersionResource
+		original runtime.Object
+		expected runtime.Object
+		got      runtime.Object
+	}{
+		"StatefulSet pod template is mutated": {
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+			original: &appsv1.StatefulSet{
+				TypeMeta:   metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+				ObjectMeta: objectMeta,
+				Spec:       appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: originalPodSpec()}},
+			},
+			expected: &appsv1.StatefulSet{
+				TypeMeta:   metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+				ObjectMeta: objectMeta,
+				Spec:       appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: expectedPodSpec()}},
+			},
+			got: &appsv1.StatefulSet{},
+		},
+		"CronJob job template is mutated": {
+			gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"},
+			original: &batchv1.CronJob{
+				TypeMeta:   metav1.TypeMeta{Kind: "CronJob", APIVersion: "batch/v1"},
+				ObjectMeta: objectMeta,
+				Spec: batchv1.CronJobSpec{
+					Schedule:    "* * * * *",
+					JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: originalPodSpec()}}},
+				},
+			},
+			expected: &batchv1.CronJob{
+				TypeMeta:   metav1.TypeMeta{Kind: "CronJob", APIVersion: "batch/v1"},
+				ObjectMeta: objectMeta,
+				Spec: batchv1.CronJobSpec{
+					Schedule:    "* * * * *",
+					JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: expectedPodSpec()}}},
+				},
+			},
+			got: &batchv1.CronJob{},
+		},
+		"bare Pod is mutated": {
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			original: &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: objectMeta,
+				Spec:       originalPodSpec(),
+			},
+			expected: &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: objectMeta,
+				Spec:       expectedPodSpec(),
+			},
+			got: &corev1.Pod{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			upstreamURL, err := url.Parse("https://4.5.6.7:12345")
+			require.NoError(t, err)
+
+			secret, err := toUnstructured(&corev1.Secret{
+				TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default-token-1234",
+					Namespace:   "namespace",
+					ClusterName: "root:default:testing",
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: "default",
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			mutators := NewMutators(MutatorOptions{
+				UpstreamURL: upstreamURL,
+				ListSecrets: func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error) {
+					return []*unstructured.Unstructured{secret}, nil
+				},
+			})[tc.gvr]
+			require.Len(t, mutators, 1)
+
+			obj, err := toUnstructured(tc.original)
+			require.NoError(t, err)
+			require.NoError(t, mutators[0].Mutate(obj))
+
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), tc.got))
+			if !apiequality.Semantic.DeepEqual(tc.expected, tc.got) {
+				t.Errorf("expected objects are not equal, got:\n %#v \n wanted:\n %#v \n", tc.got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	require.Panics(t, func() {
+		Register("kcp.dev/secrets", func(options MutatorOptions) []Mutator { return nil })
+	}, "registering a factory twice under the same name should panic")
+}
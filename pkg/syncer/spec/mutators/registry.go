@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Mutator transforms the objects of a resource type before they are applied downstream by the spec syncer.
+type Mutator interface {
+	GVR() schema.GroupVersionResource
+	Mutate(obj *unstructured.Unstructured) error
+}
+
+// MutatorOptions holds what the spec syncer provides to create mutators.
+type MutatorOptions struct {
+	// UpstreamURL is the URL of kcp, as it should be reached from the physical cluster.
+	UpstreamURL *url.URL
+	// ListSecrets lists the upstream secrets of a namespace in a workspace.
+	ListSecrets ListSecretFunc
+	// ListDownstreamIngresses lists the ingresses synced to the physical cluster.
+	ListDownstreamIngresses ListDownstreamIngressesFunc
+	// DownstreamNamespace returns the namespace of the physical cluster a namespace of a workspace is synced to.
+	DownstreamNamespace DownstreamNamespaceFunc
+}
+
+// MutatorFactory creates the mutators of a spec syncer.
+type MutatorFactory func(options MutatorOptions) []Mutator
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]MutatorFactory{}
+)
+
+func init() {
+	Register("kcp.dev/podspecable", func(options MutatorOptions) []Mutator {
+		return newPodSpecableMutators(options.UpstreamURL, options.ListSecrets)
+	})
+	Register("kcp.dev/secrets", func(options MutatorOptions) []Mutator {
+		return []Mutator{NewSecretMutator()}
+	})
+	Register("kcp.dev/services", func(options MutatorOptions) []Mutator {
+		return []Mutator{NewServiceMutator(), NewEndpointsMutator()}
+	})
+	Register("kcp.dev/ingresses", func(options MutatorOptions) []Mutator {
+		return []Mutator{NewIngressMutator(options.ListDownstreamIngresses, options.DownstreamNamespace)}
+	})
+}
+
+// Register registers a factory of mutators under the given name. Out-of-tree mutators are
+// compiled into a custom syncer binary by calling Register from an init function of a package
+// imported by the binary. Register panics if a factory is already registered under the name.
+func Register(name string, factory MutatorFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("a mutator factory is already registered under the name %q", name))
+	}
+	registry[name] = factory
+}
+
+// NewMutators creates the mutators of all the registered factories, grouped by resource type.
+// Mutators of the same resource type are ordered by the name of their factory.
+func NewMutators(options MutatorOptions) map[schema.GroupVersionResource][]Mutator {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mutators := map[schema.GroupVersionResource][]Mutator{}
+	for _, name := range names {
+		for _, mutator := range registry[name](options) {
+			mutators[mutator.GVR()] = append(mutators[mutator.GVR()], mutator)
+		}
+	}
+	return mutators
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServiceMutator removes the fields of a Service that are allocated by the cluster, so that they are
+// allocated by the physical cluster instead of conflicting with the values copied from kcp.
+type ServiceMutator struct {
+}
+
+func (sm *ServiceMutator) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "services",
+	}
+}
+
+func NewServiceMutator() *ServiceMutator {
+	return &ServiceMutator{}
+}
+
+// Mutate applies the mutator changes to the object.
+func (sm *ServiceMutator) Mutate(obj *unstructured.Unstructured) error {
+	// Headless services keep their "None" cluster IP, as it is not allocated but requested.
+	clusterIP, _, err := unstructured.NestedString(obj.Object, "spec", "clusterIP")
+	if err != nil {
+		return err
+	}
+	if clusterIP != corev1.ClusterIPNone {
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+	}
+	unstructured.RemoveNestedField(obj.Object, "spec", "healthCheckNodePort")
+	unstructured.RemoveNestedField(obj.Object, "spec", "loadBalancerIP")
+
+	ports, found, err := unstructured.NestedSlice(obj.Object, "spec", "ports")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	for _, port := range ports {
+		if port, ok := port.(map[string]interface{}); ok {
+			delete(port, "nodePort")
+		}
+	}
+	return unstructured.SetNestedSlice(obj.Object, ports, "spec", "ports")
+}
+
+// EndpointsMutator removes the references of the addresses of an Endpoints to the upstream objects,
+// as their UIDs and resource versions are meaningless in the physical cluster.
+type EndpointsMutator struct {
+}
+
+func (em *EndpointsMutator) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "endpoints",
+	}
+}
+
+func NewEndpointsMutator() *EndpointsMutator {
+	return &EndpointsMutator{}
+}
+
+// Mutate applies the mutator changes to the object.
+func (em *EndpointsMutator) Mutate(obj *unstructured.Unstructured) error {
+	subsets, found, err := unstructured.NestedSlice(obj.Object, "subsets")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	for _, subset := range subsets {
+		subset, ok := subset.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"addresses", "notReadyAddresses"} {
+			addresses, ok := subset[field].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, address := range addresses {
+				address, ok := address.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if targetRef, ok := address["targetRef"].(map[string]interface{}); ok {
+					delete(targetRef, "uid")
+					delete(targetRef, "resourceVersion")
+				}
+			}
+		}
+	}
+	return unstructured.SetNestedSlice(obj.Object, subsets, "subsets")
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestServiceMutate(t *testing.T) {
+	for _, c := range []struct {
+		desc                             string
+		originalService, expectedService *corev1.Service
+	}{{
+		desc: "Allocated fields of a NodePort service are removed",
+		originalService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				Type:       corev1.ServiceTypeNodePort,
+				ClusterIP:  "10.0.0.10",
+				ClusterIPs: []string{"10.0.0.10"},
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80, NodePort: 30080},
+					{Name: "https", Port: 443, NodePort: 30443},
+				},
+			},
+		},
+		expectedService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeNodePort,
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80},
+					{Name: "https", Port: 443},
+				},
+			},
+		},
+	}, {
+		desc: "Allocated fields of a LoadBalancer service are removed",
+		originalService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ClusterIP:             "10.0.0.10",
+				LoadBalancerIP:        "1.2.3.4",
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				HealthCheckNodePort:   31000,
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+			},
+		},
+		expectedService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				Ports:                 []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		},
+	}, {
+		desc: "A headless service keeps its cluster IP",
+		originalService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:  corev1.ClusterIPNone,
+				ClusterIPs: []string{corev1.ClusterIPNone},
+				Ports:      []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		},
+		expectedService: &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:  corev1.ClusterIPNone,
+				ClusterIPs: []string{corev1.ClusterIPNone},
+				Ports:      []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			sm := NewServiceMutator()
+			unstrOriginalService, err := toUnstructured(c.originalService)
+			require.NoError(t, err)
+
+			require.NoError(t, sm.Mutate(unstrOriginalService))
+
+			got := &corev1.Service{}
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(unstrOriginalService.UnstructuredContent(), got))
+			if !apiequality.Semantic.DeepEqual(c.expectedService, got) {
+				t.Errorf("expected service is not equal, got:\n %#v \n wanted:\n %#v \n", got, c.expectedService)
+			}
+		})
+	}
+}
+
+func TestEndpointsMutate(t *testing.T) {
+	original := &corev1.Endpoints{
+		TypeMeta:   metav1.TypeMeta{Kind: "Endpoints", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{
+				IP:        "10.1.0.1",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "pod-1", UID: "upstream-uid", ResourceVersion: "42"},
+			}},
+			NotReadyAddresses: []corev1.EndpointAddress{{
+				IP:        "10.1.0.2",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "pod-2", UID: "upstream-uid-2", ResourceVersion: "43"},
+			}},
+			Ports: []corev1.EndpointPort{{Name: "http", Port: 8080}},
+		}},
+	}
+	expected := &corev1.Endpoints{
+		TypeMeta:   metav1.TypeMeta{Kind: "Endpoints", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{
+				IP:        "10.1.0.1",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "pod-1"},
+			}},
+			NotReadyAddresses: []corev1.EndpointAddress{{
+				IP:        "10.1.0.2",
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "pod-2"},
+			}},
+			Ports: []corev1.EndpointPort{{Name: "http", Port: 8080}},
+		}},
+	}
+
+	obj, err := toUnstructured(original)
+	require.NoError(t, err)
+	require.NoError(t, NewEndpointsMutator().Mutate(obj))
+
+	got := &corev1.Endpoints{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), got))
+	if !apiequality.Semantic.DeepEqual(expected, got) {
+		t.Errorf("expected endpoints are not equal, got:\n %#v \n wanted:\n %#v \n", got, expected)
+	}
+}
@@ -21,10 +21,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,11 +34,14 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
@@ -48,14 +53,34 @@ const (
 	byWorkspaceAndNamespaceIndexName = "syncer-spec-WorkspaceNamespace" // will go away with scoping
 )
 
+var (
+	namespaceGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	secretsGVR   = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+	ingressesGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+)
+
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
 	mutators mutatorGvrMap
 
-	upstreamClient                         dynamic.ClusterInterface
-	downstreamClient                       dynamic.Interface
-	upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory
+	upstreamClient             dynamic.ClusterInterface
+	downstreamClient           dynamic.Interface
+	syncerInformers            *resourcesync.SyncerInformerFactory
+	upstreamNamespaceIndexer   cache.Indexer
+	downstreamNamespaceIndexer cache.Indexer
+	downstreamNamespaceLister  cache.GenericLister
+	namespaceOptions           NamespaceOptions
+	// specOverrides returns the spec overrides applied to the synced objects. It is nil if there are none.
+	specOverrides SpecOverridesFunc
+
+	// drifts are the downstream modifications made by other field managers than the syncer, by upstream object.
+	driftLock sync.Mutex
+	drifts    map[queueKey]drift
+
+	// changesObservedAt are the times at which the upstream changes not propagated downstream yet were first observed.
+	changesLock       sync.Mutex
+	changesObservedAt map[queueKey]time.Time
 
 	syncTargetName            string
 	syncTargetClusterName     logicalcluster.Name
@@ -63,16 +88,26 @@ type Controller struct {
 	advancedSchedulingEnabled bool
 }
 
-func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName logicalcluster.Name, syncTargetName string, upstreamURL *url.URL, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+func NewSpecSyncer(syncTargetClusterName logicalcluster.Name, syncTargetName string, upstreamURL *url.URL, advancedSchedulingEnabled bool,
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, syncerInformers *resourcesync.SyncerInformerFactory, syncTargetUID types.UID,
+	namespaceOptions NamespaceOptions, specOverrides SpecOverridesFunc) (*Controller, error) {
+
+	upstreamNamespaceInformer := syncerInformers.UpstreamNamespaceInformer()
+	downstreamNamespaceInformer := syncerInformers.DownstreamNamespaceInformer()
 
 	c := Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 
-		upstreamClient:      upstreamClient,
-		downstreamClient:    downstreamClient,
-		upstreamInformers:   upstreamInformers,
-		downstreamInformers: downstreamInformers,
+		upstreamClient:             upstreamClient,
+		downstreamClient:           downstreamClient,
+		syncerInformers:            syncerInformers,
+		upstreamNamespaceIndexer:   upstreamNamespaceInformer.GetIndexer(),
+		downstreamNamespaceIndexer: downstreamNamespaceInformer.GetIndexer(),
+		downstreamNamespaceLister:  cache.NewGenericLister(downstreamNamespaceInformer.GetIndexer(), namespaceGVR.GroupResource()),
+		namespaceOptions:           namespaceOptions,
+		specOverrides:              specOverrides,
+		drifts:                     map[queueKey]drift{},
+		changesObservedAt:          map[queueKey]time.Time{},
 
 		syncTargetName:            syncTargetName,
 		syncTargetClusterName:     syncTargetClusterName,
@@ -80,102 +115,234 @@ func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName log
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
 	}
 
-	namespaceGVR := schema.GroupVersionResource{
-		Group:    "",
-		Version:  "v1",
-		Resource: "namespaces",
-	}
-	namespaceLister := downstreamInformers.ForResource(namespaceGVR).Lister()
-
-	err := downstreamInformers.ForResource(namespaceGVR).Informer().AddIndexers(cache.Indexers{byNamespaceLocatorIndexName: indexByNamespaceLocator})
+	err := downstreamNamespaceInformer.AddIndexers(cache.Indexers{byNamespaceLocatorIndexName: indexByNamespaceLocator})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, gvr := range gvrs {
-		gvr := gvr // because used in closure
+	upstreamNamespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.AddToQueue(namespaceGVR, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNamespace := oldObj.(*unstructured.Unstructured)
+			newNamespace := newObj.(*unstructured.Unstructured)
+
+			if !equality.Semantic.DeepEqual(oldNamespace.GetLabels(), newNamespace.GetLabels()) ||
+				!equality.Semantic.DeepEqual(oldNamespace.GetAnnotations(), newNamespace.GetAnnotations()) {
+				c.AddToQueue(namespaceGVR, newNamespace)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.AddToQueue(namespaceGVR, obj)
+		},
+	})
+
+	// Downstream namespaces are repaired when their labels or annotations are modified, and recreated when deleted,
+	// as long as their upstream namespace still exists.
+	downstreamNamespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNamespace := oldObj.(*unstructured.Unstructured)
+			newNamespace := newObj.(*unstructured.Unstructured)
+
+			if !equality.Semantic.DeepEqual(oldNamespace.GetLabels(), newNamespace.GetLabels()) ||
+				!equality.Semantic.DeepEqual(oldNamespace.GetAnnotations(), newNamespace.GetAnnotations()) {
+				c.enqueueFromNamespaceLocator(oldNamespace)
+				c.enqueueFromNamespaceLocator(newNamespace)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueFromNamespaceLocator(obj)
+		},
+	})
 
-		upstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				oldUnstrob := oldObj.(*unstructured.Unstructured)
-				newUnstrob := newObj.(*unstructured.Unstructured)
+	syncerInformers.AddUpstreamEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueUpstreamChange(gvr, obj)
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			oldUnstrob := oldObj.(*unstructured.Unstructured)
+			newUnstrob := newObj.(*unstructured.Unstructured)
 
-				if !deepEqualApartFromStatus(oldUnstrob, newUnstrob) {
-					c.AddToQueue(gvr, newUnstrob)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-		})
-		klog.V(2).InfoS("Set up upstream informer", "clusterName", syncTargetClusterName, "pcluster", syncTargetName, "gvr", gvr.String())
-
-		downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			DeleteFunc: func(obj interface{}) {
-				key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
-				if err != nil {
-					utilruntime.HandleError(fmt.Errorf("error getting key for type %T: %w", obj, err))
-					return
-				}
-				namespace, name, err := cache.SplitMetaNamespaceKey(key)
-				if err != nil {
-					utilruntime.HandleError(fmt.Errorf("error splitting key %q: %w", key, err))
-				}
-				klog.V(3).InfoS("processing  delete event", "key", key, "gvr", gvr, "namespace", namespace, "name", name)
+			if !deepEqualApartFromStatus(oldUnstrob, newUnstrob) {
+				c.enqueueUpstreamChange(gvr, newUnstrob)
+			}
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueUpstreamChange(gvr, obj)
+		},
+	})
+	klog.V(2).InfoS("Set up upstream event handlers", "clusterName", syncTargetClusterName, "pcluster", syncTargetName)
 
-				// Use namespace lister
-				nsObj, err := namespaceLister.Get(namespace)
-				if err != nil {
-					utilruntime.HandleError(err)
-					return
-				}
-				ns, ok := nsObj.(*unstructured.Unstructured)
-				if !ok {
-					utilruntime.HandleError(fmt.Errorf("unexpected object type: %T", nsObj))
-					return
-				}
-				locator, ok := ns.GetAnnotations()[shared.NamespaceLocatorAnnotation]
-				if !ok {
-					utilruntime.HandleError(fmt.Errorf("unable to find the locator annotation in namespace %s", namespace))
-					return
-				}
-				nsLocator := &shared.NamespaceLocator{}
-				err = json.Unmarshal([]byte(locator), nsLocator)
-				if err != nil {
-					utilruntime.HandleError(err)
-					return
-				}
-				klog.V(4).InfoS("found", "NamespaceLocator", nsLocator)
-				m := &metav1.ObjectMeta{
-					ClusterName: nsLocator.Workspace.String(),
-					Namespace:   nsLocator.Namespace,
-					Name:        name,
-				}
-				c.AddToQueue(gvr, m)
-			},
-		})
-		klog.V(2).InfoS("Set up downstream informer", "clusterName", syncTargetClusterName, "pcluster", syncTargetName, "gvr", gvr.String())
+	syncerInformers.AddDownstreamEventHandler(informer.GVREventHandlerFuncs{
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			c.enqueueDownstreamDrift(gvr, oldObj.(*unstructured.Unstructured), newObj.(*unstructured.Unstructured))
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueDownstreamDeletion(gvr, obj)
+		},
+	})
+	klog.V(2).InfoS("Set up downstream event handlers", "clusterName", syncTargetClusterName, "pcluster", syncTargetName)
+
+	if err := syncerInformers.AddUpstreamIndexers(cache.Indexers{
+		byWorkspaceAndNamespaceIndexName: indexByWorkspaceAndNamespace,
+	}); err != nil {
+		return nil, err
 	}
+	c.mutators = newMutatorGvrMap(specmutators.NewMutators(specmutators.MutatorOptions{
+		UpstreamURL:             upstreamURL,
+		ListSecrets:             newSecretLister(syncerInformers),
+		ListDownstreamIngresses: newDownstreamIngressLister(syncerInformers),
+		DownstreamNamespace: func(clusterName logicalcluster.Name, namespace string) (string, error) {
+			return c.downstreamNamespaceName(shared.NewNamespaceLocator(clusterName, syncTargetClusterName, syncTargetUID, syncTargetName, namespace))
+		},
+	}))
 
-	secretMutator := specmutators.NewSecretMutator()
+	return &c, nil
+}
 
-	upstreamSecretIndexer := upstreamInformers.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}).Informer().GetIndexer()
-	deploymentMutator := specmutators.NewDeploymentMutator(upstreamURL, newSecretLister(upstreamSecretIndexer))
+// upstreamObjectMeta returns the logical cluster, namespace and name of the upstream object of a downstream object.
+// Cluster-scoped objects point to their upstream object through the resource locator, and namespaced objects
+// through the namespace locator of their namespace.
+func (c *Controller) upstreamObjectMeta(downstreamObj metav1.Object) (*metav1.ObjectMeta, error) {
+	if downstreamObj.GetNamespace() == "" {
+		locator, exists, err := shared.ResourceLocatorFromAnnotations(downstreamObj.GetAnnotations())
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("unable to find the locator annotation in cluster-scoped object %s", downstreamObj.GetName())
+		}
+		klog.V(4).InfoS("found", "ResourceLocator", locator)
+		return &metav1.ObjectMeta{
+			ClusterName: locator.Workspace.String(),
+			Name:        locator.Name,
+		}, nil
+	}
 
-	if err := upstreamSecretIndexer.AddIndexers(cache.Indexers{
-		byWorkspaceAndNamespaceIndexName: indexByWorkspaceAndNamespace,
-	}); err != nil {
+	nsObj, err := c.downstreamNamespaceLister.Get(downstreamObj.GetNamespace())
+	if err != nil {
 		return nil, err
 	}
-	c.mutators = mutatorGvrMap{
-		deploymentMutator.GVR(): deploymentMutator.Mutate,
-		secretMutator.GVR():     secretMutator.Mutate,
+	ns, ok := nsObj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type: %T", nsObj)
+	}
+	locator, ok := ns.GetAnnotations()[shared.NamespaceLocatorAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("unable to find the locator annotation in namespace %s", ns.GetName())
+	}
+	nsLocator := &shared.NamespaceLocator{}
+	if err := json.Unmarshal([]byte(locator), nsLocator); err != nil {
+		return nil, err
 	}
+	klog.V(4).InfoS("found", "NamespaceLocator", nsLocator)
+	return &metav1.ObjectMeta{
+		ClusterName: nsLocator.Workspace.String(),
+		Namespace:   nsLocator.Namespace,
+		Name:        downstreamObj.GetName(),
+	}, nil
+}
 
-	return &c, nil
+// enqueueDownstreamDrift queues the upstream object of a downstream object modified by other field managers than
+// the syncer, so that the desired state is re-applied.
+func (c *Controller) enqueueDownstreamDrift(gvr schema.GroupVersionResource, oldObj, newObj *unstructured.Unstructured) {
+	fieldManagers := driftingFieldManagers(oldObj, newObj)
+	if fieldManagers.Len() == 0 {
+		return
+	}
+	m, err := c.upstreamObjectMeta(newObj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if !c.syncerInformers.ServesWorkspace(logicalcluster.New(m.ClusterName)) {
+		klog.V(4).InfoS("Ignoring drift of downstream object synced from a workspace served through another syncer virtual workspace", "gvr", gvr, "namespace", newObj.GetNamespace(), "name", newObj.GetName(), "workspace", m.ClusterName)
+		return
+	}
+	klog.V(3).InfoS("Downstream object modified by other field managers", "gvr", gvr, "namespace", newObj.GetNamespace(), "name", newObj.GetName(), "fieldManagers", fieldManagers.List())
+
+	key, err := cache.MetaNamespaceKeyFunc(m)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.recordDrift(queueKey{gvr: gvr, key: key}, drift{
+		fieldManagers:   fieldManagers,
+		resourceVersion: newObj.GetResourceVersion(),
+	})
+	c.AddToQueue(gvr, m)
+}
+
+// enqueueDownstreamDeletion queues the upstream object of a deleted downstream object, so that it is recreated
+// if the upstream object still exists, and the downstream namespace, which might be deleted once empty.
+func (c *Controller) enqueueDownstreamDeletion(gvr schema.GroupVersionResource, obj interface{}) {
+	key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error getting key for type %T: %w", obj, err))
+		return
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("error splitting key %q: %w", key, err))
+	}
+	klog.V(3).InfoS("processing  delete event", "key", key, "gvr", gvr, "namespace", namespace, "name", name)
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj))
+		return
+	}
+	m, err := c.upstreamObjectMeta(metaObj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if !c.syncerInformers.ServesWorkspace(logicalcluster.New(m.ClusterName)) {
+		klog.V(4).InfoS("Ignoring deletion of downstream object synced from a workspace served through another syncer virtual workspace", "gvr", gvr, "namespace", namespace, "name", name, "workspace", m.ClusterName)
+		return
+	}
+	c.AddToQueue(gvr, m)
+	if m.Namespace != "" {
+		// The downstream namespace might be deleted once its last synced object is gone.
+		c.AddToQueue(namespaceGVR, &metav1.ObjectMeta{
+			ClusterName: m.ClusterName,
+			Name:        m.Namespace,
+		})
+	}
+}
+
+func (c *Controller) enqueueFromNamespaceLocator(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj))
+		return
+	}
+	if metaObj.GetLabels()[workloadv1alpha1.InternalDownstreamClusterLabel] != c.syncTargetName {
+		return
+	}
+	locator, exists, err := shared.LocatorFromAnnotations(metaObj.GetAnnotations())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if !exists {
+		return
+	}
+	if !c.syncerInformers.ServesWorkspace(locator.Workspace) {
+		// The namespace is handled by the syncers of another syncer virtual workspace URL.
+		return
+	}
+	c.AddToQueue(namespaceGVR, &metav1.ObjectMeta{
+		ClusterName: locator.Workspace.String(),
+		Name:        locator.Namespace,
+	})
 }
 
 type queueKey struct {
@@ -199,6 +366,48 @@ func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}
 	)
 }
 
+// EnqueueAllUpstream queues all the upstream objects of the synced resource types, to sync them
+// again when something they are synced with changed, like their spec overrides.
+func (c *Controller) EnqueueAllUpstream() {
+	for _, gvr := range c.syncerInformers.SyncedGVRs() {
+		informers, ok := c.syncerInformers.InformerForResource(gvr)
+		if !ok {
+			continue
+		}
+		for _, obj := range informers.UpstreamInformer.GetIndexer().List() {
+			c.AddToQueue(gvr, obj)
+		}
+	}
+}
+
+// enqueueUpstreamChange queues an upstream object, and records when its change was observed
+// to measure the delay until it is propagated downstream.
+func (c *Controller) enqueueUpstreamChange(gvr schema.GroupVersionResource, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	c.changesLock.Lock()
+	if _, ok := c.changesObservedAt[queueKey{gvr: gvr, key: key}]; !ok {
+		c.changesObservedAt[queueKey{gvr: gvr, key: key}] = time.Now()
+	}
+	c.changesLock.Unlock()
+
+	c.AddToQueue(gvr, obj)
+}
+
+// popChangeObservedAt returns when the pending upstream change of an object was first observed, and forgets it.
+func (c *Controller) popChangeObservedAt(key queueKey) (time.Time, bool) {
+	c.changesLock.Lock()
+	defer c.changesLock.Unlock()
+
+	observedAt, ok := c.changesObservedAt[key]
+	delete(c.changesObservedAt, key)
+	return observedAt, ok
+}
+
 // Start starts N worker processes processing work items.
 func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer utilruntime.HandleCrash()
@@ -231,20 +440,47 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+	start := time.Now()
+	err := c.process(ctx, qk.gvr, qk.key)
+	metrics.RecordProcessed(metrics.SpecSyncer, qk.gvr, start, err)
+	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
 	}
 
 	c.queue.Forget(key)
+	if observedAt, ok := c.popChangeObservedAt(qk); ok {
+		metrics.RecordPropagationDelay(qk.gvr, observedAt)
+	}
 
 	return true
 }
 
-func newSecretLister(secretIndexer cache.Indexer) specmutators.ListSecretFunc {
+// newMutatorGvrMap chains the mutators of each resource type into a single mutation function.
+func newMutatorGvrMap(mutators map[schema.GroupVersionResource][]specmutators.Mutator) mutatorGvrMap {
+	m := make(mutatorGvrMap, len(mutators))
+	for gvr, gvrMutators := range mutators {
+		gvrMutators := gvrMutators
+		m[gvr] = func(obj *unstructured.Unstructured) error {
+			for _, mutator := range gvrMutators {
+				if err := mutator.Mutate(obj); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return m
+}
+
+func newSecretLister(syncerInformers *resourcesync.SyncerInformerFactory) specmutators.ListSecretFunc {
 	return func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, error) {
-		secretList, err := secretIndexer.ByIndex(byWorkspaceAndNamespaceIndexName, workspaceAndNamespaceIndexKey(clusterName, namespace))
+		secretInformers, ok := syncerInformers.InformerForResource(secretsGVR)
+		if !ok {
+			return nil, fmt.Errorf("secrets are not synced for workspace %s", clusterName)
+		}
+		secretList, err := secretInformers.UpstreamInformer.GetIndexer().ByIndex(byWorkspaceAndNamespaceIndexName, workspaceAndNamespaceIndexKey(clusterName, namespace))
 		if err != nil {
 			return nil, fmt.Errorf("error listing secrets for workspace %s: %w", clusterName, err)
 		}
@@ -257,6 +493,21 @@ func newSecretLister(secretIndexer cache.Indexer) specmutators.ListSecretFunc {
 	}
 }
 
+func newDownstreamIngressLister(syncerInformers *resourcesync.SyncerInformerFactory) specmutators.ListDownstreamIngressesFunc {
+	return func() ([]*unstructured.Unstructured, error) {
+		ingressInformers, ok := syncerInformers.InformerForResource(ingressesGVR)
+		if !ok {
+			return nil, fmt.Errorf("ingresses are not synced")
+		}
+		ingressList := ingressInformers.DownstreamInformer.GetIndexer().List()
+		ingresses := make([]*unstructured.Unstructured, 0, len(ingressList))
+		for _, elem := range ingressList {
+			ingresses = append(ingresses, elem.(*unstructured.Unstructured))
+		}
+		return ingresses, nil
+	}
+}
+
 func indexByWorkspaceAndNamespace(obj interface{}) ([]string, error) {
 	metaObj, ok := obj.(metav1.Object)
 	if !ok {
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// TestDownstreamEventsOfOtherVirtualWorkspaces checks that the syncers of two syncer virtual workspace URLs,
+// sharing the same downstream cluster, only handle the downstream objects of the workspaces they serve.
+func TestDownstreamEventsOfOtherVirtualWorkspaces(t *testing.T) {
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	syncTargetClusterName := logicalcluster.New("root:org")
+	syncTargetUID := types.UID("syncTargetUID")
+
+	downstreamNamespace := func(name, workspace string) *unstructured.Unstructured {
+		locator, err := json.Marshal(shared.NewNamespaceLocator(logicalcluster.New(workspace), syncTargetClusterName, syncTargetUID, "us-west1", "test"))
+		require.NoError(t, err)
+		return toUnstructured(t, namespace(name, "",
+			map[string]string{
+				"internal.workload.kcp.dev/cluster": "us-west1",
+			},
+			map[string]string{
+				shared.NamespaceLocatorAnnotation: string(locator),
+			}))
+	}
+	downstreamDeployment := func(namespace string, fieldManagers ...string) *unstructured.Unstructured {
+		obj := toUnstructured(t, deployment("theDeployment", namespace, "", map[string]string{
+			"internal.workload.kcp.dev/cluster": "us-west1",
+		}, nil, nil))
+		entries := []metav1.ManagedFieldsEntry{{Manager: syncerApplyManager, Operation: metav1.ManagedFieldsOperationApply}}
+		for _, manager := range fieldManagers {
+			entries = append(entries, metav1.ManagedFieldsEntry{Manager: manager, Operation: metav1.ManagedFieldsOperationUpdate})
+		}
+		obj.SetManagedFields(entries)
+		return obj
+	}
+
+	tests := map[string]struct {
+		event func(c *Controller, namespace string)
+		// expectQueued is the number of keys queued by the syncers of the virtual workspace URL serving the workspace.
+		expectQueued int
+	}{
+		"drift of a downstream object": {
+			event: func(c *Controller, namespace string) {
+				c.enqueueDownstreamDrift(deploymentsGVR, downstreamDeployment(namespace), downstreamDeployment(namespace, "kubectl"))
+			},
+			expectQueued: 1,
+		},
+		"deletion of a downstream object": {
+			event: func(c *Controller, namespace string) {
+				c.enqueueDownstreamDeletion(deploymentsGVR, downstreamDeployment(namespace))
+			},
+			expectQueued: 2,
+		},
+		"modification of a downstream namespace": {
+			event: func(c *Controller, namespace string) {
+				obj, err := c.downstreamNamespaceLister.Get(namespace)
+				require.NoError(t, err)
+				c.enqueueFromNamespaceLocator(obj)
+			},
+			expectQueued: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			downstreamClient := dynamicfake.NewSimpleDynamicClient(scheme,
+				downstreamNamespace("kcp-a", "root:org:ws-a"),
+				downstreamNamespace("kcp-b", "root:org:ws-b"),
+			)
+			upstreamURL, err := url.Parse("https://kcp.dev:6443")
+			require.NoError(t, err)
+
+			// Each syncer virtual workspace URL serves one of the workspaces.
+			controllers := map[string]*Controller{}
+			for _, workspace := range []string{"root:org:ws-a", "root:org:ws-b"} {
+				upstreamClient := dynamicfake.NewSimpleDynamicClient(scheme,
+					namespace("test", workspace, map[string]string{"state.workload.kcp.dev/us-west1": "Sync"}, nil),
+				)
+				upstreamClusterClient := &mockedDynamicCluster{client: upstreamClient}
+				syncerInformers := resourcesync.NewSyncerInformerFactory(upstreamClusterClient.Cluster(logicalcluster.Wildcard), downstreamClient, fakeDiscovery(deploymentsGVR),
+					"us-west1", sets.NewString(deploymentsGVR.GroupResource().String()), sets.NewString(), resourcesync.DiscoveryOptions{PollInterval: time.Hour})
+
+				controller, err := NewSpecSyncer(syncTargetClusterName, "us-west1", upstreamURL, false, upstreamClusterClient, downstreamClient, syncerInformers, syncTargetUID, NamespaceOptions{}, nil)
+				require.NoError(t, err)
+
+				require.NoError(t, syncerInformers.Start(ctx))
+				syncerInformers.WaitForCacheSync(ctx.Done())
+
+				// Drop the upstream namespace queued by the initial list.
+				require.Eventually(t, func() bool { return controller.queue.Len() == 1 }, wait.ForeverTestTimeout, 100*time.Millisecond)
+				key, _ := controller.queue.Get()
+				controller.queue.Done(key)
+				controller.queue.Forget(key)
+
+				controllers[workspace] = controller
+			}
+
+			tc.event(controllers["root:org:ws-a"], "kcp-b")
+			require.Equal(t, 0, controllers["root:org:ws-a"].queue.Len(), "the syncers of another virtual workspace URL should ignore the event")
+
+			tc.event(controllers["root:org:ws-b"], "kcp-b")
+			require.Equal(t, tc.expectQueued, controllers["root:org:ws-b"].queue.Len(), "the syncers serving the workspace should handle the event")
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// DriftCorrection is the value of the experimental.drift.workload.kcp.dev/<sync-target-name> annotation.
+type DriftCorrection struct {
+	// FieldManagers are the field managers that modified the downstream object.
+	FieldManagers []string `json:"fieldManagers"`
+	// CorrectionTime is the time when the desired state was re-applied to the downstream object.
+	CorrectionTime metav1.Time `json:"correctionTime"`
+}
+
+// drift records the modifications of a downstream object by other field managers than the syncer.
+type drift struct {
+	fieldManagers sets.String
+	// resourceVersion is the resource version of the downstream object after it has been modified.
+	resourceVersion string
+	// corrected is true when the desired state has already been re-applied, but the correction could
+	// not be reported upstream.
+	corrected bool
+}
+
+// driftingFieldManagers returns the field managers, other than the syncer, that modified the
+// downstream object between oldObj and newObj. Status updates are not considered as drift.
+func driftingFieldManagers(oldObj, newObj *unstructured.Unstructured) sets.String {
+	managers := sets.NewString()
+	oldEntries := oldObj.GetManagedFields()
+	for _, entry := range newObj.GetManagedFields() {
+		if entry.Manager == syncerApplyManager || entry.Subresource != "" {
+			continue
+		}
+		found := false
+		for _, oldEntry := range oldEntries {
+			if equality.Semantic.DeepEqual(entry, oldEntry) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			managers.Insert(entry.Manager)
+		}
+	}
+	return managers
+}
+
+// recordDrift remembers that the downstream object of the upstream object with the given key has drifted
+// from the desired state, so that the correction can be reported upstream once the desired state is re-applied.
+func (c *Controller) recordDrift(key queueKey, d drift) {
+	c.driftLock.Lock()
+	defer c.driftLock.Unlock()
+
+	if existing, ok := c.drifts[key]; ok {
+		d.fieldManagers = d.fieldManagers.Union(existing.fieldManagers)
+		d.corrected = d.corrected || existing.corrected
+	}
+	c.drifts[key] = d
+}
+
+// popDrift returns and forgets the drift recorded for the upstream object with the given key, if any.
+func (c *Controller) popDrift(key queueKey) (drift, bool) {
+	c.driftLock.Lock()
+	defer c.driftLock.Unlock()
+
+	d, ok := c.drifts[key]
+	delete(c.drifts, key)
+	return d, ok
+}
+
+// reportDriftCorrection records upstream, in the experimental.drift.workload.kcp.dev/<sync-target-name> annotation,
+// that the downstream object drifted from the desired state and that the drift has been corrected.
+func (c *Controller) reportDriftCorrection(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, d drift) error {
+	correction, err := json.Marshal(DriftCorrection{
+		FieldManagers:  d.fieldManagers.List(),
+		CorrectionTime: metav1.NewTime(time.Now().UTC()),
+	})
+	if err != nil {
+		return err
+	}
+
+	upstreamObjCopy := upstreamObj.DeepCopy()
+	annotations := upstreamObjCopy.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[workloadv1alpha1.InternalClusterDriftAnnotationPrefix+c.syncTargetName] = string(correction)
+	upstreamObjCopy.SetAnnotations(annotations)
+
+	logicalCluster := logicalcluster.From(upstreamObjCopy)
+	if _, err := c.upstreamClient.Cluster(logicalCluster).Resource(gvr).Namespace(upstreamObjCopy.GetNamespace()).Update(ctx, upstreamObjCopy, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed reporting drift correction upstream on resource %s|%s/%s: %v", logicalCluster, upstreamObjCopy.GetNamespace(), upstreamObjCopy.GetName(), err)
+		return err
+	}
+	klog.Infof("Corrected drift of %s %s|%s/%s made by field managers %v", gvr.Resource, logicalCluster, upstreamObjCopy.GetNamespace(), upstreamObjCopy.GetName(), d.fieldManagers.List())
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDriftingFieldManagers(t *testing.T) {
+	before := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	after := metav1.NewTime(time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC))
+
+	entry := func(manager string, operation metav1.ManagedFieldsOperationType, subresource string, time metav1.Time) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:     manager,
+			Operation:   operation,
+			Subresource: subresource,
+			Time:        &time,
+			FieldsType:  "FieldsV1",
+		}
+	}
+	object := func(entries ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetManagedFields(entries)
+		return obj
+	}
+
+	tests := map[string]struct {
+		oldObj   *unstructured.Unstructured
+		newObj   *unstructured.Unstructured
+		expected []string
+	}{
+		"no change": {
+			oldObj:   object(entry("syncer", metav1.ManagedFieldsOperationApply, "", before)),
+			newObj:   object(entry("syncer", metav1.ManagedFieldsOperationApply, "", before)),
+			expected: []string{},
+		},
+		"syncer apply is not drift": {
+			oldObj:   object(entry("syncer", metav1.ManagedFieldsOperationApply, "", before)),
+			newObj:   object(entry("syncer", metav1.ManagedFieldsOperationApply, "", after)),
+			expected: []string{},
+		},
+		"status update is not drift": {
+			oldObj: object(entry("syncer", metav1.ManagedFieldsOperationApply, "", before)),
+			newObj: object(
+				entry("syncer", metav1.ManagedFieldsOperationApply, "", before),
+				entry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, "status", after),
+			),
+			expected: []string{},
+		},
+		"kubectl edit is drift": {
+			oldObj: object(entry("syncer", metav1.ManagedFieldsOperationApply, "", before)),
+			newObj: object(
+				entry("syncer", metav1.ManagedFieldsOperationApply, "", before),
+				entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, "", after),
+			),
+			expected: []string{"kubectl-edit"},
+		},
+		"repeated modification by the same field manager is drift": {
+			oldObj: object(
+				entry("syncer", metav1.ManagedFieldsOperationApply, "", before),
+				entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, "", before),
+			),
+			newObj: object(
+				entry("syncer", metav1.ManagedFieldsOperationApply, "", before),
+				entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, "", after),
+			),
+			expected: []string{"kubectl-edit"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, driftingFieldManagers(tc.oldObj, tc.newObj).List())
+		})
+	}
+}
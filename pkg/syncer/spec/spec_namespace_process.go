@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// NamespaceOptions configures how the metadata of upstream namespaces is propagated to the downstream namespaces.
+type NamespaceOptions struct {
+	// LabelAllowlist are the keys of the upstream namespace labels that are propagated downstream.
+	// A key ending with "*" matches all the keys with the same prefix.
+	LabelAllowlist []string
+	// AnnotationAllowlist are the keys of the upstream namespace annotations that are propagated downstream.
+	// A key ending with "*" matches all the keys with the same prefix.
+	AnnotationAllowlist []string
+}
+
+// processNamespace syncs an upstream namespace to its downstream namespace. The downstream namespace
+// is created or has its labels and annotations repaired while the upstream namespace is scheduled to
+// the SyncTarget, and deleted once the upstream namespace is gone and all the synced objects have
+// been removed from it.
+func (c *Controller) processNamespace(ctx context.Context, key string) error {
+	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		klog.Errorf("Invalid key %q: %v", key, err)
+		return nil
+	}
+	clusterName, upstreamNamespace := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
+	downstreamNamespace, err := c.downstreamNamespaceName(desiredNSLocator)
+	if err != nil {
+		return err
+	}
+
+	if !c.syncerInformers.UpstreamNamespaceInformer().HasSynced() {
+		return fmt.Errorf("informer for upstream namespaces is not synced yet")
+	}
+	obj, exists, err := c.upstreamNamespaceIndexer.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return c.deleteDownstreamNamespaceIfEmpty(ctx, downstreamNamespace)
+	}
+
+	namespace, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("namespace to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+	return c.ensureDownstreamNamespace(ctx, downstreamNamespace, desiredNSLocator, namespace)
+}
+
+// downstreamNamespaceName returns the name of the downstream namespace with the given namespace locator,
+// or the name derived from the namespace locator if the downstream namespace doesn't exist yet.
+func (c *Controller) downstreamNamespaceName(locator shared.NamespaceLocator) (string, error) {
+	jsonNSLocator, err := json.Marshal(locator)
+	if err != nil {
+		return "", err
+	}
+
+	downstreamNamespaces, err := c.downstreamNamespaceIndexer.ByIndex(byNamespaceLocatorIndexName, string(jsonNSLocator))
+	if err != nil {
+		return "", err
+	}
+
+	if len(downstreamNamespaces) == 1 {
+		namespace := downstreamNamespaces[0].(*unstructured.Unstructured)
+		klog.V(4).Infof("Found downstream namespace %s for upstream namespace %s|%s", namespace.GetName(), locator.Workspace, locator.Namespace)
+		return namespace.GetName(), nil
+	} else if len(downstreamNamespaces) > 1 {
+		// This should never happen unless there's some namespace collision.
+		var namespacesCollisions []string
+		for _, namespace := range downstreamNamespaces {
+			namespacesCollisions = append(namespacesCollisions, namespace.(*unstructured.Unstructured).GetName())
+		}
+		return "", shared.NewSyncError(shared.NamespaceCollisionReason, fmt.Errorf("(namespace collision) found multiple downstream namespaces: %s for upstream namespace %s|%s", strings.Join(namespacesCollisions, ","), locator.Workspace, locator.Namespace))
+	}
+
+	klog.V(4).Infof("No downstream namespaces found for upstream namespace %s|%s", locator.Workspace, locator.Namespace)
+	return shared.PhysicalClusterNamespaceName(locator)
+}
+
+// ensureDownstreamNamespaceExists ensures the downstream namespace of an upstream object exists before
+// the object is synced, as the object may be processed before its namespace.
+func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
+	upstreamLogicalCluster := logicalcluster.From(upstreamObj)
+	desiredNSLocator := shared.NewNamespaceLocator(upstreamLogicalCluster, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamObj.GetNamespace())
+
+	var upstreamNamespace *unstructured.Unstructured
+	obj, exists, err := c.upstreamNamespaceIndexer.GetByKey(clusters.ToClusterAwareKey(upstreamLogicalCluster, upstreamObj.GetNamespace()))
+	if err != nil {
+		return err
+	}
+	if exists {
+		upstreamNamespace, _ = obj.(*unstructured.Unstructured)
+	}
+
+	return c.ensureDownstreamNamespace(ctx, downstreamNamespace, desiredNSLocator, upstreamNamespace)
+}
+
+// ensureDownstreamNamespace creates the downstream namespace, or repairs its labels and annotations.
+// The labels and annotations of upstreamNamespace are only propagated if upstreamNamespace is not nil.
+func (c *Controller) ensureDownstreamNamespace(ctx context.Context, downstreamNamespace string, desiredNSLocator shared.NamespaceLocator, upstreamNamespace *unstructured.Unstructured) error {
+	b, err := json.Marshal(desiredNSLocator)
+	if err != nil {
+		return err
+	}
+	desiredAnnotations := map[string]string{
+		shared.NamespaceLocatorAnnotation: string(b),
+	}
+	desiredLabels := map[string]string{
+		// TODO: this should be set once at syncer startup and propagated around everywhere.
+		workloadv1alpha1.InternalDownstreamClusterLabel: c.syncTargetName,
+	}
+	if upstreamNamespace != nil {
+		for k, v := range allowedEntries(upstreamNamespace.GetAnnotations(), c.namespaceOptions.AnnotationAllowlist) {
+			desiredAnnotations[k] = v
+		}
+		for k, v := range allowedEntries(upstreamNamespace.GetLabels(), c.namespaceOptions.LabelAllowlist) {
+			desiredLabels[k] = v
+		}
+	}
+
+	// Check if the namespace already exists, if not create it.
+	obj, err := c.downstreamNamespaceLister.Get(downstreamNamespace)
+	if apierrors.IsNotFound(err) {
+		newNamespace := &unstructured.Unstructured{}
+		newNamespace.SetAPIVersion("v1")
+		newNamespace.SetKind("Namespace")
+		newNamespace.SetName(downstreamNamespace)
+		newNamespace.SetAnnotations(desiredAnnotations)
+		newNamespace.SetLabels(desiredLabels)
+
+		_, err := c.downstreamClient.Resource(namespaceGVR).Create(ctx, newNamespace, metav1.CreateOptions{})
+		if err == nil {
+			klog.Infof("Created downstream namespace %s for upstream namespace %s|%s", downstreamNamespace, desiredNSLocator.Workspace, desiredNSLocator.Namespace)
+			return nil
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		// The namespace is not watched by the syncer when its labels have drifted, so it is read from the
+		// physical cluster to be repaired.
+		obj, err = c.downstreamClient.Resource(namespaceGVR).Get(ctx, downstreamNamespace, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// The namespace exists, so check if it has the correct namespace locator.
+	existing := obj.(*unstructured.Unstructured)
+	nsLocator, exists, err := shared.LocatorFromAnnotations(existing.GetAnnotations())
+	if err != nil {
+		return shared.NewSyncError(shared.NamespaceCollisionReason, fmt.Errorf("(possible namespace collision) namespace %s already exists, but found an error when trying to decode the annotation: %w", downstreamNamespace, err))
+	}
+	if !exists {
+		// A namespace without our namespace locator is not ours, even if it has the derived name.
+		return shared.NewSyncError(shared.NamespaceCollisionReason, fmt.Errorf("(namespace collision) namespace %s has no namespace locator", downstreamNamespace))
+	}
+	if !reflect.DeepEqual(desiredNSLocator, *nsLocator) {
+		return shared.NewSyncError(shared.NamespaceCollisionReason, fmt.Errorf("(namespace collision) namespace %s already exists, but has a different namespace locator annotation: %+v vs %+v", downstreamNamespace, nsLocator, desiredNSLocator))
+	}
+
+	newNamespace := existing.DeepCopy()
+	newNamespace.SetAnnotations(mergeAllowedEntries(existing.GetAnnotations(), desiredAnnotations, upstreamNamespace != nil, c.namespaceOptions.AnnotationAllowlist))
+	newNamespace.SetLabels(mergeAllowedEntries(existing.GetLabels(), desiredLabels, upstreamNamespace != nil, c.namespaceOptions.LabelAllowlist))
+	if reflect.DeepEqual(existing, newNamespace) {
+		return nil
+	}
+
+	if _, err := c.downstreamClient.Resource(namespaceGVR).Update(ctx, newNamespace, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("Updated downstream namespace %s for upstream namespace %s|%s", downstreamNamespace, desiredNSLocator.Workspace, desiredNSLocator.Namespace)
+	return nil
+}
+
+// deleteDownstreamNamespaceIfEmpty deletes the downstream namespace if it doesn't contain synced objects anymore.
+// Otherwise the namespace is processed again when the remaining synced objects are deleted.
+func (c *Controller) deleteDownstreamNamespaceIfEmpty(ctx context.Context, downstreamNamespace string) error {
+	if _, err := c.downstreamNamespaceLister.Get(downstreamNamespace); apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, gvr := range c.syncerInformers.SyncedGVRs() {
+		syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+		if !ok {
+			continue
+		}
+		if !syncerInformer.HasSynced() {
+			return fmt.Errorf("informers for resource %q are not synced yet", gvr.String())
+		}
+		objs, err := syncerInformer.DownstreamInformer.GetIndexer().ByIndex(cache.NamespaceIndex, downstreamNamespace)
+		if err != nil {
+			return err
+		}
+		if len(objs) > 0 {
+			klog.V(4).Infof("Downstream namespace %s still contains synced %s, not deleting it", downstreamNamespace, gvr.Resource)
+			return nil
+		}
+	}
+
+	if err := c.downstreamClient.Resource(namespaceGVR).Delete(ctx, downstreamNamespace, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	klog.Infof("Deleted downstream namespace %s", downstreamNamespace)
+	return nil
+}
+
+// allowedEntries returns the entries of m whose key matches the allowlist.
+func allowedEntries(m map[string]string, allowlist []string) map[string]string {
+	allowed := map[string]string{}
+	for k, v := range m {
+		if isAllowed(k, allowlist) {
+			allowed[k] = v
+		}
+	}
+	return allowed
+}
+
+// mergeAllowedEntries returns existing, updated with the desired entries. If pruneAllowed is true,
+// the existing entries allowed by the allowlist, but not desired anymore, are removed.
+func mergeAllowedEntries(existing, desired map[string]string, pruneAllowed bool, allowlist []string) map[string]string {
+	merged := make(map[string]string, len(existing)+len(desired))
+	for k, v := range existing {
+		if _, found := desired[k]; !found && pruneAllowed && isAllowed(k, allowlist) {
+			continue
+		}
+		merged[k] = v
+	}
+	for k, v := range desired {
+		merged[k] = v
+	}
+	return merged
+}
+
+func isAllowed(key string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		} else if key == allowed {
+			return true
+		}
+	}
+	return false
+}
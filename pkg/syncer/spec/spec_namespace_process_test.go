@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeAllowedEntries(t *testing.T) {
+	tests := map[string]struct {
+		existing     map[string]string
+		upstream     map[string]string
+		pruneAllowed bool
+		allowlist    []string
+		expected     map[string]string
+	}{
+		"no allowlist keeps the existing entries": {
+			existing:     map[string]string{"downstream": "value"},
+			upstream:     map[string]string{"team": "a"},
+			pruneAllowed: true,
+			expected:     map[string]string{"downstream": "value"},
+		},
+		"allowed entries are propagated": {
+			existing:     map[string]string{"downstream": "value"},
+			upstream:     map[string]string{"team": "a", "other": "b"},
+			pruneAllowed: true,
+			allowlist:    []string{"team"},
+			expected:     map[string]string{"downstream": "value", "team": "a"},
+		},
+		"prefix allowlist": {
+			upstream:     map[string]string{"example.com/team": "a", "example.com/cost-center": "b", "other": "c"},
+			pruneAllowed: true,
+			allowlist:    []string{"example.com/*"},
+			expected:     map[string]string{"example.com/team": "a", "example.com/cost-center": "b"},
+		},
+		"allowed entries removed upstream are pruned": {
+			existing:     map[string]string{"downstream": "value", "team": "a"},
+			upstream:     map[string]string{},
+			pruneAllowed: true,
+			allowlist:    []string{"team"},
+			expected:     map[string]string{"downstream": "value"},
+		},
+		"allowed entries are not pruned without an upstream namespace": {
+			existing:  map[string]string{"downstream": "value", "team": "a"},
+			allowlist: []string{"team"},
+			expected:  map[string]string{"downstream": "value", "team": "a"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			desired := allowedEntries(tc.upstream, tc.allowlist)
+			require.Equal(t, tc.expected, mergeAllowedEntries(tc.existing, desired, tc.pruneAllowed, tc.allowlist))
+		})
+	}
+}
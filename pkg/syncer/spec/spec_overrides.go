@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+// SpecOverridesFunc returns the spec overrides to apply, in order, to an upstream object when syncing it down.
+type SpecOverridesFunc func(upstreamObj *unstructured.Unstructured) ([]*workloadv1alpha1.SpecOverrides, error)
+
+// NewSpecOverridesFunc returns a SpecOverridesFunc returning the overrides of the SyncTargetOverrides selecting
+// the upstream object, among those of the Locations the SyncTarget is an instance of. The SyncTarget, its Locations
+// and the SyncTargetOverrides are in the same workspace.
+func NewSpecOverridesFunc(syncTargetName string, syncTargetLister workloadlisters.SyncTargetLister, locationLister schedulinglisters.LocationLister,
+	syncTargetOverrideLister workloadlisters.SyncTargetOverrideLister) SpecOverridesFunc {
+	return func(upstreamObj *unstructured.Unstructured) ([]*workloadv1alpha1.SpecOverrides, error) {
+		syncTarget, err := syncTargetLister.Get(syncTargetName)
+		if err != nil {
+			return nil, err
+		}
+		syncTargetOverrides, err := syncTargetOverrideLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(syncTargetOverrides, func(i, j int) bool {
+			return syncTargetOverrides[i].Name < syncTargetOverrides[j].Name
+		})
+
+		var overrides []*workloadv1alpha1.SpecOverrides
+		for _, syncTargetOverride := range syncTargetOverrides {
+			location, err := locationLister.Get(syncTargetOverride.Spec.Location)
+			if errors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !isInstanceOf(syncTarget, location) {
+				continue
+			}
+			if syncTargetOverride.Spec.ObjectSelector != nil {
+				selector, err := metav1.LabelSelectorAsSelector(syncTargetOverride.Spec.ObjectSelector)
+				if err != nil {
+					klog.Errorf("Ignoring SyncTargetOverride %s with invalid object selector: %v", syncTargetOverride.Name, err)
+					continue
+				}
+				if !selector.Matches(labels.Set(upstreamObj.GetLabels())) {
+					continue
+				}
+			}
+			overrides = append(overrides, &syncTargetOverride.Spec.Overrides)
+		}
+		return overrides, nil
+	}
+}
+
+// isInstanceOf returns whether the SyncTarget is selected by the instance selector of the Location.
+func isInstanceOf(syncTarget *workloadv1alpha1.SyncTarget, location *schedulingv1alpha1.Location) bool {
+	if location.Spec.Resource.Group != workloadv1alpha1.SchemeGroupVersion.Group || location.Spec.Resource.Resource != "synctargets" {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(location.Spec.InstanceSelector)
+	if err != nil {
+		klog.Errorf("Ignoring Location %s with invalid instance selector: %v", location.Name, err)
+		return false
+	}
+	return selector.Matches(labels.Set(syncTarget.Labels))
+}
+
+// replicatedKinds are the kinds whose replicas can be overridden even if the replicas are not set.
+var replicatedKinds = sets.NewString("Deployment", "ReplicaSet", "StatefulSet")
+
+// podSpecPath returns the path of the pod spec of the object, i.e. of the pod template of the
+// workload resources, or nil if the object has no pod spec.
+func podSpecPath(obj *unstructured.Unstructured) []string {
+	switch {
+	case obj.GetKind() == "Pod":
+		return []string{"spec"}
+	case obj.GetKind() == "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec"); found {
+		return []string{"spec", "template", "spec"}
+	}
+	return nil
+}
+
+// applySpecOverrides applies the spec overrides to the downstream object.
+func applySpecOverrides(obj *unstructured.Unstructured, overrides *workloadv1alpha1.SpecOverrides) error {
+	if overrides.Replicas != nil {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas"); found || replicatedKinds.Has(obj.GetKind()) {
+			if err := unstructured.SetNestedField(obj.Object, int64(*overrides.Replicas), "spec", "replicas"); err != nil {
+				return err
+			}
+		}
+	}
+
+	path := podSpecPath(obj)
+	if path == nil {
+		return nil
+	}
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return err
+	}
+
+	if len(overrides.NodeSelector) > 0 {
+		nodeSelector, _, err := unstructured.NestedStringMap(podSpec, "nodeSelector")
+		if err != nil {
+			return err
+		}
+		if nodeSelector == nil {
+			nodeSelector = map[string]string{}
+		}
+		for k, v := range overrides.NodeSelector {
+			nodeSelector[k] = v
+		}
+		if err := unstructured.SetNestedStringMap(podSpec, nodeSelector, "nodeSelector"); err != nil {
+			return err
+		}
+	}
+
+	resources := map[string]map[string]interface{}{}
+	for _, container := range overrides.Containers {
+		if container.Resources == nil {
+			continue
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(container.Resources)
+		if err != nil {
+			return err
+		}
+		resources[container.Name] = u
+	}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, found, err := unstructured.NestedSlice(podSpec, field)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if image, ok := container["image"].(string); ok {
+				container["image"] = mirrorImage(image, overrides.ImageRegistryMirrors)
+			}
+			if name, ok := container["name"].(string); ok {
+				if r, found := resources[name]; found {
+					container["resources"] = r
+				}
+			}
+		}
+		if err := unstructured.SetNestedSlice(podSpec, containers, field); err != nil {
+			return err
+		}
+	}
+
+	return unstructured.SetNestedMap(obj.Object, podSpec, path...)
+}
+
+// mirrorImage replaces the registry of the image with its mirror. Mirrors can also replace a path of
+// a registry, in which case the longest matching one is used. Images without registry are considered
+// to be on docker.io.
+func mirrorImage(image string, mirrors map[string]string) string {
+	if len(mirrors) == 0 {
+		return image
+	}
+
+	fullName := image
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 1 {
+		fullName = "docker.io/library/" + image
+	} else if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		fullName = "docker.io/" + image
+	}
+
+	var longest string
+	for registry := range mirrors {
+		if (fullName == registry || strings.HasPrefix(fullName, registry+"/")) && len(registry) > len(longest) {
+			longest = registry
+		}
+	}
+	if longest == "" {
+		return image
+	}
+	return mirrors[longest] + strings.TrimPrefix(fullName, longest)
+}
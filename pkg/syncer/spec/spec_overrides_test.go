@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+func TestApplySpecOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       string
+		overrides workloadv1alpha1.SpecOverrides
+		want      string
+	}{
+		{
+			name: "deployment without replicas",
+			obj:  `{"kind":"Deployment","spec":{"template":{"spec":{"containers":[{"name":"app","image":"nginx"}]}}}}`,
+			overrides: workloadv1alpha1.SpecOverrides{
+				Replicas:             pointer.Int32(3),
+				ImageRegistryMirrors: map[string]string{"docker.io": "mirror.example.com/docker"},
+				NodeSelector:         map[string]string{"disktype": "ssd"},
+				Containers: []workloadv1alpha1.ContainerOverride{{
+					Name: "app",
+					Resources: &corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					},
+				}},
+			},
+			want: `{"kind":"Deployment","spec":{"replicas":3,"template":{"spec":{"nodeSelector":{"disktype":"ssd"},` +
+				`"containers":[{"name":"app","image":"mirror.example.com/docker/library/nginx","resources":{"limits":{"cpu":"1"}}}]}}}}`,
+		},
+		{
+			name: "pod with init containers and an existing node selector",
+			obj: `{"kind":"Pod","spec":{"nodeSelector":{"zone":"a"},"initContainers":[{"name":"init","image":"quay.io/org/init:v1"}],` +
+				`"containers":[{"name":"app","image":"registry.example.com:5000/app@sha256:abc"}]}}`,
+			overrides: workloadv1alpha1.SpecOverrides{
+				Replicas: pointer.Int32(3),
+				ImageRegistryMirrors: map[string]string{
+					"quay.io":                    "mirror.example.com/quay",
+					"quay.io/org":                "mirror.example.com/org",
+					"registry.example.com:5000":  "mirror.example.com",
+					"registry.example.com:50000": "wrong.example.com",
+				},
+				NodeSelector: map[string]string{"disktype": "ssd"},
+			},
+			want: `{"kind":"Pod","spec":{"nodeSelector":{"zone":"a","disktype":"ssd"},"initContainers":[{"name":"init","image":"mirror.example.com/org/init:v1"}],` +
+				`"containers":[{"name":"app","image":"mirror.example.com/app@sha256:abc"}]}}`,
+		},
+		{
+			name: "cron job",
+			obj:  `{"kind":"CronJob","spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"job","image":"org/job"}]}}}}}}`,
+			overrides: workloadv1alpha1.SpecOverrides{
+				ImageRegistryMirrors: map[string]string{"docker.io": "mirror.example.com"},
+			},
+			want: `{"kind":"CronJob","spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"job","image":"mirror.example.com/org/job"}]}}}}}}`,
+		},
+		{
+			name: "object without pod spec",
+			obj:  `{"kind":"ConfigMap","data":{"foo":"bar"}}`,
+			overrides: workloadv1alpha1.SpecOverrides{
+				Replicas:     pointer.Int32(3),
+				NodeSelector: map[string]string{"disktype": "ssd"},
+			},
+			want: `{"kind":"ConfigMap","data":{"foo":"bar"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			require.NoError(t, json.Unmarshal([]byte(tt.obj), &obj.Object))
+			want := &unstructured.Unstructured{}
+			require.NoError(t, json.Unmarshal([]byte(tt.want), &want.Object))
+
+			require.NoError(t, applySpecOverrides(obj, &tt.overrides))
+			require.Equal(t, want, obj)
+		})
+	}
+}
+
+func TestSpecOverridesFunc(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "us-east1-a", Labels: map[string]string{"region": "us-east1"}},
+	}
+	location := func(name string, selector *metav1.LabelSelector) *schedulingv1alpha1.Location {
+		return &schedulingv1alpha1.Location{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: schedulingv1alpha1.LocationSpec{
+				Resource:         schedulingv1alpha1.GroupVersionResource{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"},
+				InstanceSelector: selector,
+			},
+		}
+	}
+	override := func(name, location string, selector *metav1.LabelSelector, replicas int32) *workloadv1alpha1.SyncTargetOverride {
+		return &workloadv1alpha1.SyncTargetOverride{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: workloadv1alpha1.SyncTargetOverrideSpec{
+				Location:       location,
+				ObjectSelector: selector,
+				Overrides:      workloadv1alpha1.SpecOverrides{Replicas: pointer.Int32(replicas)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name                string
+		locations           []*schedulingv1alpha1.Location
+		syncTargetOverrides []*workloadv1alpha1.SyncTargetOverride
+		objLabels           map[string]string
+		wantReplicas        []int32
+	}{
+		{
+			name:      "no overrides",
+			locations: []*schedulingv1alpha1.Location{location("us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}})},
+		},
+		{
+			name:      "overrides of the locations of the sync target, in name order",
+			locations: []*schedulingv1alpha1.Location{location("us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}}), location("all", &metav1.LabelSelector{})},
+			syncTargetOverrides: []*workloadv1alpha1.SyncTargetOverride{
+				override("b", "us-east1", nil, 2),
+				override("a", "all", nil, 1),
+			},
+			wantReplicas: []int32{1, 2},
+		},
+		{
+			name: "overrides of other locations are ignored",
+			locations: []*schedulingv1alpha1.Location{
+				location("us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}}),
+				location("us-west1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-west1"}}),
+			},
+			syncTargetOverrides: []*workloadv1alpha1.SyncTargetOverride{
+				override("a", "us-west1", nil, 1),
+				override("b", "us-east1", nil, 2),
+				override("c", "missing", nil, 3),
+			},
+			wantReplicas: []int32{2},
+		},
+		{
+			name:      "object selector",
+			locations: []*schedulingv1alpha1.Location{location("us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east1"}})},
+			syncTargetOverrides: []*workloadv1alpha1.SyncTargetOverride{
+				override("a", "us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, 1),
+				override("b", "us-east1", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}, 2),
+			},
+			objLabels:    map[string]string{"app": "web"},
+			wantReplicas: []int32{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncTargetIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, syncTargetIndexer.Add(syncTarget))
+			locationIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, location := range tt.locations {
+				require.NoError(t, locationIndexer.Add(location))
+			}
+			syncTargetOverrideIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, syncTargetOverride := range tt.syncTargetOverrides {
+				require.NoError(t, syncTargetOverrideIndexer.Add(syncTargetOverride))
+			}
+
+			specOverrides := NewSpecOverridesFunc(syncTarget.Name, workloadlisters.NewSyncTargetLister(syncTargetIndexer),
+				schedulinglisters.NewLocationLister(locationIndexer), workloadlisters.NewSyncTargetOverrideLister(syncTargetOverrideIndexer))
+
+			obj := &unstructured.Unstructured{}
+			obj.SetLabels(tt.objLabels)
+			overrides, err := specOverrides(obj)
+			require.NoError(t, err)
+
+			var replicas []int32
+			for _, o := range overrides {
+				replicas = append(replicas, *o.Replicas)
+			}
+			require.Equal(t, tt.wantReplicas, replicas)
+		})
+	}
+}
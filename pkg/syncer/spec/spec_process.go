@@ -20,7 +20,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -40,6 +39,7 @@ import (
 	"k8s.io/utils/pointer"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
@@ -49,16 +49,33 @@ const (
 
 type mutatorGvrMap map[schema.GroupVersionResource]func(obj *unstructured.Unstructured) error
 
+// isSyncerReportAnnotation returns true for the annotations the syncers write on upstream objects
+// to report the downstream state.
+func isSyncerReportAnnotation(key string) bool {
+	return strings.HasPrefix(key, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) ||
+		strings.HasPrefix(key, workloadv1alpha1.InternalClusterDriftAnnotationPrefix) ||
+		strings.HasPrefix(key, workloadv1alpha1.InternalClusterSyncStatusAnnotationPrefix)
+}
+
+// isSyncTargetAnnotation returns true for the annotations holding the per-SyncTarget state of
+// an upstream object, which must not leak to the downstream objects.
+func isSyncTargetAnnotation(key string) bool {
+	return isSyncerReportAnnotation(key) ||
+		strings.HasPrefix(key, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix) ||
+		strings.HasPrefix(key, workloadv1alpha1.InternalClusterEvictionAnnotationPrefix) ||
+		strings.HasPrefix(key, workloadv1alpha1.ClusterSpecDiffAnnotationPrefix)
+}
+
 func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured) bool {
 	// TODO(jmprusi): Remove this after switching to virtual workspaces.
-	// remove status annotation from oldObj and newObj before comparing
+	// remove the annotations written by the syncers from oldObj and newObj before comparing
 	oldAnnotations, _, err := unstructured.NestedStringMap(oldUnstrob.Object, "metadata", "annotations")
 	if err != nil {
 		klog.Errorf("failed to get annotations from object: %v", err)
 		return false
 	}
 	for k := range oldAnnotations {
-		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+		if isSyncerReportAnnotation(k) {
 			delete(oldAnnotations, k)
 		}
 	}
@@ -69,7 +86,7 @@ func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured)
 		return false
 	}
 	for k := range newAnnotations {
-		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+		if isSyncerReportAnnotation(k) {
 			delete(newAnnotations, k)
 		}
 	}
@@ -106,6 +123,10 @@ func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured)
 func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResource, key string) error {
 	klog.V(3).InfoS("Processing", "gvr", gvr, "key", key)
 
+	if gvr == namespaceGVR {
+		return c.processNamespace(ctx, key)
+	}
+
 	// from upstream
 	upstreamNamespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -114,50 +135,42 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	}
 	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
 
-	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
-	jsonNSLocator, err := json.Marshal(desiredNSLocator)
-	if err != nil {
-		return err
+	if upstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, key, clusterName, name)
 	}
 
-	namespaceGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
-	downstreamNamespaces, err := c.downstreamInformers.ForResource(namespaceGvr).Informer().GetIndexer().ByIndex(byNamespaceLocatorIndexName, string(jsonNSLocator))
-	if err != nil {
-		return err
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		klog.V(2).Infof("Resource %q is not synced anymore, skipping %s", gvr.String(), key)
+		return nil
 	}
-	var downstreamNamespace string
-
-	if len(downstreamNamespaces) == 1 {
-		namespace := downstreamNamespaces[0].(*unstructured.Unstructured)
-		klog.V(4).Infof("Found downstream namespace %s for upstream namespace %s", namespace.GetName(), upstreamNamespace)
-		downstreamNamespace = namespace.GetName()
-	} else if len(downstreamNamespaces) > 1 {
-		// This should never happen unless there's some namespace collision.
-		var namespacesCollisions []string
-		for _, namespace := range downstreamNamespaces {
-			namespacesCollisions = append(namespacesCollisions, namespace.(*unstructured.Unstructured).GetName())
-		}
-		return fmt.Errorf("(namespace collision) found multiple downstream namespaces: %s for upstream namespace %s|%s", strings.Join(namespacesCollisions, ","), clusterName, upstreamNamespace)
-	} else {
-		klog.V(4).Infof("No downstream namespaces found for %s", key)
-		downstreamNamespace, err = shared.PhysicalClusterNamespaceName(desiredNSLocator)
-		if err != nil {
-			klog.Errorf("Error hashing namespace %s|%s: %v", clusterName, upstreamNamespace, err)
-			return nil
-		}
+	if !syncerInformer.HasSynced() {
+		return fmt.Errorf("informers for resource %q are not synced yet", gvr.String())
 	}
 
 	// get the upstream object
-	obj, exists, err := c.upstreamInformers.ForResource(gvr).Informer().GetIndexer().GetByKey(key)
+	obj, exists, err := syncerInformer.UpstreamInformer.GetIndexer().GetByKey(key)
 	if err != nil {
 		return err
 	}
+
+	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
+	downstreamNamespace, nsErr := c.downstreamNamespaceName(desiredNSLocator)
+
 	if !exists {
+		if nsErr != nil {
+			return nsErr
+		}
 		// deleted upstream => delete downstream
+		c.popDrift(queueKey{gvr: gvr, key: key})
 		klog.Infof("Deleting downstream GVR %q object %s/%s for upstream cluster %q", gvr.String(), upstreamNamespace, name, clusterName)
-		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
 			return err
 		}
+		metrics.RecordSyncedObject(metrics.SpecSyncer, gvr, metrics.DeleteOperation)
 		return nil
 	}
 
@@ -166,68 +179,54 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	if !ok {
 		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
 	}
-	return c.applyToDownstream(ctx, gvr, downstreamNamespace, u)
+	if nsErr != nil {
+		return c.updateSyncStatus(ctx, gvr, u, nsErr)
+	}
+	return c.updateSyncStatus(ctx, gvr, u, c.applyToDownstream(ctx, gvr, downstreamNamespace, u))
 }
 
-// TODO: This function is there as a quick and dirty implementation of namespace creation.
-//       In fact We should also be getting notifications about namespaces created upstream and be creating downstream equivalents.
-func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
-	namespaces := c.downstreamClient.Resource(schema.GroupVersionResource{
-		Group:    "",
-		Version:  "v1",
-		Resource: "namespaces",
-	})
-
-	newNamespace := &unstructured.Unstructured{}
-	newNamespace.SetAPIVersion("v1")
-	newNamespace.SetKind("Namespace")
-	newNamespace.SetName(downstreamNamespace)
-
-	// TODO: if the downstream namespace loses these annotations/labels after creation,
-	// we don't have anything in place currently that will put them back.
-	upstreamLogicalCluster := logicalcluster.From(upstreamObj)
-	desiredNSLocator := shared.NewNamespaceLocator(upstreamLogicalCluster, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamObj.GetNamespace())
-	b, err := json.Marshal(desiredNSLocator)
+// processClusterScoped syncs a cluster-scoped upstream resource to a downstream resource, whose name
+// is derived from the upstream workspace and the SyncTarget to avoid collisions between workspaces.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, key string, clusterName logicalcluster.Name, name string) error {
+	locator := shared.NewResourceLocator(clusterName, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, name)
+	downstreamName, err := shared.PhysicalClusterResourceName(locator)
 	if err != nil {
-		return err
-	}
-	newNamespace.SetAnnotations(map[string]string{
-		shared.NamespaceLocatorAnnotation: string(b),
-	})
-
-	if upstreamObj.GetLabels() != nil {
-		newNamespace.SetLabels(map[string]string{
-			// TODO: this should be set once at syncer startup and propagated around everywhere.
-			workloadv1alpha1.InternalDownstreamClusterLabel: c.syncTargetName,
-		})
+		klog.Errorf("Error computing the downstream name of %s %s|%s: %v", gvr.Resource, clusterName, name, err)
+		return nil
 	}
 
-	// Check if the namespace already exists, if not create it.
-	namespace, err := c.downstreamInformers.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}).Lister().Get(newNamespace.GetName())
-	if err != nil && apierrors.IsNotFound(err) {
-		if _, err := namespaces.Create(ctx, newNamespace, metav1.CreateOptions{}); err != nil {
-			return err
-		}
-		klog.Infof("Created downstream namespace %s for upstream namespace %s|%s", newNamespace.GetName(), desiredNSLocator.Workspace, desiredNSLocator.Namespace)
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		klog.V(2).Infof("Resource %q is not synced anymore, skipping %s", gvr.String(), key)
 		return nil
-	} else if err != nil {
-		return err
+	}
+	if !syncerInformer.HasSynced() {
+		return fmt.Errorf("informers for resource %q are not synced yet", gvr.String())
 	}
 
-	// The namespace exists, so check if it has the correct namespace locator.
-	unstrNamespace := namespace.(*unstructured.Unstructured)
-	nsLocator, exists, err := shared.LocatorFromAnnotations(unstrNamespace.GetAnnotations())
+	obj, exists, err := syncerInformer.UpstreamInformer.GetIndexer().GetByKey(key)
 	if err != nil {
-		return fmt.Errorf("(possible namespace collision) namespace %s already exists, but found an error when trying to decode the annotation: %w", newNamespace.GetName(), err)
+		return err
 	}
 	if !exists {
-		return fmt.Errorf("(namespace collision) namespace %s has no namespace locator", unstrNamespace.GetName())
-	}
-	if !reflect.DeepEqual(desiredNSLocator, *nsLocator) {
-		return fmt.Errorf("(namespace collision) namespace %s already exists, but has a different namespace locator annotation: %+v vs %+v", newNamespace.GetName(), nsLocator, desiredNSLocator)
+		// deleted upstream => delete downstream
+		c.popDrift(queueKey{gvr: gvr, key: key})
+		klog.Infof("Deleting downstream GVR %q object %s for upstream cluster %q", gvr.String(), downstreamName, clusterName)
+		if err := c.downstreamClient.Resource(gvr).Delete(ctx, downstreamName, metav1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		metrics.RecordSyncedObject(metrics.SpecSyncer, gvr, metrics.DeleteOperation)
+		return nil
 	}
 
-	return nil
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+	return c.updateSyncStatus(ctx, gvr, u, c.applyToDownstream(ctx, gvr, "", u))
 }
 
 func (c *Controller) ensureSyncerFinalizer(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) error {
@@ -256,9 +255,13 @@ func (c *Controller) ensureSyncerFinalizer(ctx context.Context, gvr schema.Group
 	return nil
 }
 
+// applyToDownstream applies the upstream object to the downstream namespace, or as a cluster-scoped
+// object if downstreamNamespace is empty.
 func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
-	if err := c.ensureDownstreamNamespaceExists(ctx, downstreamNamespace, upstreamObj); err != nil {
-		return err
+	if downstreamNamespace != "" {
+		if err := c.ensureDownstreamNamespaceExists(ctx, downstreamNamespace, upstreamObj); err != nil {
+			return err
+		}
 	}
 
 	if err := c.ensureSyncerFinalizer(ctx, gvr, upstreamObj); err != nil {
@@ -270,14 +273,40 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 
 	// Run name transformations on the downstreamObj.
 	transformedName := getTransformedName(downstreamObj)
+	if downstreamNamespace == "" {
+		// Cluster-scoped objects of all the workspaces share the same downstream scope, so their names
+		// are made unique, and the upstream object owning them is recorded in an annotation.
+		locator := shared.NewResourceLocator(upstreamObjLogicalCluster, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, upstreamObj.GetName())
+		name, err := shared.PhysicalClusterResourceName(locator)
+		if err != nil {
+			return err
+		}
+		transformedName = name
+
+		b, err := json.Marshal(locator)
+		if err != nil {
+			return err
+		}
+		annotations := downstreamObj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[shared.ResourceLocatorAnnotation] = string(b)
+		downstreamObj.SetAnnotations(annotations)
+	}
 
 	// Run any transformations on the object before we apply it to the downstream cluster.
 	if mutator, ok := c.mutators[gvr]; ok {
 		if err := mutator(downstreamObj); err != nil {
-			return err
+			return shared.NewSyncError(shared.MutationFailedReason, err)
 		}
 	}
 
+	// Point the references to cluster-scoped resources synced from the workspace to their downstream names.
+	if err := rewriteClusterScopedReferences(downstreamObj, c.clusterScopedDownstreamName(upstreamObjLogicalCluster)); err != nil {
+		return err
+	}
+
 	downstreamObj.SetName(transformedName)
 	downstreamObj.SetUID("")
 	downstreamObj.SetResourceVersion("")
@@ -292,6 +321,18 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	downstreamObj.SetOwnerReferences(nil)
 	// Strip finalizers to avoid the deletion of the downstream resource from being blocked.
 	downstreamObj.SetFinalizers(nil)
+	// Strip the per-SyncTarget annotations, which are only meaningful upstream.
+	if annotations := downstreamObj.GetAnnotations(); len(annotations) > 0 {
+		for k := range annotations {
+			if isSyncTargetAnnotation(k) {
+				delete(annotations, k)
+			}
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		downstreamObj.SetAnnotations(annotations)
+	}
 
 	// replace upstream state label with downstream cluster label. We don't want to leak upstream state machine
 	// state to downstream, and also we don't need downstream updates every time the upstream state machine changes.
@@ -308,11 +349,10 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 				return err
 			}
 			if specExists {
-				// TODO(jmprusi): Surface those errors to the user.
 				patch, err := jsonpatch.DecodePatch([]byte(specDiffPatch))
 				if err != nil {
 					klog.Errorf("Failed to decode spec diff patch: %v", err)
-					return err
+					return shared.NewSyncError(shared.InvalidSpecDiffReason, fmt.Errorf("failed to decode spec diff patch: %w", err))
 				}
 				upstreamSpecJSON, err := json.Marshal(upstreamSpec)
 				if err != nil {
@@ -320,7 +360,7 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 				}
 				patchedUpstreamSpecJSON, err := patch.Apply(upstreamSpecJSON)
 				if err != nil {
-					return err
+					return shared.NewSyncError(shared.InvalidSpecDiffReason, fmt.Errorf("failed to apply spec diff patch: %w", err))
 				}
 				var newSpec map[string]interface{}
 				if err := json.Unmarshal(patchedUpstreamSpecJSON, &newSpec); err != nil {
@@ -332,6 +372,19 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 			}
 		}
 	}
+
+	// Apply the spec overrides declared for the locations of the SyncTarget.
+	if c.specOverrides != nil {
+		overrides, err := c.specOverrides(upstreamObj)
+		if err != nil {
+			return err
+		}
+		for _, o := range overrides {
+			if err := applySpecOverrides(downstreamObj, o); err != nil {
+				return shared.NewSyncError(shared.InvalidSpecOverridesReason, fmt.Errorf("failed to apply spec overrides: %w", err))
+			}
+		}
+	}
 	// TODO: wipe things like finalizers, owner-refs and any other life-cycle fields. The life-cycle
 	//       should exclusively owned by the syncer. Let's not some Kubernetes magic interfere with it.
 
@@ -356,6 +409,7 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 			return err
 		}
 		klog.V(2).Infof("Deleted %s %s/%s from downstream %s|%s/%s", gvr.Resource, upstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), downstreamNamespace, downstreamObj.GetName())
+		metrics.RecordSyncedObject(metrics.SpecSyncer, gvr, metrics.DeleteOperation)
 		return nil
 	}
 
@@ -365,11 +419,27 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 		return err
 	}
 
-	if _, err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Patch(ctx, downstreamObj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: syncerApplyManager, Force: pointer.Bool(true)}); err != nil {
+	appliedObj, err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Patch(ctx, downstreamObj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: syncerApplyManager, Force: pointer.Bool(true)})
+	if err != nil {
 		klog.Errorf("Error upserting %s %s/%s from upstream %s|%s/%s: %v", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
-		return err
+		return shared.NewSyncError(shared.ApplyFailedReason, err)
 	}
 	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName())
+	metrics.RecordSyncedObject(metrics.SpecSyncer, gvr, metrics.UpsertOperation)
+
+	upstreamKey, err := cache.MetaNamespaceKeyFunc(upstreamObj)
+	if err != nil {
+		return err
+	}
+	// The drift is only corrected if applying the desired state changed the downstream object.
+	// Modifications of fields that are not owned by the syncer are left as they are.
+	if d, drifted := c.popDrift(queueKey{gvr: gvr, key: upstreamKey}); drifted && (d.corrected || appliedObj.GetResourceVersion() != d.resourceVersion) {
+		if err := c.reportDriftCorrection(ctx, gvr, upstreamObj, d); err != nil {
+			d.corrected = true
+			c.recordDrift(queueKey{gvr: gvr, key: upstreamKey}, d)
+			return err
+		}
+	}
 
 	return nil
 }
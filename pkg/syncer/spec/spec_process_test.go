@@ -35,15 +35,17 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clusters"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
@@ -508,6 +510,7 @@ func TestSyncerProcess(t *testing.T) {
 							"state.workload.kcp.dev/us-west1": "Sync",
 						}, nil, []string{"workload.kcp.dev/syncer-us-west1"}),
 					))),
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0}`),
 			},
 			expectActionsOnTo: []clienttesting.Action{
 				createNamespaceAction(
@@ -561,7 +564,9 @@ func TestSyncerProcess(t *testing.T) {
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
 
-			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0}`),
+			},
 			expectActionsOnTo: []clienttesting.Action{
 				createNamespaceAction(
 					"",
@@ -592,6 +597,71 @@ func TestSyncerProcess(t *testing.T) {
 				),
 			},
 		},
+		"SpecSyncer sync to downstream, the per-SyncTarget annotations are not synced": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"state.workload.kcp.dev/us-west1": "Sync",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, map[string]string{
+					"experimental.status.workload.kcp.dev/us-west1":      `{"replicas":1}`,
+					"experimental.drift.workload.kcp.dev/us-west1":       `{"drifted":true}`,
+					"experimental.sync-status.workload.kcp.dev/us-east1": `{"observedGeneration":0}`,
+					"experimental.spec-diff.workload.kcp.dev/us-east1":   `[{"op":"replace","path":"/replicas","value":3}]`,
+					"deletion.internal.workload.kcp.dev/us-east1":        "2002-10-02T10:00:00-05:00",
+					"eviction.internal.workload.kcp.dev/us-east1":        "2002-10-02T10:00:00-05:00",
+					"example.com/kept": "kept",
+				}, []string{"workload.kcp.dev/syncer-us-west1"}),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0}`),
+			},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("kcp-2r7hmup1y2r1", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
+					),
+				),
+				patchDeploymentAction(
+					"theDeployment",
+					"kcp-2r7hmup1y2r1",
+					types.ApplyPatchType,
+					toJson(t,
+						changeUnstructured(
+							toUnstructured(t, deployment("theDeployment", "kcp-2r7hmup1y2r1", "", map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							}, map[string]string{
+								"example.com/kept": "kept",
+							}, nil)),
+							setNestedField(map[string]interface{}{}, "status"),
+							setPodSpecServiceAccount("spec", "template", "spec"),
+						),
+					),
+				),
+			},
+		},
 		"SpecSyncer upstream resource has the state workload annotation removed, expect deletion downstream": {
 			upstreamLogicalCluster: "root:org:ws",
 			fromNamespace: namespace("test", "root:org:ws", map[string]string{
@@ -628,7 +698,8 @@ func TestSyncerProcess(t *testing.T) {
 			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
 			toResources: []runtime.Object{
 				namespace("kcp-2r7hmup1y2r1", "", map[string]string{
-					"state.workload.kcp.dev/us-west1": "Sync",
+					"internal.workload.kcp.dev/cluster": "us-west1",
+					"state.workload.kcp.dev/us-west1":   "Sync",
 				},
 					map[string]string{
 						"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
@@ -670,7 +741,8 @@ func TestSyncerProcess(t *testing.T) {
 			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
 			toResources: []runtime.Object{
 				namespace("kcp-2r7hmup1y2r1", "", map[string]string{
-					"state.workload.kcp.dev/us-west1": "Sync",
+					"internal.workload.kcp.dev/cluster": "us-west1",
+					"state.workload.kcp.dev/us-west1":   "Sync",
 				},
 					map[string]string{
 						"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
@@ -764,8 +836,7 @@ func TestSyncerProcess(t *testing.T) {
 							toUnstructured(t, deployment("theDeployment", "kcp-2r7hmup1y2r1", "", map[string]string{
 								"internal.workload.kcp.dev/cluster": "us-west1",
 							}, map[string]string{
-								"deletion.internal.workload.kcp.dev/us-west1": time.Now().Format(time.RFC3339),
-								"finalizers.workload.kcp.dev/us-west1":        "another-controller-finalizer",
+								"finalizers.workload.kcp.dev/us-west1": "another-controller-finalizer",
 							}, nil)),
 							// TODO(jmprusi): Those next changes do "nothing", it's just for the test to pass
 							//                as the test expects some null fields to be there...
@@ -816,6 +887,7 @@ func TestSyncerProcess(t *testing.T) {
 							"state.workload.kcp.dev/us-west1": "Sync",
 						}, map[string]string{"experimental.spec-diff.workload.kcp.dev/us-west1": "[{\"op\":\"replace\",\"path\":\"/replicas\",\"value\":3}]"}, []string{shared.SyncerFinalizerNamePrefix + "us-west1"}),
 					))),
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0}`),
 			},
 			expectActionsOnTo: []clienttesting.Action{
 				createNamespaceAction(
@@ -839,7 +911,7 @@ func TestSyncerProcess(t *testing.T) {
 						changeUnstructured(
 							toUnstructured(t, deployment("theDeployment", "kcp-2r7hmup1y2r1", "", map[string]string{
 								"internal.workload.kcp.dev/cluster": "us-west1",
-							}, map[string]string{"experimental.spec-diff.workload.kcp.dev/us-west1": "[{\"op\":\"replace\",\"path\":\"/replicas\",\"value\":3}]"}, nil)),
+							}, nil, nil)),
 							setNestedField(map[string]interface{}{
 								"replicas": int64(3),
 							}, "spec"),
@@ -891,8 +963,10 @@ func TestSyncerProcess(t *testing.T) {
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
 			expectError:                         true,
-			expectActionsOnFrom:                 []clienttesting.Action{},
-			expectActionsOnTo:                   []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0,"reason":"NamespaceCollision","message":"(namespace collision) namespace kcp-2r7hmup1y2r1 already exists, but has a different namespace locator annotation: \u0026{SyncTarget:{Path:root:org:ws Name:us-west1 UID:syncTargetUID} Workspace:root:org:ws Namespace:ANOTHERNAMESPACE} vs {SyncTarget:{Path:root:org:ws Name:us-west1 UID:syncTargetUID} Workspace:root:org:ws Namespace:test}"}`),
+			},
+			expectActionsOnTo: []clienttesting.Action{},
 		},
 		"SpecSyncer namespace conflict: try to sync to an already existing namespace without a namespace-locator, expect error": {
 			upstreamLogicalCluster: "root:org:ws",
@@ -923,8 +997,83 @@ func TestSyncerProcess(t *testing.T) {
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
 			expectError:                         true,
-			expectActionsOnFrom:                 []clienttesting.Action{},
-			expectActionsOnTo:                   []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0,"reason":"NamespaceCollision","message":"(namespace collision) namespace kcp-2r7hmup1y2r1 has no namespace locator"}`),
+			},
+			expectActionsOnTo: []clienttesting.Action{},
+		},
+		"SpecSyncer namespace drift: the labels of a namespace with the namespace-locator are repaired": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("test", "root:org:ws", map[string]string{
+				"state.workload.kcp.dev/us-west1": "Sync",
+			}, nil),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResources: []runtime.Object{
+				secret("default-token-abc", "test", "root:org:ws",
+					map[string]string{"state.workload.kcp.dev/us-west1": "Sync"},
+					map[string]string{"kubernetes.io/service-account.name": "default"},
+					map[string][]byte{
+						"token":     []byte("token"),
+						"namespace": []byte("namespace"),
+					}),
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, []string{"workload.kcp.dev/syncer-us-west1"}),
+			},
+			toResources: []runtime.Object{
+				namespace("kcp-2r7hmup1y2r1", "", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, map[string]string{
+					"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			},
+			resourceToProcessLogicalClusterName: "root:org:ws",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{
+				patchSyncStatusAction(t, "theDeployment", "test", "us-west1", `{"observedGeneration":0}`),
+			},
+			expectActionsOnTo: []clienttesting.Action{
+				createNamespaceAction(
+					"",
+					changeUnstructured(
+						toUnstructured(t, namespace("kcp-2r7hmup1y2r1", "",
+							map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							},
+							map[string]string{
+								"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+							})),
+						removeNilOrEmptyFields,
+					),
+				),
+				getNamespaceAction("kcp-2r7hmup1y2r1"),
+				updateNamespaceAction(
+					toUnstructured(t, namespace("kcp-2r7hmup1y2r1", "",
+						map[string]string{
+							"internal.workload.kcp.dev/cluster": "us-west1",
+							"state.workload.kcp.dev/us-west1":   "Sync",
+						},
+						map[string]string{
+							"kcp.dev/namespace-locator": `{"syncTarget":{"path":"root:org:ws","name":"us-west1","uid":"syncTargetUID"},"workspace":"root:org:ws","namespace":"test"}`,
+						})),
+				),
+				patchDeploymentAction(
+					"theDeployment",
+					"kcp-2r7hmup1y2r1",
+					types.ApplyPatchType,
+					toJson(t,
+						changeUnstructured(
+							toUnstructured(t, deployment("theDeployment", "kcp-2r7hmup1y2r1", "", map[string]string{
+								"internal.workload.kcp.dev/cluster": "us-west1",
+							}, nil, nil)),
+							setNestedField(map[string]interface{}{}, "status"),
+							setPodSpecServiceAccount("spec", "template", "spec"),
+						),
+					),
+				),
+			},
 		},
 	}
 
@@ -952,35 +1101,21 @@ func TestSyncerProcess(t *testing.T) {
 
 			toClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.toResources...)
 
-			fromInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClusterClient.Cluster(logicalcluster.Wildcard), time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-				o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + tc.syncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync)
-			})
-			toInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-				o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + tc.syncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync)
-			})
+			fromDiscovery := fakeDiscovery(tc.gvr)
+			syncerInformers := resourcesync.NewSyncerInformerFactory(fromClusterClient.Cluster(logicalcluster.Wildcard), toClient, fromDiscovery, tc.syncTargetName, sets.NewString(tc.gvr.GroupResource().String()), sets.NewString(), resourcesync.DiscoveryOptions{PollInterval: time.Hour})
 
 			setupServersideApplyPatchReactor(toClient)
-			namespaceWatcherStarted := setupWatchReactor("namespaces", fromClient)
 			resourceWatcherStarted := setupWatchReactor(tc.gvr.Resource, fromClient)
 
-			gvrs := []schema.GroupVersionResource{
-				{Group: "", Version: "v1", Resource: "namespaces"},
-				{Group: "", Version: "v1", Resource: "secrets"},
-				tc.gvr,
-			}
 			upstreamURL, err := url.Parse("https://kcp.dev:6443")
 			require.NoError(t, err)
-			controller, err := NewSpecSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID)
+			controller, err := NewSpecSyncer(kcpLogicalCluster, tc.syncTargetName, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, syncerInformers, syncTargetUID, NamespaceOptions{}, nil)
 			require.NoError(t, err)
 
-			fromInformers.Start(ctx.Done())
-			toInformers.Start(ctx.Done())
-
-			fromInformers.WaitForCacheSync(ctx.Done())
-			toInformers.WaitForCacheSync(ctx.Done())
+			require.NoError(t, syncerInformers.Start(ctx))
+			syncerInformers.WaitForCacheSync(ctx.Done())
 
 			<-resourceWatcherStarted
-			<-namespaceWatcherStarted
 
 			fromClient.ClearActions()
 			toClient.ClearActions()
@@ -1005,6 +1140,25 @@ func TestSyncerProcess(t *testing.T) {
 	}
 }
 
+func fakeDiscovery(gvr schema.GroupVersionResource) *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: gvr.GroupVersion().String(),
+					APIResources: []metav1.APIResource{
+						{
+							Name:       gvr.Resource,
+							Namespaced: true,
+							Verbs:      metav1.Verbs{"get", "list", "watch"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func setupServersideApplyPatchReactor(toClient *dynamicfake.FakeDynamicClient) {
 	toClient.PrependReactor("patch", "*", func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
 		patchAction := action.(clienttesting.PatchAction)
@@ -1165,6 +1319,13 @@ func getDeploymentAction(name, namespace string) clienttesting.GetActionImpl {
 	}
 }
 
+func getNamespaceAction(name string) clienttesting.GetActionImpl {
+	return clienttesting.GetActionImpl{
+		ActionImpl: namespaceAction("get"),
+		Name:       name,
+	}
+}
+
 func createNamespaceAction(name string, object runtime.Object) clienttesting.CreateActionImpl {
 	return clienttesting.CreateActionImpl{
 		ActionImpl: namespaceAction("create"),
@@ -1173,6 +1334,13 @@ func createNamespaceAction(name string, object runtime.Object) clienttesting.Cre
 	}
 }
 
+func updateNamespaceAction(object runtime.Object) clienttesting.UpdateActionImpl {
+	return clienttesting.UpdateActionImpl{
+		ActionImpl: namespaceAction("update"),
+		Object:     object,
+	}
+}
+
 func updateDeploymentAction(namespace string, object runtime.Object, subresources ...string) clienttesting.UpdateActionImpl {
 	return clienttesting.UpdateActionImpl{
 		ActionImpl: deploymentAction("update", namespace, subresources...),
@@ -1180,6 +1348,19 @@ func updateDeploymentAction(namespace string, object runtime.Object, subresource
 	}
 }
 
+// patchSyncStatusAction returns the patch of the sync status annotation of an upstream deployment.
+func patchSyncStatusAction(t require.TestingT, name, namespace, syncTargetName, status string) clienttesting.PatchActionImpl {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"experimental.sync-status.workload.kcp.dev/" + syncTargetName: status,
+			},
+		},
+	})
+	require.NoError(t, err)
+	return patchDeploymentAction(name, namespace, types.MergePatchType, patch)
+}
+
 func patchDeploymentAction(name, namespace string, patchType types.PatchType, patch []byte, subresources ...string) clienttesting.PatchActionImpl {
 	return clienttesting.PatchActionImpl{
 		ActionImpl: deploymentAction("patch", namespace, subresources...),
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"github.com/kcp-dev/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clusters"
+
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+var (
+	clusterRolesGroupResource    = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}
+	ingressClassesGroupResource  = schema.GroupResource{Group: "networking.k8s.io", Resource: "ingressclasses"}
+	priorityClassesGroupResource = schema.GroupResource{Group: "scheduling.k8s.io", Resource: "priorityclasses"}
+	storageClassesGroupResource  = schema.GroupResource{Group: "storage.k8s.io", Resource: "storageclasses"}
+)
+
+// downstreamNameFunc returns the name in the physical cluster of the cluster-scoped resource with the
+// given name, and false if the resource is not synced from the upstream workspace.
+type downstreamNameFunc func(groupResource schema.GroupResource, name string) (string, bool, error)
+
+// rewriteClusterScopedReferences rewrites the references of the downstream object to cluster-scoped
+// resources synced from the upstream workspace, to the names these resources are given in the physical
+// cluster. References to resources that are not synced, e.g. provided by the physical cluster, are kept.
+func rewriteClusterScopedReferences(obj *unstructured.Unstructured, downstreamName downstreamNameFunc) error {
+	if path := podSpecPath(obj); path != nil {
+		if err := rewriteReference(obj.Object, priorityClassesGroupResource, downstreamName, append(path, "priorityClassName")...); err != nil {
+			return err
+		}
+	}
+
+	switch obj.GetKind() {
+	case "PersistentVolumeClaim":
+		return rewriteReference(obj.Object, storageClassesGroupResource, downstreamName, "spec", "storageClassName")
+	case "StatefulSet":
+		templates, found, err := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		if err != nil || !found {
+			return err
+		}
+		for _, t := range templates {
+			template, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := rewriteReference(template, storageClassesGroupResource, downstreamName, "spec", "storageClassName"); err != nil {
+				return err
+			}
+		}
+		return unstructured.SetNestedSlice(obj.Object, templates, "spec", "volumeClaimTemplates")
+	case "Ingress":
+		return rewriteReference(obj.Object, ingressClassesGroupResource, downstreamName, "spec", "ingressClassName")
+	case "RoleBinding", "ClusterRoleBinding":
+		if kind, _, _ := unstructured.NestedString(obj.Object, "roleRef", "kind"); kind == "ClusterRole" {
+			return rewriteReference(obj.Object, clusterRolesGroupResource, downstreamName, "roleRef", "name")
+		}
+	}
+	return nil
+}
+
+// rewriteReference rewrites the name of the cluster-scoped resource referenced by the given field.
+func rewriteReference(obj map[string]interface{}, groupResource schema.GroupResource, downstreamName downstreamNameFunc, fields ...string) error {
+	name, found, err := unstructured.NestedString(obj, fields...)
+	if err != nil || !found || name == "" {
+		return err
+	}
+	newName, synced, err := downstreamName(groupResource, name)
+	if err != nil || !synced {
+		return err
+	}
+	return unstructured.SetNestedField(obj, newName, fields...)
+}
+
+// clusterScopedDownstreamName returns the downstreamNameFunc of the cluster-scoped resources synced
+// from the given upstream workspace.
+func (c *Controller) clusterScopedDownstreamName(workspace logicalcluster.Name) downstreamNameFunc {
+	return func(groupResource schema.GroupResource, name string) (string, bool, error) {
+		for _, gvr := range c.syncerInformers.SyncedGVRs() {
+			if gvr.GroupResource() != groupResource {
+				continue
+			}
+			syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+			if !ok {
+				continue
+			}
+			_, exists, err := syncerInformer.UpstreamInformer.GetIndexer().GetByKey(clusters.ToClusterAwareKey(workspace, name))
+			if err != nil {
+				return "", false, err
+			}
+			if !exists {
+				continue
+			}
+			downstreamName, err := shared.PhysicalClusterResourceName(shared.NewResourceLocator(workspace, c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, name))
+			if err != nil {
+				return "", false, err
+			}
+			return downstreamName, true, nil
+		}
+		return "", false, nil
+	}
+}
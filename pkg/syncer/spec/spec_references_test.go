@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+func TestRewriteClusterScopedReferences(t *testing.T) {
+	// Only the resources named "synced" are synced from the workspace.
+	downstreamName := func(groupResource schema.GroupResource, name string) (string, bool, error) {
+		if name != "synced" {
+			return "", false, nil
+		}
+		return "kcp-abc-" + groupResource.Resource + "-" + name, true, nil
+	}
+
+	tests := []struct {
+		name string
+		obj  string
+		want string
+	}{
+		{
+			name: "cluster role binding to a synced cluster role",
+			obj:  `{"kind":"ClusterRoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"synced"}}`,
+			want: `{"kind":"ClusterRoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"kcp-abc-clusterroles-synced"}}`,
+		},
+		{
+			name: "role binding to a cluster role of the physical cluster",
+			obj:  `{"kind":"RoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"view"}}`,
+			want: `{"kind":"RoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"view"}}`,
+		},
+		{
+			name: "role binding to a role",
+			obj:  `{"kind":"RoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"Role","name":"synced"}}`,
+			want: `{"kind":"RoleBinding","roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"Role","name":"synced"}}`,
+		},
+		{
+			name: "ingress",
+			obj:  `{"kind":"Ingress","spec":{"ingressClassName":"synced"}}`,
+			want: `{"kind":"Ingress","spec":{"ingressClassName":"kcp-abc-ingressclasses-synced"}}`,
+		},
+		{
+			name: "deployment",
+			obj:  `{"kind":"Deployment","spec":{"template":{"spec":{"priorityClassName":"synced"}}}}`,
+			want: `{"kind":"Deployment","spec":{"template":{"spec":{"priorityClassName":"kcp-abc-priorityclasses-synced"}}}}`,
+		},
+		{
+			name: "stateful set",
+			obj: `{"kind":"StatefulSet","spec":{"template":{"spec":{"priorityClassName":"high"}},` +
+				`"volumeClaimTemplates":[{"spec":{"storageClassName":"synced"}},{"spec":{"storageClassName":"standard"}},{"spec":{}}]}}`,
+			want: `{"kind":"StatefulSet","spec":{"template":{"spec":{"priorityClassName":"high"}},` +
+				`"volumeClaimTemplates":[{"spec":{"storageClassName":"kcp-abc-storageclasses-synced"}},{"spec":{"storageClassName":"standard"}},{"spec":{}}]}}`,
+		},
+		{
+			name: "persistent volume claim",
+			obj:  `{"kind":"PersistentVolumeClaim","spec":{"storageClassName":"synced"}}`,
+			want: `{"kind":"PersistentVolumeClaim","spec":{"storageClassName":"kcp-abc-storageclasses-synced"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			require.NoError(t, json.Unmarshal([]byte(tt.obj), &obj.Object))
+			want := &unstructured.Unstructured{}
+			require.NoError(t, json.Unmarshal([]byte(tt.want), &want.Object))
+
+			require.NoError(t, rewriteClusterScopedReferences(obj, downstreamName))
+			require.Equal(t, want, obj)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/kcp-dev/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// updateSyncStatus reports the result of syncing the upstream object in its
+// experimental.sync-status.workload.kcp.dev/<sync-target-name> annotation, so that users can find
+// out in kcp which generation landed on the sync target, or why it did not. Only shared.SyncError
+// failures are reported, other errors are considered transient. The annotation is only patched when
+// the status changes, and not anymore once the object is being removed from the sync target.
+// The sync error, if any, is returned.
+func (c *Controller) updateSyncStatus(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) error {
+	var syncError *shared.SyncError
+	if syncErr != nil && !errors.As(syncErr, &syncError) {
+		return syncErr
+	}
+	if upstreamObj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+c.syncTargetName] != "" {
+		return syncErr
+	}
+
+	existing, exists, err := shared.SyncStatusFromAnnotations(upstreamObj.GetAnnotations(), c.syncTargetName)
+	if err != nil {
+		klog.Errorf("Failed decoding sync status of upstream resource %s|%s/%s: %v", logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		exists = false
+	}
+
+	desired := newSyncStatus(upstreamObj.GetGeneration(), syncError)
+	if exists && *existing == desired {
+		return syncErr
+	}
+
+	b, err := json.Marshal(desired)
+	if err != nil {
+		return utilerrors.NewAggregate([]error{syncErr, err})
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				workloadv1alpha1.InternalClusterSyncStatusAnnotationPrefix + c.syncTargetName: string(b),
+			},
+		},
+	})
+	if err != nil {
+		return utilerrors.NewAggregate([]error{syncErr, err})
+	}
+
+	logicalCluster := logicalcluster.From(upstreamObj)
+	if _, err := c.upstreamClient.Cluster(logicalCluster).Resource(gvr).Namespace(upstreamObj.GetNamespace()).Patch(ctx, upstreamObj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Errorf("Failed patching sync status upstream on resource %s|%s/%s: %v", logicalCluster, upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		return utilerrors.NewAggregate([]error{syncErr, err})
+	}
+	return syncErr
+}
+
+func newSyncStatus(generation int64, syncError *shared.SyncError) shared.SyncStatus {
+	status := shared.SyncStatus{
+		ObservedGeneration: generation,
+	}
+	if syncError != nil {
+		status.Reason = syncError.Reason
+		status.Message = syncError.Error()
+	}
+	return status
+}
@@ -23,31 +23,36 @@ import (
 
 	"github.com/kcp-dev/logicalcluster"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
 const (
-	controllerName = "kcp-workload-syncer-status"
+	controllerName                         = "kcp-workload-syncer-status"
+	byDownstreamClusterScopedNameIndexName = "syncer-status-ByDownstreamClusterScopedName"
 )
 
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
-	upstreamClient                         dynamic.ClusterInterface
-	downstreamClient                       dynamic.Interface
-	upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory
-	downstreamNamespaceLister              cache.GenericLister
+	upstreamClient            dynamic.ClusterInterface
+	downstreamClient          dynamic.Interface
+	syncerInformers           *resourcesync.SyncerInformerFactory
+	downstreamNamespaceLister cache.GenericLister
 
 	syncTargetName            string
 	syncTargetClusterName     logicalcluster.Name
@@ -55,17 +60,16 @@ type Controller struct {
 	advancedSchedulingEnabled bool
 }
 
-func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName logicalcluster.Name, syncTargetName string, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+func NewStatusSyncer(syncTargetClusterName logicalcluster.Name, syncTargetName string, advancedSchedulingEnabled bool,
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, syncerInformers *resourcesync.SyncerInformerFactory, syncTargetUID types.UID) (*Controller, error) {
 
 	c := &Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 
 		upstreamClient:            upstreamClient,
 		downstreamClient:          downstreamClient,
-		upstreamInformers:         upstreamInformers,
-		downstreamInformers:       downstreamInformers,
-		downstreamNamespaceLister: downstreamInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Lister(),
+		syncerInformers:           syncerInformers,
+		downstreamNamespaceLister: cache.NewGenericLister(syncerInformers.DownstreamNamespaceInformer().GetIndexer(), schema.GroupResource{Resource: "namespaces"}),
 
 		syncTargetName:            syncTargetName,
 		syncTargetClusterName:     syncTargetClusterName,
@@ -73,31 +77,51 @@ func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetClusterName l
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
 	}
 
-	for _, gvr := range gvrs {
-		gvr := gvr // because used in closure
-
-		downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				oldUnstrob := oldObj.(*unstructured.Unstructured)
-				newUnstrob := newObj.(*unstructured.Unstructured)
-
-				if !deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob) {
-					c.AddToQueue(gvr, newUnstrob)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-		})
-		klog.InfoS("Set up informer", "clusterName", syncTargetClusterName, "pcluster", syncTargetName, "gvr", gvr.String())
+	syncerInformers.AddDownstreamEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			oldUnstrob := oldObj.(*unstructured.Unstructured)
+			newUnstrob := newObj.(*unstructured.Unstructured)
+
+			if !deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob) {
+				c.AddToQueue(gvr, newUnstrob)
+			}
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+	})
+	klog.InfoS("Set up downstream event handlers", "clusterName", syncTargetClusterName, "pcluster", syncTargetName)
+
+	if err := syncerInformers.AddUpstreamIndexers(cache.Indexers{
+		byDownstreamClusterScopedNameIndexName: c.indexByDownstreamClusterScopedName,
+	}); err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
 
+// indexByDownstreamClusterScopedName is a cache.IndexFunc that indexes cluster-scoped upstream objects
+// by the name of their downstream copy.
+func (c *Controller) indexByDownstreamClusterScopedName(obj interface{}) ([]string, error) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
+	}
+	if metaObj.GetNamespace() != "" {
+		return []string{}, nil
+	}
+	locator := shared.NewResourceLocator(logicalcluster.From(metaObj), c.syncTargetClusterName, c.syncTargetUID, c.syncTargetName, metaObj.GetName())
+	name, err := shared.PhysicalClusterResourceName(locator)
+	if err != nil {
+		return []string{}, err
+	}
+	return []string{name}, nil
+}
+
 type queueKey struct {
 	gvr schema.GroupVersionResource
 	key string // meta namespace key
@@ -151,7 +175,10 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, qk.gvr, qk.key); err != nil {
+	start := time.Now()
+	err := c.process(ctx, qk.gvr, qk.key)
+	metrics.RecordProcessed(metrics.StatusSyncer, qk.gvr, start, err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
 		return true
@@ -20,8 +20,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
@@ -29,15 +29,24 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	workloadcliplugin "github.com/kcp-dev/kcp/pkg/cliplugins/workload/plugin"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
+const (
+	// syncerStatusApplyManager is the field manager used by the status syncer to apply the status of
+	// upstream objects. It is different from the field manager of the spec syncer.
+	syncerStatusApplyManager = "syncer-status"
+)
+
 func deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob *unstructured.Unstructured) bool {
 	newFinalizers := newUnstrob.GetFinalizers()
 	oldFinalizers := oldUnstrob.GetFinalizers()
@@ -58,6 +67,9 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		return nil
 	}
 	downstreamClusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+	if downstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, key, name)
+	}
 	// TODO(sttts): do not reference the cli plugin here
 	if strings.HasPrefix(workloadcliplugin.SyncerIDPrefix, downstreamNamespace) {
 		// skip syncer namespace
@@ -93,9 +105,23 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	}
 	upstreamNamespace := namespaceLocator.Namespace
 	upstreamWorkspace := namespaceLocator.Workspace
+	if !c.syncerInformers.ServesWorkspace(upstreamWorkspace) {
+		// The status is synced by the syncers of the syncer virtual workspace URL serving the workspace.
+		klog.V(4).Infof("Workspace %s of downstream namespace %q is not served through this syncer virtual workspace, skipping %s", upstreamWorkspace, nsKey, key)
+		return nil
+	}
+
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		klog.V(2).Infof("Resource %q is not synced anymore, skipping %s", gvr.String(), key)
+		return nil
+	}
+	if !syncerInformer.HasSynced() {
+		return fmt.Errorf("informers for resource %q are not synced yet", gvr.String())
+	}
 
 	// get the downstream object
-	obj, exists, err := c.downstreamInformers.ForResource(gvr).Informer().GetIndexer().GetByKey(key)
+	obj, exists, err := syncerInformer.DownstreamInformer.GetIndexer().GetByKey(key)
 	if err != nil {
 		return err
 	}
@@ -112,23 +138,71 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	return c.updateStatusInUpstream(ctx, gvr, upstreamNamespace, upstreamWorkspace, u)
 }
 
-func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, downstreamObj *unstructured.Unstructured) error {
-	upstreamObj := downstreamObj.DeepCopy()
-	upstreamObj.SetUID("")
-	upstreamObj.SetResourceVersion("")
-	upstreamObj.SetNamespace(upstreamNamespace)
+// processClusterScoped syncs the status of a cluster-scoped downstream object to the upstream object it has been synced from.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, key, name string) error {
+	syncerInformer, ok := c.syncerInformers.InformerForResource(gvr)
+	if !ok {
+		klog.V(2).Infof("Resource %q is not synced anymore, skipping %s", gvr.String(), key)
+		return nil
+	}
+	if !syncerInformer.HasSynced() {
+		return fmt.Errorf("informers for resource %q are not synced yet", gvr.String())
+	}
 
-	// Run name transformations on upstreamObj
-	transformName(upstreamObj)
+	upstreamObjs, err := syncerInformer.UpstreamInformer.GetIndexer().ByIndex(byDownstreamClusterScopedNameIndexName, name)
+	if err != nil {
+		return err
+	}
+	if len(upstreamObjs) == 0 {
+		klog.V(4).Infof("No upstream object found for downstream GVR %q object %s", gvr.String(), name)
+		return nil
+	}
+	upstreamMeta, ok := upstreamObjs[0].(metav1.Object)
+	if !ok {
+		return fmt.Errorf("upstream object is expected to be metav1.Object, but is %T", upstreamObjs[0])
+	}
+	upstreamWorkspace := logicalcluster.From(upstreamMeta)
+	upstreamName := upstreamMeta.GetName()
 
-	name := upstreamObj.GetName()
-	downstreamStatus, statusExists, err := unstructured.NestedFieldCopy(upstreamObj.UnstructuredContent(), "status")
+	// get the downstream object
+	obj, exists, err := syncerInformer.DownstreamInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		klog.Infof("Downstream GVR %q object %s does not exist. Removing finalizer upstream", gvr.String(), name)
+		return shared.EnsureUpstreamFinalizerRemoved(ctx, gvr, c.upstreamClient, "", c.syncTargetName, upstreamWorkspace, upstreamName)
+	}
+
+	// update upstream status, under the upstream name
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+	u = u.DeepCopy()
+	u.SetName(upstreamName)
+	annotations := u.GetAnnotations()
+	delete(annotations, shared.ResourceLocatorAnnotation)
+	u.SetAnnotations(annotations)
+	return c.updateStatusInUpstream(ctx, gvr, "", upstreamWorkspace, u)
+}
+
+// updateStatusInUpstream writes the status of the downstream object to the upstream object. Only the status is
+// sent upstream, so that downstream-only metadata (labels, annotations, finalizers) never leaks into kcp.
+func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, downstreamObj *unstructured.Unstructured) error {
+	// Run name transformations on a copy of the downstream object
+	transformed := downstreamObj.DeepCopy()
+	transformName(transformed)
+	name := transformed.GetName()
+
+	downstreamStatus, statusExists, err := unstructured.NestedFieldCopy(downstreamObj.UnstructuredContent(), "status")
 	if err != nil {
 		return err
 	} else if !statusExists {
 		klog.Infof("Resource doesn't contain a status. Skipping updating status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace())
 		return nil
 	}
+	transformStatus(gvr.GroupResource(), downstreamStatus)
 
 	existing, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
@@ -136,47 +210,67 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 		return err
 	}
 
-	labels := upstreamObj.GetLabels()
-	delete(labels, workloadv1alpha1.InternalDownstreamClusterLabel)
-	labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+c.syncTargetName] = string(workloadv1alpha1.ResourceStateSync)
-	upstreamObj.SetLabels(labels)
-
-	// TODO: verify that we really only update status, and not some non-status fields in ObjectMeta.
-	//       I believe to remember that we had resources where that happened.
-
-	upstreamObj.SetResourceVersion(existing.GetResourceVersion())
-
 	if c.advancedSchedulingEnabled {
-		newUpstream := existing.DeepCopy()
 		statusAnnotationValue, err := json.Marshal(downstreamStatus)
 		if err != nil {
 			return err
 		}
-		newUpstreamAnnotations := newUpstream.GetAnnotations()
-		if newUpstreamAnnotations == nil {
-			newUpstreamAnnotations = make(map[string]string)
-		}
-		newUpstreamAnnotations[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+c.syncTargetName] = string(statusAnnotationValue)
-		newUpstream.SetAnnotations(newUpstreamAnnotations)
-
-		if reflect.DeepEqual(existing, newUpstream) {
+		statusAnnotation := workloadv1alpha1.InternalClusterStatusAnnotationPrefix + c.syncTargetName
+		if existing.GetAnnotations()[statusAnnotation] == string(statusAnnotationValue) {
 			klog.V(2).Infof("No need to update the status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace())
 			return nil
 		}
 
-		if _, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Update(ctx, newUpstream, metav1.UpdateOptions{}); err != nil {
-			klog.Errorf("Failed updating location status annotation of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace(), err)
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					statusAnnotation: string(statusAnnotationValue),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		_, err = c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		metrics.RecordStatusUpdate(gvr, start)
+		if err != nil {
+			klog.Errorf("Failed updating location status annotation of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace(), err)
 			return err
 		}
-		klog.Infof("Updated status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace())
+		metrics.RecordSyncedObject(metrics.StatusSyncer, gvr, metrics.UpsertOperation)
+		klog.Infof("Updated status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace())
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.UnstructuredContent()["status"], downstreamStatus) {
+		klog.V(2).Infof("No need to update the status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace())
 		return nil
 	}
 
-	if _, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).UpdateStatus(ctx, upstreamObj, metav1.UpdateOptions{}); err != nil {
-		klog.Errorf("Failed updating status of resource %q %s|%s/%s from pcluster namespace %s: %v", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace(), err)
+	// Server-side apply of the status subresource with only the identifying fields and the status, so
+	// that the spec and the metadata of the upstream object are never modified by the status syncer.
+	statusOnly := &unstructured.Unstructured{}
+	statusOnly.SetAPIVersion(existing.GetAPIVersion())
+	statusOnly.SetKind(existing.GetKind())
+	statusOnly.SetName(name)
+	statusOnly.SetNamespace(upstreamNamespace)
+	if err := unstructured.SetNestedField(statusOnly.UnstructuredContent(), downstreamStatus, "status"); err != nil {
+		return err
+	}
+	patch, err := json.Marshal(statusOnly)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err = c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Patch(ctx, name, types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: syncerStatusApplyManager, Force: pointer.Bool(true)}, "status")
+	metrics.RecordStatusUpdate(gvr, start)
+	if err != nil {
+		klog.Errorf("Failed updating status of resource %q %s|%s/%s from pcluster namespace %s: %v", gvr.String(), upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace(), err)
 		return err
 	}
-	klog.Infof("Updated status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace())
+	metrics.RecordSyncedObject(metrics.StatusSyncer, gvr, metrics.UpsertOperation)
+	klog.Infof("Updated status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, name, downstreamObj.GetNamespace())
 	return nil
 }
 
@@ -33,15 +33,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clusters"
 
-	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 )
 
 var scheme *runtime.Scheme
@@ -348,17 +349,157 @@ func TestSyncerProcess(t *testing.T) {
 			expectActionsOnFrom: []clienttesting.Action{},
 			expectActionsOnTo: []clienttesting.Action{
 				getDeploymentAction("theDeployment", "test"),
-				updateDeploymentAction("test",
-					toUnstructured(t, changeDeployment(
-						deployment("theDeployment", "test", "", map[string]string{
-							"state.workload.kcp.dev/us-west1": "Sync",
-						}, nil, nil),
-						addDeploymentStatus(appsv1.DeploymentStatus{
-							Replicas: 15,
-						}))),
+				patchDeploymentAction("theDeployment", "test", types.ApplyPatchType,
+					[]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"theDeployment","namespace":"test"},"status":{"replicas":15}}`),
 					"status"),
 			},
 		},
+		"StatusSyncer upsert to existing resource, downstream metadata is not synced upstream": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+					"downstream-only":                   "label",
+				}, map[string]string{
+					"downstream-only": "annotation",
+				}, []string{"downstream-only/finalizer"}),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, nil),
+			},
+			resourceToProcessLogicalClusterName: "",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				getDeploymentAction("theDeployment", "test"),
+				patchDeploymentAction("theDeployment", "test", types.ApplyPatchType,
+					[]byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"theDeployment","namespace":"test"},"status":{"replicas":15}}`),
+					"status"),
+			},
+		},
+		"StatusSyncer upsert to existing service, the cluster IP and node ports are not synced upstream": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"},
+			fromResource: service("theService", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+				"internal.workload.kcp.dev/cluster": "us-west1",
+			}, corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeLoadBalancer,
+				ClusterIP: "10.0.0.10",
+				Ports:     []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+			}, corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}},
+			}),
+			toResources: []runtime.Object{
+				service("theService", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeLoadBalancer,
+					Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+				}, corev1.ServiceStatus{}),
+			},
+			resourceToProcessLogicalClusterName: "",
+			resourceToProcessName:               "theService",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				clienttesting.GetActionImpl{
+					ActionImpl: serviceAction("get", "test"),
+					Name:       "theService",
+				},
+				clienttesting.PatchActionImpl{
+					ActionImpl: serviceAction("patch", "test", "status"),
+					Name:       "theService",
+					PatchType:  types.ApplyPatchType,
+					Patch:      []byte(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"theService","namespace":"test"},"status":{"loadBalancer":{"ingress":[{"ip":"1.2.3.4"}]}}}`),
+				},
+			},
+		},
+		"StatusSyncer upsert to existing resource, status unchanged": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"workspace":"root:org:ws","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				changeDeployment(
+					deployment("theDeployment", "test", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/us-west1": "Sync",
+					}, nil, nil),
+					addDeploymentStatus(appsv1.DeploymentStatus{
+						Replicas: 15,
+					})),
+			},
+			resourceToProcessLogicalClusterName: "",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				getDeploymentAction("theDeployment", "test"),
+			},
+		},
+		"StatusSyncer skips objects of a workspace served through another syncer virtual workspace": {
+			upstreamLogicalCluster: "root:org:ws",
+			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
+				map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				},
+				map[string]string{
+					"kcp.dev/namespace-locator": `{"workspace":"root:org:other","namespace":"test"}`,
+				}),
+			gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			fromResource: changeDeployment(
+				deployment("theDeployment", "kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "", map[string]string{
+					"internal.workload.kcp.dev/cluster": "us-west1",
+				}, nil, nil),
+				addDeploymentStatus(appsv1.DeploymentStatus{
+					Replicas: 15,
+				})),
+			toResources: []runtime.Object{
+				deployment("theDeployment", "test", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/us-west1": "Sync",
+				}, nil, nil),
+			},
+			resourceToProcessLogicalClusterName: "",
+			resourceToProcessName:               "theDeployment",
+			syncTargetName:                      "us-west1",
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo:   []clienttesting.Action{},
+		},
 		"StatusSyncer upstream deletion": {
 			upstreamLogicalCluster: "root:org:ws",
 			fromNamespace: namespace("kcp0124d7647eb6a00b1fcb6f2252201601634989dd79deb7375c373973", "",
@@ -418,13 +559,8 @@ func TestSyncerProcess(t *testing.T) {
 			expectActionsOnFrom: []clienttesting.Action{},
 			expectActionsOnTo: []clienttesting.Action{
 				getDeploymentAction("theDeployment", "test"),
-				updateDeploymentAction("test",
-					toUnstructured(t, changeDeployment(
-						deployment("theDeployment", "test", "root:org:ws", map[string]string{
-							"state.workload.kcp.dev/us-west1": "Sync",
-						}, map[string]string{
-							"experimental.status.workload.kcp.dev/us-west1": "{\"replicas\":15}",
-						}, nil)))),
+				patchDeploymentAction("theDeployment", "test", types.MergePatchType,
+					[]byte(`{"metadata":{"annotations":{"experimental.status.workload.kcp.dev/us-west1":"{\"replicas\":15}"}}}`)),
 			},
 		},
 		"StatusSyncer with AdvancedScheduling, deletion: object exists upstream": {
@@ -528,29 +664,18 @@ func TestSyncerProcess(t *testing.T) {
 				client: toClient,
 			}
 
-			fromInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-				o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + tc.syncTargetName
-			})
-			toInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClusterClient.Cluster(logicalcluster.Wildcard), time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-				o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + tc.syncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync)
-			})
+			toDiscovery := fakeDiscovery(tc.gvr)
+			syncerInformers := resourcesync.NewSyncerInformerFactory(toClusterClient.Cluster(logicalcluster.Wildcard), fromClient, toDiscovery, tc.syncTargetName, sets.NewString(tc.gvr.GroupResource().String()), sets.NewString(), resourcesync.DiscoveryOptions{PollInterval: time.Hour})
 
 			setupServersideApplyPatchReactor(toClient)
 			namespaceWatcherStarted := setupWatchReactor("namespaces", fromClient)
 			resourceWatcherStarted := setupWatchReactor(tc.gvr.Resource, fromClient)
 
-			gvrs := []schema.GroupVersionResource{
-				{Group: "", Version: "v1", Resource: "namespaces"},
-				tc.gvr,
-			}
-			controller, err := NewStatusSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, syncTargetUID)
+			controller, err := NewStatusSyncer(kcpLogicalCluster, tc.syncTargetName, tc.advancedSchedulingEnabled, toClusterClient, fromClient, syncerInformers, syncTargetUID)
 			require.NoError(t, err)
 
-			fromInformers.Start(ctx.Done())
-			toInformers.Start(ctx.Done())
-
-			fromInformers.WaitForCacheSync(ctx.Done())
-			toInformers.WaitForCacheSync(ctx.Done())
+			require.NoError(t, syncerInformers.Start(ctx))
+			syncerInformers.WaitForCacheSync(ctx.Done())
 
 			<-resourceWatcherStarted
 			<-namespaceWatcherStarted
@@ -559,14 +684,7 @@ func TestSyncerProcess(t *testing.T) {
 			toClient.ClearActions()
 
 			key := tc.fromNamespace.Name + "/" + clusters.ToClusterAwareKey(logicalcluster.New(tc.resourceToProcessLogicalClusterName), tc.resourceToProcessName)
-			err = controller.process(context.Background(),
-				schema.GroupVersionResource{
-					Group:    "apps",
-					Version:  "v1",
-					Resource: "deployments",
-				},
-				key,
-			)
+			err = controller.process(context.Background(), tc.gvr, key)
 			if tc.expectError {
 				assert.Error(t, err)
 			} else {
@@ -578,6 +696,25 @@ func TestSyncerProcess(t *testing.T) {
 	}
 }
 
+func fakeDiscovery(gvr schema.GroupVersionResource) *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: gvr.GroupVersion().String(),
+					APIResources: []metav1.APIResource{
+						{
+							Name:       gvr.Resource,
+							Namespaced: true,
+							Verbs:      metav1.Verbs{"get", "list", "watch"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func setupServersideApplyPatchReactor(toClient *dynamicfake.FakeDynamicClient) {
 	toClient.PrependReactor("patch", "*", func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
 		patchAction := action.(clienttesting.PatchAction)
@@ -665,6 +802,32 @@ func setNestedField(value interface{}, fields ...string) unstructuredChange {
 	}
 }
 
+func service(name, namespace, clusterName string, labels map[string]string, spec corev1.ServiceSpec, status corev1.ServiceStatus) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			ClusterName: clusterName,
+			Labels:      labels,
+		},
+		Spec:   spec,
+		Status: status,
+	}
+}
+
+func serviceAction(verb, namespace string, subresources ...string) clienttesting.ActionImpl {
+	return clienttesting.ActionImpl{
+		Namespace:   namespace,
+		Verb:        verb,
+		Resource:    schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"},
+		Subresource: strings.Join(subresources, "/"),
+	}
+}
+
 func deploymentAction(verb, namespace string, subresources ...string) clienttesting.ActionImpl {
 	return clienttesting.ActionImpl{
 		Namespace:   namespace,
@@ -681,6 +844,15 @@ func getDeploymentAction(name, namespace string) clienttesting.GetActionImpl {
 	}
 }
 
+func patchDeploymentAction(name, namespace string, patchType types.PatchType, patch []byte, subresources ...string) clienttesting.PatchActionImpl {
+	return clienttesting.PatchActionImpl{
+		ActionImpl: deploymentAction("patch", namespace, subresources...),
+		Name:       name,
+		PatchType:  patchType,
+		Patch:      patch,
+	}
+}
+
 func updateDeploymentAction(namespace string, object runtime.Object, subresources ...string) clienttesting.UpdateActionImpl {
 	return clienttesting.UpdateActionImpl{
 		ActionImpl: deploymentAction("update", namespace, subresources...),
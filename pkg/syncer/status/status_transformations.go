@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"net"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// statusTransformations remove from the statuses of the downstream objects the values allocated by the
+// physical cluster that are meaningless in kcp, like the cluster IPs and node ports removed from the specs
+// by the spec syncer mutators, before the statuses are synced upstream.
+var statusTransformations = map[schema.GroupResource]func(status map[string]interface{}){
+	{Group: "", Resource: "pods"}:                       transformPodStatus,
+	{Group: "", Resource: "services"}:                   transformLoadBalancerStatus,
+	{Group: "networking.k8s.io", Resource: "ingresses"}: transformLoadBalancerStatus,
+}
+
+// clusterNetworks are the private, shared, loopback and link-local networks, whose addresses are only
+// reachable from the network of the physical cluster.
+var clusterNetworks = parseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"fc00::/7",
+	"fe80::/10",
+	"::1/128",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func inClusterNetwork(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range clusterNetworks {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// transformStatus applies the status transformation of the resource, if any, to the status.
+func transformStatus(gr schema.GroupResource, status interface{}) {
+	transform, ok := statusTransformations[gr]
+	if !ok {
+		return
+	}
+	if status, ok := status.(map[string]interface{}); ok {
+		transform(status)
+	}
+}
+
+// transformPodStatus removes the IPs of the pod and of its node in the network of the physical cluster.
+func transformPodStatus(status map[string]interface{}) {
+	delete(status, "podIP")
+	delete(status, "podIPs")
+	delete(status, "hostIP")
+}
+
+// transformLoadBalancerStatus removes the load balancer ingress IPs of a Service or an Ingress that are
+// in the network of the physical cluster, e.g. of internal load balancers, the same way the spec syncer
+// removes the requested load balancer IP. The public IPs and the hostnames are kept, as they are how
+// the workload is reached from outside of the physical cluster.
+func transformLoadBalancerStatus(status map[string]interface{}) {
+	loadBalancer, ok := status["loadBalancer"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	ingresses, ok := loadBalancer["ingress"].([]interface{})
+	if !ok {
+		return
+	}
+
+	kept := make([]interface{}, 0, len(ingresses))
+	for _, entry := range ingresses {
+		ingress, ok := entry.(map[string]interface{})
+		if !ok {
+			kept = append(kept, entry)
+			continue
+		}
+		if ip, ok := ingress["ip"].(string); ok && inClusterNetwork(ip) {
+			delete(ingress, "ip")
+			if hostname, _ := ingress["hostname"].(string); hostname == "" {
+				continue
+			}
+		}
+		kept = append(kept, ingress)
+	}
+	if len(kept) == 0 {
+		delete(loadBalancer, "ingress")
+		return
+	}
+	loadBalancer["ingress"] = kept
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestTransformStatus(t *testing.T) {
+	tests := map[string]struct {
+		gr     schema.GroupResource
+		status interface{}
+		want   interface{}
+	}{
+		"pod IPs are removed": {
+			gr: schema.GroupResource{Resource: "pods"},
+			status: map[string]interface{}{
+				"phase":  "Running",
+				"podIP":  "10.1.0.5",
+				"podIPs": []interface{}{map[string]interface{}{"ip": "10.1.0.5"}},
+				"hostIP": "192.168.0.2",
+			},
+			want: map[string]interface{}{
+				"phase": "Running",
+			},
+		},
+		"public load balancer IPs of a service are kept": {
+			gr: schema.GroupResource{Resource: "services"},
+			status: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{map[string]interface{}{"ip": "1.2.3.4"}}},
+			},
+			want: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{map[string]interface{}{"ip": "1.2.3.4"}}},
+			},
+		},
+		"load balancer IPs of a service in the network of the physical cluster are removed": {
+			gr: schema.GroupResource{Resource: "services"},
+			status: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"ip": "10.0.0.10"},
+					map[string]interface{}{"ip": "1.2.3.4"},
+					map[string]interface{}{"ip": "fd00::10"},
+				}},
+			},
+			want: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"ip": "1.2.3.4"},
+				}},
+			},
+		},
+		"load balancer hostnames of an ingress are kept without the private IPs": {
+			gr: schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
+			status: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"ip": "192.168.1.5", "hostname": "lb.example.com"},
+				}},
+			},
+			want: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"hostname": "lb.example.com"},
+				}},
+			},
+		},
+		"load balancer of an ingress only in the network of the physical cluster is removed": {
+			gr: schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
+			status: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"ip": "172.18.0.2"},
+				}},
+			},
+			want: map[string]interface{}{
+				"loadBalancer": map[string]interface{}{},
+			},
+		},
+		"status of a resource without transformation is kept": {
+			gr: schema.GroupResource{Group: "apps", Resource: "deployments"},
+			status: map[string]interface{}{
+				"replicas": int64(1),
+			},
+			want: map[string]interface{}{
+				"replicas": int64(1),
+			},
+		},
+		"status that is not an object is kept": {
+			gr:     schema.GroupResource{Resource: "pods"},
+			status: "unexpected",
+			want:   "unexpected",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			transformStatus(tc.gr, tc.status)
+			require.Equal(t, tc.want, tc.status)
+		})
+	}
+}
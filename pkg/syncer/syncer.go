@@ -20,19 +20,19 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"strings"
+	"reflect"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -40,9 +40,11 @@ import (
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/syncer/metrics"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
 	"github.com/kcp-dev/kcp/pkg/syncer/spec"
 	"github.com/kcp-dev/kcp/pkg/syncer/status"
-	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
 const (
@@ -50,11 +52,12 @@ const (
 
 	resyncPeriod = 10 * time.Hour
 
-	// TODO(marun) Coordinate this value with the interval configured for the heartbeat controller
-	heartbeatInterval = 20 * time.Second
-
-	// TODO(marun) Ensure backoff rather than using a constant to avoid thundering herds
-	gvrQueryInterval = 1 * time.Second
+	// initialDiscoveryAttempts is the number of attempts of the first discovery of the resource types
+	// of a syncer virtual workspace URL, before its syncers fail to start.
+	initialDiscoveryAttempts = 5
+	// cacheSyncTimeout is the time after which the syncers of a syncer virtual workspace URL fail to
+	// start if their informers have not synced.
+	cacheSyncTimeout = 2 * time.Minute
 )
 
 // SyncerConfig defines the syncer configuration that is guaranteed to
@@ -66,11 +69,24 @@ type SyncerConfig struct {
 	ResourcesToSync  sets.String
 	KCPClusterName   logicalcluster.Name
 	SyncTargetName   string
+
+	// ClusterScopedResourcesToSync are the cluster-scoped resources that are synced
+	// in addition to ResourcesToSync. Cluster-scoped resources are not synced otherwise.
+	ClusterScopedResourcesToSync sets.String
+
+	// NamespaceOptions configures the propagation of upstream namespace metadata downstream.
+	NamespaceOptions spec.NamespaceOptions
+
+	// BackoffOptions configures the heartbeat, the discovery polling and the retries of failed requests.
+	// Unset intervals are defaulted.
+	BackoffOptions BackoffOptions
 }
 
 func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, importPollInterval time.Duration) error {
 	klog.Infof("Starting syncer for logical-cluster: %s, sync-target: %s", cfg.KCPClusterName, cfg.SyncTargetName)
 
+	backoffOptions := cfg.BackoffOptions.withDefaults()
+
 	kcpVersion := version.Get().GitVersion
 
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(rest.AddUserAgent(rest.CopyConfig(cfg.UpstreamConfig), "kcp#syncer/"+kcpVersion))
@@ -78,51 +94,67 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		return err
 	}
 
-	// TODO(david): Implement real support for several virtual workspace URLs that can change over time.
-	// TODO(david): For now we retrieve the syncerVirtualWorkpaceURL at start, since we temporarily stick to a single URL (sharding not supported).
-	// TODO(david): But the complete implementation should setup a SyncTarget informer, create spec and status syncer for every URLs found in the
-	// TODO(david): Status.SyncerVirtualWorkspaceURLs slice, and update them each time this list changes.
-	var syncerVirtualWorkspaceURL string
-	// TODO(david): we need to provide user-facing details if this polling goes on forever. Blocking here is a bad UX.
-	// TODO(david): Also, any regressions in our code will make any e2e test that starts a syncer (at least in-process)
-	// TODO(david): block until it hits the 10 minute overall test timeout.
-	klog.Infof("Attempting to retrieve the Syncer virtual workspace URL from SyncTarget %s|%s", cfg.KCPClusterName, cfg.SyncTargetName)
-	var syncTarget *workloadv1alpha1.SyncTarget
-	err = wait.PollImmediateInfinite(5*time.Second, func() (bool, error) {
-		var err error
-		syncTarget, err = kcpClusterClient.Cluster(cfg.KCPClusterName).WorkloadV1alpha1().SyncTargets().Get(ctx, cfg.SyncTargetName, metav1.GetOptions{})
-		if err != nil {
-			return false, err
-		}
-
-		if len(syncTarget.Status.VirtualWorkspaces) == 0 {
-			return false, nil
-		}
-
-		if len(syncTarget.Status.VirtualWorkspaces) > 1 {
-			klog.Errorf("SyncTarget %s|%s should not have several Syncer virtual workspace URLs: not supported for now, ignoring additional URLs", cfg.KCPClusterName, cfg.SyncTargetName)
-		}
-		syncerVirtualWorkspaceURL = syncTarget.Status.VirtualWorkspaces[0].URL
-		return true, nil
-	})
-	if err != nil {
-		return err
-	}
-
 	// Resources are accepted as a set to ensure the provision of a
 	// unique set of resources, but all subsequent consumption is via
 	// slice whose entries are assumed to be unique.
-	resources := cfg.ResourcesToSync.List()
+	resources := cfg.ResourcesToSync.Union(cfg.ClusterScopedResourcesToSync).List()
 
-	// Start api import first because spec and status syncers are blocked by
-	// gvr discovery finding all the configured resource types in the kcp
-	// workspace.
+	// Start api import first, so that the configured resource types get
+	// published in the kcp workspace, and picked up by the gvr discovery
+	// of the spec and status syncers, as soon as possible.
 	apiImporter, err := NewAPIImporter(cfg.UpstreamConfig, cfg.DownstreamConfig, resources, cfg.KCPClusterName, cfg.SyncTargetName)
 	if err != nil {
 		return err
 	}
 	go apiImporter.Start(ctx, importPollInterval)
 
+	// Spec and status syncers are started for every syncer virtual workspace URL found in the
+	// SyncTarget status, and stopped when the URL disappears. The SyncTarget informer is restricted
+	// to the SyncTarget of this syncer.
+	kcpInformerFactory := kcpexternalversions.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(cfg.KCPClusterName), resyncPeriod,
+		kcpexternalversions.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.SyncTargetName).String()
+		}))
+	virtualWorkspacesController := newVirtualWorkspacesController(kcpClusterClient.Cluster(cfg.KCPClusterName), kcpInformerFactory.Workload().V1alpha1().SyncTargets(),
+		func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, virtualWorkspaceURL string) error {
+			return startSyncers(ctx, cfg, backoffOptions, syncTarget, virtualWorkspaceURL, numSyncerThreads)
+		},
+		backoffOptions)
+	kcpInformerFactory.Start(ctx.Done())
+	kcpInformerFactory.WaitForCacheSync(ctx.Done())
+	go virtualWorkspacesController.Start(ctx, 1)
+
+	// Attempt to heartbeat every interval, with jitter to spread the heartbeats of many syncers.
+	go wait.JitterUntilWithContext(ctx, func(ctx context.Context) {
+		var heartbeatTime time.Time
+
+		// Attempt to heartbeat with an exponential backoff until successful.
+		retryUntilSucceeded(ctx, backoffOptions.retryBackoff(), func(ctx context.Context) bool {
+			patchBytes := []byte(fmt.Sprintf(`[{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, time.Now().Format(time.RFC3339)))
+			syncTarget, err := kcpClusterClient.Cluster(cfg.KCPClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+			metrics.RecordHeartbeat(err)
+			if err != nil {
+				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for SyncTarget %s|%s: %v", cfg.KCPClusterName, cfg.SyncTargetName, err)
+				return false
+			}
+			heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
+			return true
+		})
+
+		klog.V(5).Infof("Heartbeat set for SyncTarget %s|%s: %s", cfg.KCPClusterName, cfg.SyncTargetName, heartbeatTime)
+
+	}, backoffOptions.HeartbeatInterval, backoffOptions.Jitter, true)
+
+	return nil
+}
+
+// startSyncers starts a spec and a status syncer that sync resources through the given syncer
+// virtual workspace URL. It returns once the informers of the synced resource types are synced,
+// and the syncers are stopped when ctx is done.
+func startSyncers(ctx context.Context, cfg *SyncerConfig, backoffOptions BackoffOptions, syncTarget *workloadv1alpha1.SyncTarget, syncerVirtualWorkspaceURL string, numSyncerThreads int) error {
+	kcpVersion := version.Get().GitVersion
+	resources := cfg.ResourcesToSync.Union(cfg.ClusterScopedResourcesToSync).List()
+
 	upstreamConfig := rest.CopyConfig(cfg.UpstreamConfig)
 	upstreamConfig.Host = syncerVirtualWorkspaceURL
 	upstreamConfig.UserAgent = "kcp#spec-syncer/" + kcpVersion
@@ -142,41 +174,41 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		return err
 	}
 	upstreamDiscoveryClient := upstreamDiscoveryClusterClient.WithCluster(logicalcluster.Wildcard)
-
-	upstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(upstreamDynamicClusterClient.Cluster(logicalcluster.Wildcard), resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + cfg.SyncTargetName + "=" + string(workloadv1alpha1.ResourceStateSync)
-	})
-	downstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactoryWithOptions(downstreamDynamicClient, metav1.NamespaceAll, func(o *metav1.ListOptions) {
-		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + cfg.SyncTargetName
-	}, cache.WithResyncPeriod(resyncPeriod), cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc))
-
-	// TODO(ncdc): we need to provide user-facing details if this polling goes on forever. Blocking here is a bad UX.
-	// TODO(ncdc): Also, any regressions in our code will make any e2e test that starts a syncer (at least in-process)
-	// TODO(ncdc): block until it hits the 10 minute overall test timeout.
-	//
-	// Block syncer start on gvr discovery completing successfully and
-	// including the resources configured for syncing. The spec and status
-	// syncers depend on the types being present to start their informers.
-	var gvrs []schema.GroupVersionResource
-	err = wait.PollImmediateInfinite(gvrQueryInterval, func() (bool, error) {
-		klog.Infof("Attempting to retrieve GVRs from upstream clusterName %s (for pcluster %s)", cfg.KCPClusterName, cfg.SyncTargetName)
-
-		var err error
-		// Get all types the upstream API server knows about.
-		// TODO: watch this and learn about new types, or forget about old ones.
-		gvrs, err = getAllGVRs(upstreamDiscoveryClient, resources...)
-		// TODO(marun) Should some of these errors be fatal?
-		if err != nil {
-			klog.Errorf("Failed to retrieve GVRs from kcp: %v", err)
-			return false, nil
-		}
-		return true, nil
-	})
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(rest.AddUserAgent(rest.CopyConfig(cfg.UpstreamConfig), "kcp#spec-syncer/"+kcpVersion))
 	if err != nil {
-		// Should never happen
 		return err
 	}
 
+	// The SyncTarget is watched for its syncer virtual workspace URLs and its labels.
+	syncTargetInformerFactory := kcpexternalversions.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(cfg.KCPClusterName), resyncPeriod,
+		kcpexternalversions.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.SyncTargetName).String()
+		}))
+	syncTargetInformer := syncTargetInformerFactory.Workload().V1alpha1().SyncTargets()
+
+	// The informers of the synced resource types are started and stopped as the types
+	// appear and disappear in the syncer virtual workspace.
+	syncerInformers := resourcesync.NewSyncerInformerFactory(upstreamDynamicClusterClient.Cluster(logicalcluster.Wildcard), downstreamDynamicClient,
+		upstreamDiscoveryClient, cfg.SyncTargetName, cfg.ResourcesToSync, cfg.ClusterScopedResourcesToSync,
+		resourcesync.DiscoveryOptions{
+			PollInterval: backoffOptions.DiscoveryPollInterval,
+			PollJitter:   backoffOptions.Jitter,
+			RetryBackoff: backoffOptions.retryBackoff(),
+			// The syncers of the virtual workspace URL are started again with a backoff if the discovery fails.
+			InitialAttempts: initialDiscoveryAttempts,
+		})
+	syncerInformers.SetWorkspaceLookup(resourcesync.WorkspaceLookup{
+		UpstreamClusterClient: upstreamDynamicClusterClient,
+		SoleVirtualWorkspace: func() bool {
+			syncTarget, err := syncTargetInformer.Lister().Get(cfg.SyncTargetName)
+			if err != nil {
+				return false
+			}
+			virtualWorkspaces := syncTarget.Status.VirtualWorkspaces
+			return len(virtualWorkspaces) == 1 && virtualWorkspaces[0].URL == syncerVirtualWorkspaceURL
+		},
+	})
+
 	// Check whether we're in the Advanced Scheduling feature-gated mode.
 	advancedSchedulingEnabled := false
 	if syncTarget.GetAnnotations()[AdvancedSchedulingFeatureAnnotation] == "true" {
@@ -184,146 +216,82 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		advancedSchedulingEnabled = true
 	}
 
-	klog.Infof("Creating spec syncer for clusterName %s to pcluster %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, resources)
+	// The spec overrides are declared by SyncTargetOverrides for the Locations the SyncTarget is an instance of,
+	// in the workspace of the SyncTarget.
+	overridesInformerFactory := kcpexternalversions.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(cfg.KCPClusterName), resyncPeriod)
+	locationInformer := overridesInformerFactory.Scheduling().V1alpha1().Locations()
+	syncTargetOverrideInformer := overridesInformerFactory.Workload().V1alpha1().SyncTargetOverrides()
+	specOverrides := spec.NewSpecOverridesFunc(cfg.SyncTargetName, syncTargetInformer.Lister(), locationInformer.Lister(), syncTargetOverrideInformer.Lister())
+
+	klog.Infof("Creating spec syncer for clusterName %s to pcluster %s through %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, syncerVirtualWorkspaceURL, resources)
 	upstreamURL, err := url.Parse(cfg.UpstreamConfig.Host)
 	if err != nil {
 		return err
 	}
-	specSyncer, err := spec.NewSpecSyncer(gvrs, cfg.KCPClusterName, cfg.SyncTargetName, upstreamURL, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
+	specSyncer, err := spec.NewSpecSyncer(cfg.KCPClusterName, cfg.SyncTargetName, upstreamURL, advancedSchedulingEnabled,
+		upstreamDynamicClusterClient, downstreamDynamicClient, syncerInformers, syncTarget.GetUID(), cfg.NamespaceOptions, specOverrides)
 	if err != nil {
 		return err
 	}
 
-	klog.Infof("Creating status syncer for clusterName %s from pcluster %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, resources)
-	statusSyncer, err := status.NewStatusSyncer(gvrs, cfg.KCPClusterName, cfg.SyncTargetName, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
+	// Objects are synced again when the overrides that may apply to them change.
+	resyncOnChange := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { specSyncer.EnqueueAllUpstream() },
+		UpdateFunc: func(_, obj interface{}) { specSyncer.EnqueueAllUpstream() },
+		DeleteFunc: func(obj interface{}) { specSyncer.EnqueueAllUpstream() },
+	}
+	locationInformer.Informer().AddEventHandler(resyncOnChange)
+	syncTargetOverrideInformer.Informer().AddEventHandler(resyncOnChange)
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if !equality.Semantic.DeepEqual(oldObj.(*workloadv1alpha1.SyncTarget).Labels, newObj.(*workloadv1alpha1.SyncTarget).Labels) {
+				specSyncer.EnqueueAllUpstream()
+			}
+		},
+	})
+
+	klog.Infof("Creating status syncer for clusterName %s from pcluster %s through %s, resources %v", cfg.KCPClusterName, cfg.SyncTargetName, syncerVirtualWorkspaceURL, resources)
+	statusSyncer, err := status.NewStatusSyncer(cfg.KCPClusterName, cfg.SyncTargetName, advancedSchedulingEnabled,
+		upstreamDynamicClusterClient, downstreamDynamicClient, syncerInformers, syncTarget.GetUID())
 	if err != nil {
 		return err
 	}
 
-	upstreamInformers.Start(ctx.Done())
-	downstreamInformers.Start(ctx.Done())
-
-	upstreamInformers.WaitForCacheSync(ctx.Done())
-	downstreamInformers.WaitForCacheSync(ctx.Done())
-
-	go specSyncer.Start(ctx, numSyncerThreads)
-	go statusSyncer.Start(ctx, numSyncerThreads)
-
-	// Attempt to heartbeat every interval
-	go wait.UntilWithContext(ctx, func(ctx context.Context) {
-		var heartbeatTime time.Time
-
-		// TODO(marun) Figure out a strategy for backoff to avoid a thundering herd problem with lots of syncers
-
-		// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
-		// poll error can be safely ignored.
-		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
-			patchBytes := []byte(fmt.Sprintf(`[{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, time.Now().Format(time.RFC3339)))
-			syncTarget, err := kcpClusterClient.Cluster(cfg.KCPClusterName).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
-			if err != nil {
-				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for SyncTarget %s|%s: %v", cfg.KCPClusterName, cfg.SyncTargetName, err)
-				return false, nil
+	// The SyncTarget must be known before the upstream informers look up workspaces.
+	kcpSyncCtx, kcpCancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer kcpCancel()
+	syncTargetInformerFactory.Start(ctx.Done())
+	overridesInformerFactory.Start(ctx.Done())
+	for _, synced := range []map[reflect.Type]bool{syncTargetInformerFactory.WaitForCacheSync(kcpSyncCtx.Done()), overridesInformerFactory.WaitForCacheSync(kcpSyncCtx.Done())} {
+		for informerType, ok := range synced {
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return fmt.Errorf("timed out waiting for the informer of %v to sync", informerType)
 			}
-			heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
-			return true, nil
-		})
-
-		klog.V(5).Infof("Heartbeat set for SyncTarget %s|%s: %s", cfg.KCPClusterName, cfg.SyncTargetName, heartbeatTime)
-
-	}, heartbeatInterval)
-
-	return nil
-}
-
-func contains(ss []string, s string) bool {
-	for _, n := range ss {
-		if n == s {
-			return true
 		}
 	}
-	return false
-}
 
-func getAllGVRs(discoveryClient discovery.DiscoveryInterface, resourcesToSync ...string) ([]schema.GroupVersionResource, error) {
-	toSyncSet := sets.NewString(resourcesToSync...)
-	willBeSyncedSet := sets.NewString()
-	rs, err := discoveryClient.ServerPreferredResources()
-	if err != nil {
-		if strings.Contains(err.Error(), "unable to retrieve the complete list of server APIs") {
-			// This error may occur when some API resources added from CRDs are not completely ready.
-			// We should just retry without a limit on the number of retries in such a case.
-			//
-			// In fact this might be related to a bug in the changes made on the feature-logical-cluster
-			// Kubernetes branch to support legacy schema resources added as CRDs.
-			// If this is confirmed, this test will be removed when the CRD bug is fixed.
-			return nil, err
-		} else {
-			return nil, err
-		}
+	// Block syncer start on the first gvr discovery completing successfully.
+	// Resource types that are not published yet are picked up by the next discoveries.
+	klog.Infof("Attempting to retrieve GVRs from upstream clusterName %s (for pcluster %s) through %s", cfg.KCPClusterName, cfg.SyncTargetName, syncerVirtualWorkspaceURL)
+	if err := syncerInformers.Start(ctx); err != nil {
+		return err
 	}
-	// TODO(jmprusi): Added Configmaps and Secrets to the default syncing, but we should figure out
-	//                a way to avoid doing that: https://github.com/kcp-dev/kcp/issues/727
-	gvrstrs := sets.NewString("configmaps.v1.", "secrets.v1.") // A syncer should always watch secrets and configmaps.
-	for _, r := range rs {
-		// v1 -> v1.
-		// apps/v1 -> v1.apps
-		// tekton.dev/v1beta1 -> v1beta1.tekton.dev
-		groupVersion, err := schema.ParseGroupVersion(r.GroupVersion)
-		if err != nil {
-			klog.Warningf("Unable to parse GroupVersion %s : %v", r.GroupVersion, err)
-			continue
-		}
-		vr := groupVersion.Version + "." + groupVersion.Group
-		for _, ai := range r.APIResources {
-			var willBeSynced string
-			groupResource := schema.GroupResource{
-				Group:    groupVersion.Group,
-				Resource: ai.Name,
+	syncCtx, cancel := context.WithTimeout(ctx, cacheSyncTimeout)
+	defer cancel()
+	for gvr, synced := range syncerInformers.WaitForCacheSync(syncCtx.Done()) {
+		if !synced {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-
-			if toSyncSet.Has(groupResource.String()) {
-				willBeSynced = groupResource.String()
-			} else if toSyncSet.Has(ai.Name) {
-				willBeSynced = ai.Name
-			} else {
-				// We're not interested in this resource type
-				continue
-			}
-			if strings.Contains(ai.Name, "/") {
-				// foo/status, pods/exec, namespace/finalize, etc.
-				continue
-			}
-			if !ai.Namespaced {
-				// Ignore cluster-scoped things.
-				continue
-			}
-			if !contains(ai.Verbs, "watch") {
-				klog.Infof("resource %s %s is not watchable: %v", vr, ai.Name, ai.Verbs)
-				continue
-			}
-			gvrstrs.Insert(fmt.Sprintf("%s.%s", ai.Name, vr))
-			willBeSyncedSet.Insert(willBeSynced)
+			return fmt.Errorf("timed out waiting for the informers of %s to sync", gvr)
 		}
 	}
 
-	notFoundResourceTypes := toSyncSet.Difference(willBeSyncedSet)
-	if notFoundResourceTypes.Len() != 0 {
-		// Some of the API resources expected to be there are still not published by KCP.
-		// We should just retry without a limit on the number of retries in such a case,
-		// until the corresponding resources are added inside KCP as CRDs and published as API resources.
-		return nil, fmt.Errorf("the following resource types were requested to be synced, but were not found in the KCP logical cluster: %v", notFoundResourceTypes.List())
-	}
+	go specSyncer.Start(ctx, numSyncerThreads)
+	go statusSyncer.Start(ctx, numSyncerThreads)
 
-	gvrs := make([]schema.GroupVersionResource, 0, gvrstrs.Len())
-	for _, gvrstr := range gvrstrs.List() {
-		gvr, _ := schema.ParseResourceArg(gvrstr)
-		if gvr == nil {
-			klog.Warningf("Unable to parse resource %q as <resource>.<version>.<group>", gvrstr)
-			continue
-		}
-		gvrs = append(gvrs, *gvr)
-	}
-	return gvrs, nil
+	return nil
 }
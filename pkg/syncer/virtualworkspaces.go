@@ -0,0 +1,329 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+)
+
+const virtualWorkspacesControllerName = "kcp-workload-syncer-virtualworkspaces"
+
+// startSyncersFunc starts a spec and a status syncer against the given syncer virtual workspace URL.
+// It blocks until the syncers are started, which are then stopped when ctx is done.
+type startSyncersFunc func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, virtualWorkspaceURL string) error
+
+// virtualWorkspaceSyncers tracks the spec and status syncers started for a syncer virtual workspace URL.
+type virtualWorkspaceSyncers struct {
+	cancel   context.CancelFunc
+	starting bool
+	started  bool
+	// err is the error of the last start attempt. It is kept while retrying, and cleared once the syncers are started.
+	err error
+	// retryAt is the time after which syncers that failed to start are started again.
+	retryAt time.Time
+}
+
+// virtualWorkspacesController watches the SyncTarget of the syncer, and starts a pair of spec and
+// status syncers for each syncer virtual workspace URL found in the SyncTarget status. The syncers
+// are stopped when their URL disappears from the status. The health of the syncers is reported in
+// the SyncerReady condition of the SyncTarget.
+type virtualWorkspacesController struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClient         kcpclient.Interface
+	syncTargetIndexer cache.Indexer
+	startSyncers      startSyncersFunc
+	// startBackoff computes the delay before starting again the syncers of a virtual workspace URL that failed to start.
+	startBackoff workqueue.RateLimiter
+	// startSlots limits the number of virtual workspace URLs whose syncers are starting at the same time.
+	// It is nil if there is no limit.
+	startSlots chan struct{}
+
+	lock    sync.Mutex
+	syncers map[string]*virtualWorkspaceSyncers
+}
+
+func newVirtualWorkspacesController(kcpClient kcpclient.Interface, syncTargetInformer workloadinformers.SyncTargetInformer, startSyncers startSyncersFunc, backoffOptions BackoffOptions) *virtualWorkspacesController {
+	c := &virtualWorkspacesController{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), virtualWorkspacesControllerName),
+
+		kcpClient:         kcpClient,
+		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
+		startSyncers:      startSyncers,
+		startBackoff:      workqueue.NewItemExponentialFailureRateLimiter(backoffOptions.RetryInitialInterval, backoffOptions.RetryMaxInterval),
+
+		syncers: map[string]*virtualWorkspaceSyncers{},
+	}
+	if backoffOptions.MaxConcurrentReconnects > 0 {
+		c.startSlots = make(chan struct{}, backoffOptions.MaxConcurrentReconnects)
+	}
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueSyncTarget(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueSyncTarget(obj) },
+		DeleteFunc: func(obj interface{}) {},
+	})
+
+	return c
+}
+
+func (c *virtualWorkspacesController) enqueueSyncTarget(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Start starts the controller workers, and stops all the started syncers when ctx is done.
+func (c *virtualWorkspacesController) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("Starting workers", "controller", virtualWorkspacesControllerName)
+	defer klog.InfoS("Stopping workers", "controller", virtualWorkspacesControllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for url, syncers := range c.syncers {
+		syncers.cancel()
+		delete(c.syncers, url)
+	}
+}
+
+func (c *virtualWorkspacesController) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *virtualWorkspacesController) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%s failed to sync %q: %w", virtualWorkspacesControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *virtualWorkspacesController) process(ctx context.Context, key string) error {
+	obj, exists, err := c.syncTargetIndexer.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to get SyncTarget with key %q because: %v", key, err)
+		return nil
+	}
+	if !exists {
+		klog.Infof("SyncTarget with key %q was deleted", key)
+		return nil
+	}
+
+	currentSyncTarget := obj.(*workloadv1alpha1.SyncTarget)
+	newSyncTarget := c.reconcile(ctx, key, currentSyncTarget)
+
+	if reflect.DeepEqual(currentSyncTarget.Status, newSyncTarget.Status) {
+		return nil
+	}
+
+	currentSyncTargetJSON, err := json.Marshal(workloadv1alpha1.SyncTarget{
+		Status: currentSyncTarget.Status,
+	})
+	if err != nil {
+		return err
+	}
+	newSyncTargetJSON, err := json.Marshal(workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             currentSyncTarget.UID,
+			ResourceVersion: currentSyncTarget.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: newSyncTarget.Status,
+	})
+	if err != nil {
+		return err
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(currentSyncTargetJSON, newSyncTargetJSON)
+	if err != nil {
+		return err
+	}
+	if _, err := c.kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, currentSyncTarget.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+		klog.Errorf("Failed to patch the status of SyncTarget %q: %v", key, err)
+		return err
+	}
+
+	return nil
+}
+
+// reconcile starts and stops syncers according to the virtual workspace URLs of the SyncTarget status,
+// and returns a copy of the SyncTarget with an up-to-date SyncerReady condition. Syncers that failed
+// to start are started again with an exponential backoff.
+func (c *virtualWorkspacesController) reconcile(ctx context.Context, key string, syncTarget *workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	desiredURLs := sets.NewString()
+	for _, virtualWorkspace := range syncTarget.Status.VirtualWorkspaces {
+		desiredURLs.Insert(virtualWorkspace.URL)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url, syncers := range c.syncers {
+		if desiredURLs.Has(url) {
+			continue
+		}
+		klog.Infof("Stopping syncers for virtual workspace URL %s of SyncTarget %q", url, key)
+		syncers.cancel()
+		delete(c.syncers, url)
+		c.startBackoff.Forget(url)
+	}
+
+	syncTargetCopy := syncTarget.DeepCopy()
+	updateSyncerReadyCondition(syncTargetCopy, c.syncers)
+
+	for _, url := range desiredURLs.List() {
+		syncers, found := c.syncers[url]
+		if found && (syncers.starting || syncers.started || time.Now().Before(syncers.retryAt)) {
+			continue
+		}
+		if !found {
+			syncers = &virtualWorkspaceSyncers{}
+			c.syncers[url] = syncers
+		}
+
+		klog.Infof("Starting syncers for virtual workspace URL %s of SyncTarget %q", url, key)
+		syncersCtx, cancel := context.WithCancel(ctx)
+		syncers.cancel = cancel
+		syncers.starting = true
+		go c.start(syncersCtx, key, syncTarget.DeepCopy(), url, syncers)
+	}
+
+	return syncTargetCopy
+}
+
+func (c *virtualWorkspacesController) start(ctx context.Context, key string, syncTarget *workloadv1alpha1.SyncTarget, url string, syncers *virtualWorkspaceSyncers) {
+	if c.startSlots != nil {
+		select {
+		case c.startSlots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+	err := c.startSyncers(ctx, syncTarget, url)
+	if c.startSlots != nil {
+		<-c.startSlots
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if ctx.Err() != nil {
+		// The syncers have been stopped in the meantime.
+		return
+	}
+
+	syncers.starting = false
+	if err != nil {
+		klog.Errorf("Failed to start syncers for virtual workspace URL %s of SyncTarget %q: %v", url, key, err)
+		syncers.cancel()
+		syncers.err = err
+		delay := c.startBackoff.When(url)
+		syncers.retryAt = time.Now().Add(delay)
+		c.queue.Add(key)
+		c.queue.AddAfter(key, delay)
+		return
+	}
+
+	klog.Infof("Started syncers for virtual workspace URL %s of SyncTarget %q", url, key)
+	syncers.started = true
+	syncers.err = nil
+	c.startBackoff.Forget(url)
+	c.queue.Add(key)
+}
+
+// updateSyncerReadyCondition sets the SyncerReady condition of the SyncTarget from the state of the
+// syncers started for each of its virtual workspace URLs.
+func updateSyncerReadyCondition(syncTarget *workloadv1alpha1.SyncTarget, syncers map[string]*virtualWorkspaceSyncers) {
+	if len(syncTarget.Status.VirtualWorkspaces) == 0 {
+		conditions.MarkFalse(syncTarget, workloadv1alpha1.SyncerReady, workloadv1alpha1.SyncerStartingReason, conditionsv1alpha1.ConditionSeverityInfo,
+			"No syncer virtual workspace URL found in the SyncTarget status")
+		return
+	}
+
+	var failed, starting []string
+	for _, virtualWorkspace := range syncTarget.Status.VirtualWorkspaces {
+		s, found := syncers[virtualWorkspace.URL]
+		switch {
+		case found && s.err != nil:
+			failed = append(failed, fmt.Sprintf("%s: %v", virtualWorkspace.URL, s.err))
+		case !found || !s.started:
+			starting = append(starting, virtualWorkspace.URL)
+		}
+	}
+	sort.Strings(failed)
+	sort.Strings(starting)
+
+	switch {
+	case len(failed) > 0:
+		conditions.MarkFalse(syncTarget, workloadv1alpha1.SyncerReady, workloadv1alpha1.ErrorStartingSyncerReason, conditionsv1alpha1.ConditionSeverityError,
+			"Syncers failed to start for virtual workspace URLs: %s", strings.Join(failed, "; "))
+	case len(starting) > 0:
+		conditions.MarkFalse(syncTarget, workloadv1alpha1.SyncerReady, workloadv1alpha1.SyncerStartingReason, conditionsv1alpha1.ConditionSeverityInfo,
+			"Syncers are starting for virtual workspace URLs: %s", strings.Join(starting, ", "))
+	default:
+		conditions.MarkTrue(syncTarget, workloadv1alpha1.SyncerReady)
+	}
+}
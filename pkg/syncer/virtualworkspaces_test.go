@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestUpdateSyncerReadyCondition(t *testing.T) {
+	tests := map[string]struct {
+		urls            []string
+		syncers         map[string]*virtualWorkspaceSyncers
+		expectedStatus  corev1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		"no virtual workspace URL": {
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  workloadv1alpha1.SyncerStartingReason,
+			expectedMessage: "No syncer virtual workspace URL found in the SyncTarget status",
+		},
+		"syncers not started yet": {
+			urls: []string{"https://shard-1/services/syncer", "https://shard-2/services/syncer"},
+			syncers: map[string]*virtualWorkspaceSyncers{
+				"https://shard-1/services/syncer": {started: true},
+				"https://shard-2/services/syncer": {starting: true},
+			},
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  workloadv1alpha1.SyncerStartingReason,
+			expectedMessage: "Syncers are starting for virtual workspace URLs: https://shard-2/services/syncer",
+		},
+		"syncers failed to start": {
+			urls: []string{"https://shard-1/services/syncer", "https://shard-2/services/syncer", "https://shard-3/services/syncer"},
+			syncers: map[string]*virtualWorkspaceSyncers{
+				"https://shard-1/services/syncer": {started: true},
+				"https://shard-2/services/syncer": {err: errors.New("connection refused")},
+			},
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  workloadv1alpha1.ErrorStartingSyncerReason,
+			expectedMessage: "Syncers failed to start for virtual workspace URLs: https://shard-2/services/syncer: connection refused",
+		},
+		"all syncers started": {
+			urls: []string{"https://shard-1/services/syncer", "https://shard-2/services/syncer"},
+			syncers: map[string]*virtualWorkspaceSyncers{
+				"https://shard-1/services/syncer": {started: true},
+				"https://shard-2/services/syncer": {started: true},
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			syncTarget := &workloadv1alpha1.SyncTarget{}
+			for _, url := range tc.urls {
+				syncTarget.Status.VirtualWorkspaces = append(syncTarget.Status.VirtualWorkspaces, workloadv1alpha1.VirtualWorkspace{URL: url})
+			}
+
+			updateSyncerReadyCondition(syncTarget, tc.syncers)
+
+			condition := conditions.Get(syncTarget, workloadv1alpha1.SyncerReady)
+			require.NotNil(t, condition)
+			require.Equal(t, tc.expectedStatus, condition.Status)
+			require.Equal(t, tc.expectedReason, condition.Reason)
+			require.Equal(t, tc.expectedMessage, condition.Message)
+		})
+	}
+}